@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func (app *application) listAbsenceAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Status  string
+		ClassID int64
+		data.Filters
+	}
+
+	qs := r.URL.Query()
+
+	input.Status = app.readString(qs, "status", "open")
+	input.ClassID = int64(app.readInt(qs, "class_id", 0))
+	input.Filters.Page = app.readInt(qs, "page", 1)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20)
+	input.Filters.Sort = app.readString(qs, "sort", "-created_at")
+	input.Filters.SortSafelist = []string{"created_at", "absences", "last_name", "-created_at", "-absences", "-last_name"}
+
+	if input.Status != "open" && input.Status != "resolved" && input.Status != "all" {
+		app.failedValidationResponse(w, r, map[string]string{"status": "must be open, resolved or all"})
+		return
+	}
+
+	alerts, metadata, err := app.models.AbsenceAlerts.GetAll(input.Status, input.ClassID, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"alerts": alerts, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) resolveAbsenceAlertHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.AbsenceAlerts.Resolve(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "absence alert resolved successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func validateTuitionPlan(plan *data.TuitionPlan) map[string]string {
+	errs := map[string]string{}
+
+	if plan.Name == "" {
+		errs["name"] = "must be provided"
+	}
+	if plan.Frequency != "weekly" && plan.Frequency != "monthly" {
+		errs["frequency"] = "must be weekly or monthly"
+	}
+	if plan.Schedule != "full_time" && plan.Schedule != "part_time" {
+		errs["schedule"] = "must be full_time or part_time"
+	}
+	if plan.RateCents < 0 {
+		errs["rate_cents"] = "must not be negative"
+	}
+	if plan.RegistrationFeeCents < 0 {
+		errs["registration_fee_cents"] = "must not be negative"
+	}
+
+	return errs
+}
+
+func (app *application) createTuitionPlanHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name                 string `json:"name"`
+		Frequency            string `json:"frequency"`
+		Schedule             string `json:"schedule"`
+		RateCents            int64  `json:"rate_cents"`
+		RegistrationFeeCents int64  `json:"registration_fee_cents"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	plan := &data.TuitionPlan{
+		Name:                 strings.TrimSpace(input.Name),
+		Frequency:            input.Frequency,
+		Schedule:             input.Schedule,
+		RateCents:            input.RateCents,
+		RegistrationFeeCents: input.RegistrationFeeCents,
+		Active:               true,
+	}
+
+	if errs := validateTuitionPlan(plan); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.TuitionPlans.Insert(plan)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/billing/plans/%d", plan.PlanID))
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"plan": plan}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listTuitionPlansHandler(w http.ResponseWriter, r *http.Request) {
+	activeOnly := app.readString(r.URL.Query(), "all", "false") != "true"
+
+	plans, err := app.models.TuitionPlans.GetAll(activeOnly)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"plans": plans}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showTuitionPlanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	plan, err := app.models.TuitionPlans.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"plan": plan}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateTuitionPlanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	plan, err := app.models.TuitionPlans.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Name                 *string `json:"name"`
+		Frequency            *string `json:"frequency"`
+		Schedule             *string `json:"schedule"`
+		RateCents            *int64  `json:"rate_cents"`
+		RegistrationFeeCents *int64  `json:"registration_fee_cents"`
+		Active               *bool   `json:"active"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Name != nil {
+		plan.Name = strings.TrimSpace(*input.Name)
+	}
+
+	if input.Frequency != nil {
+		plan.Frequency = *input.Frequency
+	}
+
+	if input.Schedule != nil {
+		plan.Schedule = *input.Schedule
+	}
+
+	if input.RateCents != nil {
+		plan.RateCents = *input.RateCents
+	}
+
+	if input.RegistrationFeeCents != nil {
+		plan.RegistrationFeeCents = *input.RegistrationFeeCents
+	}
+
+	if input.Active != nil {
+		plan.Active = *input.Active
+	}
+
+	if errs := validateTuitionPlan(plan); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.TuitionPlans.Update(plan)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"plan": plan}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createStudentPlanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		PlanID   int64      `json:"plan_id"`
+		StartsOn *data.Date `json:"starts_on"`
+		EndsOn   *data.Date `json:"ends_on"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	studentPlan := &data.StudentPlan{
+		StudentID: id,
+		PlanID:    input.PlanID,
+		StartsOn:  data.Date(time.Now()),
+		EndsOn:    input.EndsOn,
+	}
+	if input.StartsOn != nil {
+		studentPlan.StartsOn = *input.StartsOn
+	}
+
+	errs := map[string]string{}
+	if studentPlan.EndsOn != nil && time.Time(*studentPlan.EndsOn).Before(time.Time(studentPlan.StartsOn)) {
+		errs["ends_on"] = "must not be before starts_on"
+	}
+
+	plan, err := app.models.TuitionPlans.Get(studentPlan.PlanID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		errs["plan_id"] = "must be an existing tuition plan"
+	case err != nil:
+		app.serverErrorResponse(w, r, err)
+		return
+	case !plan.Active:
+		errs["plan_id"] = "must be a tuition plan that is still offered"
+	}
+
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	_, err = app.models.Students.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.StudentPlans.Insert(studentPlan)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	studentPlan.PlanName = plan.Name
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"student_plan": studentPlan}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listStudentPlansHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	plans, err := app.models.StudentPlans.GetAllByStudentID(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"student_plans": plans}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateStudentPlanHandler changes the dates of a student's plan, usually to end it when the
+// student leaves or switches to another plan.
+func (app *application) updateStudentPlanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	studentPlanID, err := strconv.ParseInt(chi.URLParam(r, "studentPlanID"), 10, 64)
+	if err != nil || studentPlanID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	studentPlan, err := app.models.StudentPlans.Get(id, studentPlanID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		StartsOn *data.Date `json:"starts_on"`
+		EndsOn   *data.Date `json:"ends_on"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.StartsOn != nil {
+		studentPlan.StartsOn = *input.StartsOn
+	}
+
+	if input.EndsOn != nil {
+		studentPlan.EndsOn = input.EndsOn
+	}
+
+	if studentPlan.EndsOn != nil && time.Time(*studentPlan.EndsOn).Before(time.Time(studentPlan.StartsOn)) {
+		app.failedValidationResponse(w, r, map[string]string{"ends_on": "must not be before starts_on"})
+		return
+	}
+
+	err = app.models.StudentPlans.Update(studentPlan)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"student_plan": studentPlan}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listInvoicesHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	status := app.readString(qs, "status", "")
+	if status != "" && status != "open" && status != "paid" && status != "void" {
+		app.failedValidationResponse(w, r, map[string]string{"status": "must be open, paid or void"})
+		return
+	}
+
+	invoices, err := app.models.Invoices.GetAll(int64(app.readInt(qs, "guardian_id", 0)), status)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"invoices": invoices}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	invoice, err := app.models.Invoices.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"invoice": invoice}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func validateDiscountRule(rule *data.DiscountRule) map[string]string {
+	errs := map[string]string{}
+
+	if rule.Name == "" {
+		errs["name"] = "must be provided"
+	}
+	if rule.AppliesTo != "sibling" && rule.AppliesTo != "staff" {
+		errs["applies_to"] = "must be sibling or staff"
+	}
+	switch rule.Calculation {
+	case "percent":
+		if rule.Value < 1 || rule.Value > 100 {
+			errs["value"] = "must be a percentage between 1 and 100"
+		}
+	case "fixed":
+		if rule.Value < 1 {
+			errs["value"] = "must be more than zero"
+		}
+	default:
+		errs["calculation"] = "must be percent or fixed"
+	}
+	if rule.FromChild < 2 {
+		errs["from_child"] = "must be at least 2"
+	}
+
+	return errs
+}
+
+func (app *application) createDiscountRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name        string `json:"name"`
+		AppliesTo   string `json:"applies_to"`
+		Calculation string `json:"calculation"`
+		Value       int64  `json:"value"`
+		FromChild   *int   `json:"from_child"`
+		Stackable   bool   `json:"stackable"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	rule := &data.DiscountRule{
+		Name:        strings.TrimSpace(input.Name),
+		AppliesTo:   input.AppliesTo,
+		Calculation: input.Calculation,
+		Value:       input.Value,
+		FromChild:   2,
+		Stackable:   input.Stackable,
+		Active:      true,
+	}
+	if input.FromChild != nil {
+		rule.FromChild = *input.FromChild
+	}
+
+	if errs := validateDiscountRule(rule); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.DiscountRules.Insert(rule)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/billing/discounts/%d", rule.RuleID))
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"discount": rule}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listDiscountRulesHandler(w http.ResponseWriter, r *http.Request) {
+	activeOnly := app.readString(r.URL.Query(), "all", "false") != "true"
+
+	rules, err := app.models.DiscountRules.GetAll(activeOnly)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"discounts": rules}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showDiscountRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	rule, err := app.models.DiscountRules.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"discount": rule}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateDiscountRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	rule, err := app.models.DiscountRules.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Name        *string `json:"name"`
+		AppliesTo   *string `json:"applies_to"`
+		Calculation *string `json:"calculation"`
+		Value       *int64  `json:"value"`
+		FromChild   *int    `json:"from_child"`
+		Stackable   *bool   `json:"stackable"`
+		Active      *bool   `json:"active"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Name != nil {
+		rule.Name = strings.TrimSpace(*input.Name)
+	}
+
+	if input.AppliesTo != nil {
+		rule.AppliesTo = *input.AppliesTo
+	}
+
+	if input.Calculation != nil {
+		rule.Calculation = *input.Calculation
+	}
+
+	if input.Value != nil {
+		rule.Value = *input.Value
+	}
+
+	if input.FromChild != nil {
+		rule.FromChild = *input.FromChild
+	}
+
+	if input.Stackable != nil {
+		rule.Stackable = *input.Stackable
+	}
+
+	if input.Active != nil {
+		rule.Active = *input.Active
+	}
+
+	if errs := validateDiscountRule(rule); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.DiscountRules.Update(rule)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"discount": rule}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/billing"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/events"
+)
+
+// TestRebillVoidedPeriod voids a family's invoice and checks that billing the period again
+// invoices everything on it once more, registration and late pickup fees included.
+func TestRebillVoidedPeriod(t *testing.T) {
+	db := openTestDB(t)
+	models := data.NewModels(db, events.NewHub(1))
+	period := billing.MonthOf(time.Date(2031, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	student := &data.Student{FirstName: "Ada", LastName: "Lovelace", Gender: "female", DateOfBirth: data.Date(time.Date(2028, 3, 1, 0, 0, 0, 0, time.UTC))}
+	guardian := &data.Guardian{FirstName: "Anne", LastName: "Lovelace", Gender: "female", Relationship: "mother", Contact: "555-0101"}
+
+	err := models.Students.InsertWithGuardian(student, guardian)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &data.TuitionPlan{Name: "Full time", Frequency: "monthly", Schedule: "full_time", RateCents: 62000, RegistrationFeeCents: 5000, Active: true}
+
+	err = models.TuitionPlans.Insert(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := &data.LateFeeRule{EffectiveFrom: data.Date(period.Start), ClosesAt: "18:00", Charge: "per_minute", BlockMinutes: 1, RateCents: 100}
+
+	err = models.LateFeeRules.Insert(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var familyID int64
+	err = db.QueryRow(`SELECT family_id FROM students WHERE student_id = $1`, student.StudentID).Scan(&familyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM invoices WHERE guardian_id = $1`, familyID)
+		db.Exec(`DELETE FROM student_plans WHERE student_id = $1`, student.StudentID)
+		db.Exec(`DELETE FROM tuition_plans WHERE plan_id = $1`, plan.PlanID)
+		db.Exec(`DELETE FROM students WHERE student_id = $1`, student.StudentID)
+		db.Exec(`DELETE FROM late_fee_rules WHERE rule_id = $1`, rule.RuleID)
+	})
+
+	err = models.StudentPlans.Insert(&data.StudentPlan{StudentID: student.StudentID, PlanID: plan.PlanID, StartsOn: data.Date(period.Start)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fee := &data.LatePickupFee{
+		StudentID:    student.StudentID,
+		ClassDate:    data.Date(period.Start.AddDate(0, 0, 9)),
+		RuleID:       rule.RuleID,
+		CheckedOutAt: period.Start.AddDate(0, 0, 9).Add(18*time.Hour + 15*time.Minute),
+		MinutesLate:  15,
+		AmountCents:  1500,
+	}
+
+	_, err = models.LateFees.Insert(fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := billFamily(t, models, familyID, period)
+	if first.TotalCents != 68500 {
+		t.Fatalf("first invoice comes to %d, want 68500", first.TotalCents)
+	}
+
+	err = models.Invoices.Void(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second := billFamily(t, models, familyID, period)
+	if second.InvoiceID == first.InvoiceID {
+		t.Fatalf("billing the voided period again saved no new invoice")
+	}
+	if second.TotalCents != first.TotalCents || len(second.Lines) != len(first.Lines) {
+		t.Errorf("second invoice has %d lines coming to %d, want %d lines coming to %d",
+			len(second.Lines), second.TotalCents, len(first.Lines), first.TotalCents)
+	}
+
+	// With the period invoiced again, nothing is left to bill.
+	plans, err := models.StudentPlans.GetBillable(period.Start, period.End)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plan := range plans {
+		if plan.StudentID == student.StudentID && !plan.RegistrationBilled {
+			t.Errorf("the registration fee is still unbilled after the period was invoiced again")
+		}
+	}
+
+	fees, err := models.LateFees.GetBillable(period.End)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, billable := range fees {
+		if billable.FeeID == fee.FeeID {
+			t.Errorf("the late pickup fee is still unbilled after the period was invoiced again")
+		}
+	}
+}
+
+// billFamily runs billing for the period for the family alone and returns the invoice it saved.
+func billFamily(t *testing.T, models data.Models, familyID int64, period billing.Period) *data.Invoice {
+	t.Helper()
+
+	plans, err := models.StudentPlans.GetBillable(period.Start, period.End)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fees, err := models.LateFees.GetBillable(period.End)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	familyPlans := []*data.BillablePlan{}
+	for _, plan := range plans {
+		if plan.GuardianID == familyID {
+			familyPlans = append(familyPlans, plan)
+		}
+	}
+
+	familyFees := []*data.BillableLateFee{}
+	for _, fee := range fees {
+		if fee.GuardianID == familyID {
+			familyFees = append(familyFees, fee)
+		}
+	}
+
+	invoices, _, _ := billing.BuildInvoices(familyPlans, familyFees, nil, period, period.Start)
+	if len(invoices) != 1 {
+		t.Fatalf("got %d invoices for the family, want 1", len(invoices))
+	}
+
+	err = models.Invoices.Insert(invoices[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return invoices[0]
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func (app *application) listClassFacultyHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	currentOnly := app.readString(r.URL.Query(), "all", "false") != "true"
+
+	assignments, err := app.models.ClassFaculty.GetAllByClassID(classID, currentOnly)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"faculty": assignments}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createClassFacultyHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		FacultyID int64      `json:"faculty_id"`
+		Role      string     `json:"role"`
+		StartsOn  *data.Date `json:"starts_on"`
+		EndsOn    *data.Date `json:"ends_on"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	assignment := &data.ClassFaculty{
+		ClassID:   classID,
+		FacultyID: input.FacultyID,
+		Role:      input.Role,
+		StartsOn:  data.Date(time.Now()),
+		EndsOn:    input.EndsOn,
+	}
+	if input.StartsOn != nil {
+		assignment.StartsOn = *input.StartsOn
+	}
+
+	errs := map[string]string{}
+	if assignment.Role != "lead" && assignment.Role != "assistant" && assignment.Role != "substitute" {
+		errs["role"] = "must be lead, assistant or substitute"
+	}
+	if assignment.EndsOn != nil && time.Time(*assignment.EndsOn).Before(time.Time(assignment.StartsOn)) {
+		errs["ends_on"] = "must not be before starts_on"
+	}
+
+	faculty, err := app.models.Faculty.Get(assignment.FacultyID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		errs["faculty_id"] = "must be an existing faculty member"
+	case err != nil:
+		app.serverErrorResponse(w, r, err)
+		return
+	case !faculty.Active:
+		errs["faculty_id"] = "must be an active faculty member"
+	}
+
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	_, err = app.models.Classes.Get(classID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.ClassFaculty.Insert(assignment)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	assignment.FirstName = faculty.FirstName
+	assignment.LastName = faculty.LastName
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"assignment": assignment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteClassFacultyHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	assignmentID, err := strconv.ParseInt(chi.URLParam(r, "assignmentID"), 10, 64)
+	if err != nil || assignmentID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.ClassFaculty.Delete(classID, assignmentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrLastLeadTeacher):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "faculty member removed from class successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
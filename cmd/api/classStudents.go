@@ -112,6 +112,7 @@ func (app *application) addStudentAttendance(w http.ResponseWriter, r *http.Requ
 
 	var input struct {
 		Present bool `json:"present"`
+		Excused bool `json:"excused"`
 	}
 
 	err = app.readJSON(w, r, &input)
@@ -124,7 +125,8 @@ func (app *application) addStudentAttendance(w http.ResponseWriter, r *http.Requ
 		ClassID:   classID,
 		StudentID: studentID,
 		Present:   input.Present,
-		ClassDate: data.Date(time.Now()),
+		Excused:   input.Excused,
+		ClassDate: data.Date(time.Now().In(app.cfg.location)),
 	}
 
 	err = app.models.StudentAttendance.Insert(studentAttendance)
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+type contextKey string
+
+const (
+	facultyContextKey     = contextKey("faculty")
+	kioskDeviceContextKey = contextKey("kioskDevice")
+)
+
+func (app *application) contextSetFaculty(r *http.Request, faculty *data.Faculty) *http.Request {
+	ctx := context.WithValue(r.Context(), facultyContextKey, faculty)
+	return r.WithContext(ctx)
+}
+
+// contextGetFaculty returns the faculty member stored by requireAuthenticatedFaculty. It should
+// only be called from handlers behind that middleware, so a missing value is a programming error.
+func (app *application) contextGetFaculty(r *http.Request) *data.Faculty {
+	faculty, ok := r.Context().Value(facultyContextKey).(*data.Faculty)
+	if !ok {
+		panic("missing faculty value in request context")
+	}
+
+	return faculty
+}
+
+func (app *application) contextSetKioskDevice(r *http.Request, device *data.KioskDevice) *http.Request {
+	ctx := context.WithValue(r.Context(), kioskDeviceContextKey, device)
+	return r.WithContext(ctx)
+}
+
+func (app *application) contextGetKioskDevice(r *http.Request) *data.KioskDevice {
+	device, ok := r.Context().Value(kioskDeviceContextKey).(*data.KioskDevice)
+	if !ok {
+		panic("missing kiosk device value in request context")
+	}
+
+	return device
+}
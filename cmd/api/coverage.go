@@ -0,0 +1,256 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// createCoverageRequestHandler asks for a substitute to cover a class for a while. Faculty can
+// ask for someone to cover their class or offer to cover one themselves, and the grant only
+// takes effect once an admin approves it.
+func (app *application) createCoverageRequestHandler(w http.ResponseWriter, r *http.Request) {
+	faculty := app.contextGetFaculty(r)
+
+	var input struct {
+		ClassID   int64     `json:"class_id"`
+		FacultyID int64     `json:"faculty_id"`
+		StartsAt  time.Time `json:"starts_at"`
+		EndsAt    time.Time `json:"ends_at"`
+		Reason    string    `json:"reason"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.FacultyID == 0 {
+		input.FacultyID = faculty.FacultyID
+	}
+
+	errs := map[string]string{}
+	if !input.EndsAt.After(input.StartsAt) {
+		errs["ends_at"] = "must be after starts_at"
+	}
+
+	substitute, err := app.models.Faculty.Get(input.FacultyID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		errs["faculty_id"] = "must be an existing faculty member"
+	case err != nil:
+		app.serverErrorResponse(w, r, err)
+		return
+	case !substitute.Active:
+		errs["faculty_id"] = "must be an active faculty member"
+	}
+
+	class, err := app.models.Classes.Get(input.ClassID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		errs["class_id"] = "must be an existing class"
+	case err != nil:
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	grant := &data.CoverageGrant{
+		ClassID:     input.ClassID,
+		FacultyID:   input.FacultyID,
+		StartsAt:    input.StartsAt,
+		EndsAt:      input.EndsAt,
+		Reason:      input.Reason,
+		RequestedBy: &faculty.FacultyID,
+	}
+
+	err = app.models.Coverage.Insert(grant)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	adminIDs, err := app.models.Faculty.GetAdminIDs()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	for _, adminID := range adminIDs {
+		notification := &data.Notification{
+			FacultyID: adminID,
+			Subject:   fmt.Sprintf("Coverage requested for %s", class.ClassName),
+			Message: fmt.Sprintf("%s %s is to cover %s from %s to %s and needs approval.",
+				substitute.FirstName, substitute.LastName, class.ClassName,
+				grant.StartsAt.Format(time.RFC1123), grant.EndsAt.Format(time.RFC1123)),
+		}
+
+		err := app.models.Notifications.Insert(notification)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/coverage/%d", grant.GrantID))
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"coverage": grant}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listCoverageHandler lists coverage grants. Admins see every grant, other faculty only the ones
+// they requested or are the substitute on.
+func (app *application) listCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	faculty := app.contextGetFaculty(r)
+	qs := r.URL.Query()
+
+	status := app.readString(qs, "status", "")
+	if status != "" && status != "pending" && status != "approved" && status != "rejected" {
+		app.failedValidationResponse(w, r, map[string]string{"status": "must be pending, approved or rejected"})
+		return
+	}
+
+	facultyID := faculty.FacultyID
+	if faculty.IsAdmin {
+		facultyID = 0
+	}
+
+	grants, err := app.models.Coverage.GetAll(status, int64(app.readInt(qs, "class_id", 0)), facultyID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"coverage": grants}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showCoverageHandler shows a coverage grant to admins and to the faculty who requested it or
+// are the substitute on it.
+func (app *application) showCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	grant, err := app.models.Coverage.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	faculty := app.contextGetFaculty(r)
+	requester := grant.RequestedBy != nil && *grant.RequestedBy == faculty.FacultyID
+	if !faculty.IsAdmin && !requester && grant.FacultyID != faculty.FacultyID {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"coverage": grant}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) approveCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	app.decideCoverage(w, r, "approved")
+}
+
+func (app *application) rejectCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	app.decideCoverage(w, r, "rejected")
+}
+
+// decideCoverage approves or rejects a pending coverage grant and lets the substitute know.
+func (app *application) decideCoverage(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	grant, err := app.models.Coverage.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Coverage.Decide(grant, status, app.contextGetFaculty(r).FacultyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrGrantNotPending):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	class, err := app.models.Classes.Get(grant.ClassID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	notification := &data.Notification{
+		FacultyID: grant.FacultyID,
+		Subject:   fmt.Sprintf("Coverage of %s %s", class.ClassName, status),
+		Message: fmt.Sprintf("Your coverage of %s from %s to %s has been %s.",
+			class.ClassName, grant.StartsAt.Format(time.RFC1123), grant.EndsAt.Format(time.RFC1123), status),
+	}
+
+	err = app.models.Notifications.Insert(notification)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"coverage": grant}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listCoverageAuditHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	audit, err := app.models.Coverage.GetAudit(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"audit": audit}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
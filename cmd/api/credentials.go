@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func validateCredential(credential *data.FacultyCredential) map[string]string {
+	errs := map[string]string{}
+
+	if credential.Type == "" {
+		errs["type"] = "must be provided"
+	}
+	if time.Time(credential.IssuedOn).IsZero() {
+		errs["issued_on"] = "must be provided"
+	}
+	if credential.ExpiresOn != nil && time.Time(*credential.ExpiresOn).Before(time.Time(credential.IssuedOn)) {
+		errs["expires_on"] = "must not be before issued_on"
+	}
+
+	return errs
+}
+
+func (app *application) createCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Type        string     `json:"type"`
+		IssuedOn    data.Date  `json:"issued_on"`
+		ExpiresOn   *data.Date `json:"expires_on"`
+		DocumentRef string     `json:"document_ref"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	credential := &data.FacultyCredential{
+		FacultyID:   id,
+		Type:        strings.ToLower(strings.TrimSpace(input.Type)),
+		IssuedOn:    input.IssuedOn,
+		ExpiresOn:   input.ExpiresOn,
+		DocumentRef: input.DocumentRef,
+	}
+
+	if errs := validateCredential(credential); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	_, err = app.models.Faculty.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Credentials.Insert(credential)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/faculty/%d/credentials", id))
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"credential": credential}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listCredentialsHandler lists the credentials of a faculty member, which they can see for
+// themselves and admins can see for anyone.
+func (app *application) listCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	faculty := app.contextGetFaculty(r)
+	if faculty.FacultyID != id && !faculty.IsAdmin {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	credentials, err := app.models.Credentials.GetAllByFacultyID(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"credentials": credentials}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	credentialID, err := strconv.ParseInt(chi.URLParam(r, "credentialID"), 10, 64)
+	if err != nil || credentialID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	credential, err := app.models.Credentials.Get(id, credentialID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Type        *string    `json:"type"`
+		IssuedOn    *data.Date `json:"issued_on"`
+		ExpiresOn   *data.Date `json:"expires_on"`
+		DocumentRef *string    `json:"document_ref"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Type != nil {
+		credential.Type = strings.ToLower(strings.TrimSpace(*input.Type))
+	}
+
+	if input.IssuedOn != nil {
+		credential.IssuedOn = *input.IssuedOn
+	}
+
+	if input.ExpiresOn != nil {
+		credential.ExpiresOn = input.ExpiresOn
+	}
+
+	if input.DocumentRef != nil {
+		credential.DocumentRef = *input.DocumentRef
+	}
+
+	if errs := validateCredential(credential); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Credentials.Update(credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"credential": credential}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	credentialID, err := strconv.ParseInt(chi.URLParam(r, "credentialID"), 10, 64)
+	if err != nil || credentialID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Credentials.Delete(id, credentialID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "credential deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// complianceReportHandler lists the active faculty who are missing a current credential of one
+// of the required types, as of today or the "on" query string date.
+func (app *application) complianceReportHandler(w http.ResponseWriter, r *http.Request) {
+	on := time.Now()
+	if s := r.URL.Query().Get("on"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			app.failedValidationResponse(w, r, map[string]string{"on": "must be a date in the format YYYY-MM-DD"})
+			return
+		}
+		on = t
+	}
+
+	faculty, err := app.models.Credentials.GetNonCompliant(app.cfg.credentials.required, on)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{
+		"on":                   data.Date(on),
+		"required_credentials": app.cfg.credentials.required,
+		"non_compliant":        faculty,
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
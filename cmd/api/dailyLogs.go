@@ -0,0 +1,454 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/dailylog"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// readDay returns the start of the day in the "date" query string, or of today if there is none.
+func (app *application) readDay(r *http.Request) (time.Time, error) {
+	s := r.URL.Query().Get("date")
+	if s == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+// validateDailyLogEntry checks that the entry has what its kind needs, and nothing that only
+// makes sense for other kinds.
+func validateDailyLogEntry(entry *data.DailyLogEntry, errs map[string]string) {
+	if !slices.Contains(dailylog.Kinds, entry.Kind) {
+		errs["kind"] = "must be meal, nap, diaper, toilet, mood or note"
+		return
+	}
+
+	if entry.OccurredAt.IsZero() {
+		errs["occurred_at"] = "must be provided"
+	} else if entry.OccurredAt.After(time.Now()) {
+		errs["occurred_at"] = "must not be in the future"
+	}
+
+	switch {
+	case entry.EndedAt == nil:
+	case entry.Kind != "nap":
+		errs["ended_at"] = "must only be provided for naps"
+	case entry.EndedAt.Before(entry.OccurredAt):
+		errs["ended_at"] = "must not be before occurred_at"
+	case entry.EndedAt.After(time.Now()):
+		errs["ended_at"] = "must not be in the future"
+	}
+
+	switch {
+	case entry.Amount == nil:
+	case entry.Kind != "meal":
+		errs["amount"] = "must only be provided for meals"
+	case !slices.Contains(dailylog.Amounts, *entry.Amount):
+		errs["amount"] = "must be none, some, most or all"
+	}
+
+	switch entry.Kind {
+	case "diaper", "toilet":
+		if !slices.Contains(dailylog.Results, entry.Details) {
+			errs["details"] = "must be wet, bm, wet_bm or dry"
+		}
+	case "mood":
+		if !slices.Contains(dailylog.Moods, entry.Details) {
+			errs["details"] = "must be happy, content, tired, fussy, sad or unwell"
+		}
+	case "note":
+		if entry.Notes == "" {
+			errs["notes"] = "must be provided"
+		}
+	}
+}
+
+type dailyLogInput struct {
+	Kind       string     `json:"kind"`
+	OccurredAt *time.Time `json:"occurred_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	Amount     *string    `json:"amount"`
+	Details    string     `json:"details"`
+	Notes      string     `json:"notes"`
+}
+
+// entry returns the entry the input describes for the student, which occurred now unless the
+// input says otherwise.
+func (input dailyLogInput) entry(studentID, recordedBy int64) *data.DailyLogEntry {
+	entry := &data.DailyLogEntry{
+		StudentID:  studentID,
+		Kind:       strings.TrimSpace(input.Kind),
+		OccurredAt: time.Now().Truncate(time.Second),
+		EndedAt:    input.EndedAt,
+		Amount:     input.Amount,
+		Details:    strings.TrimSpace(input.Details),
+		Notes:      strings.TrimSpace(input.Notes),
+		RecordedBy: &recordedBy,
+	}
+	if input.OccurredAt != nil {
+		entry.OccurredAt = *input.OccurredAt
+	}
+	return entry
+}
+
+func (app *application) createDailyLogEntryHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	var input dailyLogInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	entry := input.entry(studentID, app.contextGetFaculty(r).FacultyID)
+
+	errs := map[string]string{}
+	validateDailyLogEntry(entry, errs)
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.DailyLogs.Insert(entry)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"entry": entry}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showDailyLogHandler shows the student's entries and summary for today or the "date" query
+// string day.
+func (app *application) showDailyLogHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	day, err := app.readDay(r)
+	if err != nil {
+		app.failedValidationResponse(w, r, map[string]string{"date": "must be a date in the format YYYY-MM-DD"})
+		return
+	}
+
+	student, err := app.models.Students.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	entries, err := app.models.DailyLogs.GetAllByStudentID(student.StudentID, day)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{
+		"entries": entries,
+		"summary": dailylog.Summarize(student, entries, day, time.Now()),
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateDailyLogEntryHandler changes an entry, most often to record when a nap ended.
+func (app *application) updateDailyLogEntryHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	entryID, err := strconv.ParseInt(chi.URLParam(r, "entryID"), 10, 64)
+	if err != nil || entryID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	entry, err := app.models.DailyLogs.Get(studentID, entryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		OccurredAt *time.Time `json:"occurred_at"`
+		EndedAt    *time.Time `json:"ended_at"`
+		Amount     *string    `json:"amount"`
+		Details    *string    `json:"details"`
+		Notes      *string    `json:"notes"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.OccurredAt != nil {
+		entry.OccurredAt = *input.OccurredAt
+	}
+	if input.EndedAt != nil {
+		entry.EndedAt = input.EndedAt
+	}
+	if input.Amount != nil {
+		entry.Amount = input.Amount
+	}
+	if input.Details != nil {
+		entry.Details = strings.TrimSpace(*input.Details)
+	}
+	if input.Notes != nil {
+		entry.Notes = strings.TrimSpace(*input.Notes)
+	}
+
+	errs := map[string]string{}
+	validateDailyLogEntry(entry, errs)
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.DailyLogs.Update(entry)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"entry": entry}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteDailyLogEntryHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	entryID, err := strconv.ParseInt(chi.URLParam(r, "entryID"), 10, 64)
+	if err != nil || entryID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.DailyLogs.Delete(studentID, entryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "daily log entry deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createClassDailyLogHandler records the same entry, such as lunch or nap time, for students of
+// the class at once. Every student in the class gets it unless student_ids picks some of them.
+func (app *application) createClassDailyLogHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		dailyLogInput
+		StudentIDs []int64 `json:"student_ids"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	students, err := app.models.ClassStudents.GetStudentsByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	errs := map[string]string{}
+	for _, id := range input.StudentIDs {
+		if !slices.ContainsFunc(students, func(s *data.Student) bool { return s.StudentID == id }) {
+			errs["student_ids"] = "must be students in the class"
+			break
+		}
+	}
+
+	recordedBy := app.contextGetFaculty(r).FacultyID
+	entries := []*data.DailyLogEntry{}
+	for _, student := range students {
+		if len(input.StudentIDs) > 0 && !slices.Contains(input.StudentIDs, student.StudentID) {
+			continue
+		}
+		entries = append(entries, input.entry(student.StudentID, recordedBy))
+	}
+
+	if len(entries) == 0 {
+		errs["student_ids"] = "must have at least one student in the class"
+	} else {
+		validateDailyLogEntry(entries[0], errs)
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.DailyLogs.InsertMany(entries)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"entries": entries}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showClassDailyLogHandler shows the summaries of every student in the class for today or the
+// "date" query string day.
+func (app *application) showClassDailyLogHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	day, err := app.readDay(r)
+	if err != nil {
+		app.failedValidationResponse(w, r, map[string]string{"date": "must be a date in the format YYYY-MM-DD"})
+		return
+	}
+
+	students, err := app.models.ClassStudents.GetStudentsByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	entries, err := app.models.DailyLogs.GetAllByClassID(classID, day)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"summaries": summarizeDailyLogs(students, entries, day)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func summarizeDailyLogs(students []*data.Student, entries []*data.DailyLogEntry, day time.Time) []*dailylog.Summary {
+	now := time.Now()
+	summaries := make([]*dailylog.Summary, len(students))
+	for i, student := range students {
+		summaries[i] = dailylog.Summarize(student, entries, day, now)
+	}
+	return summaries
+}
+
+// kioskDailySummaryHandler shows guardians their children's day at pickup, or another day's with
+// date.
+func (app *application) kioskDailySummaryHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		kioskCredentials
+		StudentIDs []int64    `json:"student_ids"`
+		Date       *data.Date `json:"date"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	guardian, err := app.authenticateGuardian(r, input.kioskCredentials)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidGuardianCredentials):
+			app.invalidCredentialsResponse(w, r)
+		case errors.Is(err, errTooManyPINAttempts):
+			app.tooManyPINAttemptsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	students, ok, err := app.guardianStudents(guardian.GuardianID, input.StudentIDs)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !ok {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	now := time.Now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if input.Date != nil {
+		t := time.Time(*input.Date)
+		day = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	}
+
+	entries := []*data.DailyLogEntry{}
+	for _, student := range students {
+		logged, err := app.models.DailyLogs.GetAllByStudentID(student.StudentID, day)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		entries = append(entries, logged...)
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"summaries": summarizeDailyLogs(students, entries, day)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
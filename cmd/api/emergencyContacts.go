@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func validateEmergencyContact(contact *data.EmergencyContact) map[string]string {
+	errs := map[string]string{}
+
+	if contact.Name == "" {
+		errs["name"] = "must be provided"
+	}
+	if contact.Phone == "" {
+		errs["phone"] = "must be provided"
+	}
+	if contact.Priority < 0 {
+		errs["priority"] = "must be a positive integer"
+	}
+
+	return errs
+}
+
+func (app *application) listEmergencyContactsHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	contacts, err := app.models.EmergencyContacts.GetAllByStudentID(studentID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"emergency_contacts": contacts}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createEmergencyContactHandler adds a contact to the student's list, after the others unless a
+// priority is given.
+func (app *application) createEmergencyContactHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Priority     int    `json:"priority"`
+		Name         string `json:"name"`
+		Relationship string `json:"relationship"`
+		Phone        string `json:"phone"`
+		AltPhone     string `json:"alt_phone"`
+		Notes        string `json:"notes"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	contact := &data.EmergencyContact{
+		StudentID:    studentID,
+		Priority:     input.Priority,
+		Name:         strings.TrimSpace(input.Name),
+		Relationship: strings.TrimSpace(input.Relationship),
+		Phone:        strings.TrimSpace(input.Phone),
+		AltPhone:     strings.TrimSpace(input.AltPhone),
+		Notes:        strings.TrimSpace(input.Notes),
+	}
+
+	if errs := validateEmergencyContact(contact); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.EmergencyContacts.Insert(contact)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateContactPriority):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"emergency_contact": contact}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateEmergencyContactHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || studentID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	contactID, err := strconv.ParseInt(chi.URLParam(r, "contactID"), 10, 64)
+	if err != nil || contactID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	contact, err := app.models.EmergencyContacts.Get(studentID, contactID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Priority     *int    `json:"priority"`
+		Name         *string `json:"name"`
+		Relationship *string `json:"relationship"`
+		Phone        *string `json:"phone"`
+		AltPhone     *string `json:"alt_phone"`
+		Notes        *string `json:"notes"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Priority != nil {
+		contact.Priority = *input.Priority
+	}
+	if input.Name != nil {
+		contact.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Relationship != nil {
+		contact.Relationship = strings.TrimSpace(*input.Relationship)
+	}
+	if input.Phone != nil {
+		contact.Phone = strings.TrimSpace(*input.Phone)
+	}
+	if input.AltPhone != nil {
+		contact.AltPhone = strings.TrimSpace(*input.AltPhone)
+	}
+	if input.Notes != nil {
+		contact.Notes = strings.TrimSpace(*input.Notes)
+	}
+
+	errs := validateEmergencyContact(contact)
+	if contact.Priority < 1 {
+		errs["priority"] = "must be a positive integer"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.EmergencyContacts.Update(contact)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateContactPriority):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"emergency_contact": contact}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteEmergencyContactHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || studentID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	contactID, err := strconv.ParseInt(chi.URLParam(r, "contactID"), 10, 64)
+	if err != nil || contactID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.EmergencyContacts.Delete(studentID, contactID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "emergency contact deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// rosterGuardian is how a guardian is listed on the emergency roster.
+type rosterGuardian struct {
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Relationship string `json:"relationship"`
+	Contact      string `json:"contact"`
+}
+
+// rosterStudent is a student on the emergency roster with everyone to call about them, guardians
+// first.
+type rosterStudent struct {
+	StudentID         int64                    `json:"student_id"`
+	FirstName         string                   `json:"first_name"`
+	LastName          string                   `json:"last_name"`
+	DateOfBirth       data.Date                `json:"date_of_birth"`
+	Allergies         []*data.AllergyFlag      `json:"allergies"`
+	Guardians         []*rosterGuardian        `json:"guardians"`
+	EmergencyContacts []*data.EmergencyContact `json:"emergency_contacts"`
+}
+
+// emergencyRosterHandler lists every student of the class with their allergies, guardians and
+// emergency contacts, as JSON or, with format=csv, as a CSV file to print.
+func (app *application) emergencyRosterHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	format := app.readString(r.URL.Query(), "format", "json")
+	if format != "json" && format != "csv" {
+		app.failedValidationResponse(w, r, map[string]string{"format": "must be json or csv"})
+		return
+	}
+
+	students, err := app.models.ClassStudents.GetStudentsByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	withGuardians, err := app.models.ClassStudents.GetStudentsByClassIDWithGuardian(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	allergies, err := app.models.Allergies.GetFlagsByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	contacts, err := app.models.EmergencyContacts.GetAllByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	guardians := map[int64][]*rosterGuardian{}
+	for _, row := range withGuardians {
+		guardians[row.StudentID] = append(guardians[row.StudentID], &rosterGuardian{
+			FirstName:    row.GuardianFirstName,
+			LastName:     row.GuardianLastName,
+			Relationship: row.GuardianRel,
+			Contact:      row.GuardianContact,
+		})
+	}
+
+	roster := []*rosterStudent{}
+	for _, student := range students {
+		entry := &rosterStudent{
+			StudentID:         student.StudentID,
+			FirstName:         student.FirstName,
+			LastName:          student.LastName,
+			DateOfBirth:       student.DateOfBirth,
+			Allergies:         allergies[student.StudentID],
+			Guardians:         guardians[student.StudentID],
+			EmergencyContacts: contacts[student.StudentID],
+		}
+		if entry.Allergies == nil {
+			entry.Allergies = []*data.AllergyFlag{}
+		}
+		if entry.Guardians == nil {
+			entry.Guardians = []*rosterGuardian{}
+		}
+		if entry.EmergencyContacts == nil {
+			entry.EmergencyContacts = []*data.EmergencyContact{}
+		}
+		roster = append(roster, entry)
+	}
+
+	if format == "csv" {
+		app.writeEmergencyRosterCSV(w, r, classID, roster)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"class_id": classID, "roster": roster}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// writeEmergencyRosterCSV writes a row per student, with their allergies, guardians and
+// emergency contacts each joined into a single column in the order they should be read.
+func (app *application) writeEmergencyRosterCSV(w http.ResponseWriter, r *http.Request, classID int64, roster []*rosterStudent) {
+	filename := fmt.Sprintf("emergency_roster_%d_%s.csv", classID, time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"student_id", "first_name", "last_name", "date_of_birth", "allergies", "guardians", "emergency_contacts"})
+
+	for _, student := range roster {
+		allergies := make([]string, len(student.Allergies))
+		for i, allergy := range student.Allergies {
+			allergies[i] = allergy.Allergen
+			if allergy.Details != "" {
+				allergies[i] += ": " + allergy.Details
+			}
+			allergies[i] += fmt.Sprintf(" (%s)", allergy.Severity)
+		}
+
+		guardians := make([]string, len(student.Guardians))
+		for i, guardian := range student.Guardians {
+			guardians[i] = fmt.Sprintf("%s %s (%s) %s", guardian.FirstName, guardian.LastName, guardian.Relationship, guardian.Contact)
+		}
+
+		contacts := make([]string, len(student.EmergencyContacts))
+		for i, contact := range student.EmergencyContacts {
+			contacts[i] = fmt.Sprintf("%d. %s (%s) %s", contact.Priority, contact.Name, contact.Relationship, contact.Phone)
+			if contact.AltPhone != "" {
+				contacts[i] += " / " + contact.AltPhone
+			}
+		}
+
+		cw.Write([]string{
+			strconv.FormatInt(student.StudentID, 10),
+			student.FirstName,
+			student.LastName,
+			time.Time(student.DateOfBirth).Format("2006-01-02"),
+			strings.Join(allergies, "; "),
+			strings.Join(guardians, "; "),
+			strings.Join(contacts, "; "),
+		})
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		app.logError(r, err)
+	}
+}
@@ -55,6 +55,11 @@ func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http
 	app.errorResponse(w, r, http.StatusTooManyRequests, message)
 }
 
+func (app *application) tooManyPINAttemptsResponse(w http.ResponseWriter, r *http.Request) {
+	message := "too many incorrect PINs, please try again later or use your QR code"
+	app.errorResponse(w, r, http.StatusTooManyRequests, message)
+}
+
 func (app *application) invalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
 	message := "invalid authentication credentials"
 	app.errorResponse(w, r, http.StatusUnauthorized, message)
@@ -64,3 +69,35 @@ func (app *application) userAlreadyExistResponse(w http.ResponseWriter, r *http.
 	message := "a user with that email address already exists"
 	app.errorResponse(w, r, http.StatusConflict, message)
 }
+
+func (app *application) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you must be authenticated to access this resource"
+	app.errorResponse(w, r, http.StatusUnauthorized, message)
+}
+
+func (app *application) invalidDeviceTokenResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+
+	message := "invalid or missing kiosk device token"
+	app.errorResponse(w, r, http.StatusUnauthorized, message)
+}
+
+func (app *application) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you do not have the necessary permissions to access this resource"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+func (app *application) inactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your account has been deactivated"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+func (app *application) paymentsDisabledResponse(w http.ResponseWriter, r *http.Request) {
+	message := "card payments are not enabled"
+	app.errorResponse(w, r, http.StatusServiceUnavailable, message)
+}
+
+func (app *application) invalidWebhookSignatureResponse(w http.ResponseWriter, r *http.Request) {
+	message := "invalid or missing webhook signature"
+	app.errorResponse(w, r, http.StatusUnauthorized, message)
+}
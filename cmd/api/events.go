@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/events"
+)
+
+// classEventsHandler streams the attendance, enrollment and sleep changes of a class, and alerts
+// for overdue sleep checks, as server-sent events. Clients reconnecting with a Last-Event-ID
+// header (or last_event_id query parameter, for EventSource polyfills that can't set headers)
+// first receive the events they missed.
+func (app *application) classEventsHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Classes.Get(classID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	since, _ := strconv.ParseInt(lastEventID, 10, 64)
+
+	// The stream outlives the server's write timeout, so lift it for this response.
+	rc := http.NewResponseController(w)
+	err = rc.SetWriteDeadline(time.Time{})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	missed, stream, unsubscribe := app.events.Subscribe(classID, since)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	for _, event := range missed {
+		err := writeServerSentEvent(w, event)
+		if err != nil {
+			app.logError(r, err)
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		err := rc.Flush()
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+
+		case event, ok := <-stream:
+			// the hub dropped us for falling behind or is shutting down, the client will reconnect and catch up
+			if !ok {
+				return
+			}
+
+			err := writeServerSentEvent(w, event)
+			if err != nil {
+				app.logError(r, err)
+				return
+			}
+		}
+	}
+}
+
+func writeServerSentEvent(w io.Writer, event events.Event) error {
+	js, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, js)
+	return err
+}
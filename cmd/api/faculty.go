@@ -13,6 +13,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// createFacultyHandler signs up a faculty member. New accounts wait for an admin to activate
+// them, so nobody is signed in.
 func (app *application) createFacultyHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		FirstName string `json:"first_name"`
@@ -58,29 +60,7 @@ func (app *application) createFacultyHandler(w http.ResponseWriter, r *http.Requ
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/faculty/%d", faculty.FacultyID))
 
-	claims := &jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 72)),
-		Issuer:    strconv.Itoa(int(faculty.FacultyID)),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString([]byte(app.cfg.jwtSecret))
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
-	}
-
-	http.SetCookie(w, &http.Cookie{
-		Name:     "jwt",
-		Value:    tokenString,
-		Expires:  time.Now().Add(time.Hour * 72),
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteStrictMode,
-	})
-
-	if err := app.writeJSON(w, http.StatusOK, faculty, nil); err != nil {
+	if err := app.writeJSON(w, http.StatusAccepted, faculty, headers); err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
@@ -116,6 +96,11 @@ func (app *application) loginFacultyHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if !faculty.Active {
+		app.inactiveAccountResponse(w, r)
+		return
+	}
+
 	claims := &jwt.RegisteredClaims{
 		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 72)),
 		Issuer:    strconv.Itoa(int(faculty.FacultyID)),
@@ -170,89 +155,19 @@ func (app *application) logoutFacultyHandler(w http.ResponseWriter, r *http.Requ
 	}
 }
 
+// getUserWithTokenHandler shows the faculty member the jwt cookie belongs to.
 func (app *application) getUserWithTokenHandler(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("jwt")
-	if err != nil {
-		switch {
-		case errors.Is(err, http.ErrNoCookie):
-			app.badRequestResponse(w, r, err)
+	faculty := app.contextGetFaculty(r)
 
-		default:
-			app.serverErrorResponse(w, r, err)
-		}
-		return
-	}
-
-	token, err := jwt.ParseWithClaims(cookie.Value, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(app.cfg.jwtSecret), nil
-	})
-	if err != nil {
-		app.invalidCredentialsResponse(w, r)
-		return
-	}
-
-	claims := token.Claims.(*jwt.RegisteredClaims)
-
-	id, err := strconv.ParseInt(claims.Issuer, 10, 64)
-	if err != nil {
-		app.invalidCredentialsResponse(w, r)
-		return
-	}
-
-	faculty, err := app.models.Faculty.Get(id)
-	if err != nil {
-		if errors.Is(err, data.ErrRecordNotFound) {
-			app.notFoundResponse(w, r)
-		} else {
-			app.serverErrorResponse(w, r, err)
-		}
-		return
-	}
-
-	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"faculty": faculty}, nil)
+	err := app.writeEnvelopedJSON(w, http.StatusOK, envelope{"faculty": faculty}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
 
+// updateFacultyHandler updates the profile of the faculty member the jwt cookie belongs to.
 func (app *application) updateFacultyHandler(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("jwt")
-	if err != nil {
-		switch {
-		case errors.Is(err, http.ErrNoCookie):
-			app.badRequestResponse(w, r, err)
-
-		default:
-			app.serverErrorResponse(w, r, err)
-		}
-		return
-	}
-
-	token, err := jwt.ParseWithClaims(cookie.Value, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(app.cfg.jwtSecret), nil
-	})
-	if err != nil {
-		app.invalidCredentialsResponse(w, r)
-		return
-	}
-
-	claims := token.Claims.(*jwt.RegisteredClaims)
-
-	id, err := strconv.ParseInt(claims.Issuer, 10, 64)
-	if err != nil {
-		app.invalidCredentialsResponse(w, r)
-		return
-	}
-
-	faculty, err := app.models.Faculty.Get(id)
-	if err != nil {
-		if errors.Is(err, data.ErrRecordNotFound) {
-			app.notFoundResponse(w, r)
-		} else {
-			app.serverErrorResponse(w, r, err)
-		}
-		return
-	}
+	faculty := app.contextGetFaculty(r)
 
 	var input struct {
 		FirstName *string `json:"first_name"`
@@ -262,7 +177,7 @@ func (app *application) updateFacultyHandler(w http.ResponseWriter, r *http.Requ
 		Position  *string `json:"position"`
 	}
 
-	err = app.readJSON(w, r, &input)
+	err := app.readJSON(w, r, &input)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
 		return
@@ -384,3 +299,182 @@ func (app *application) showNumberOfAttendanceTakenByFacultyHandler(w http.Respo
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+func (app *application) listFacultyHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name     string
+		Position string
+		Active   *bool
+		data.Filters
+	}
+
+	qs := r.URL.Query()
+
+	input.Name = app.readString(qs, "name", "")
+	input.Position = app.readString(qs, "position", "")
+	input.Filters.Page = app.readInt(qs, "page", 1)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20)
+	input.Filters.Sort = app.readString(qs, "sort", "last_name")
+	input.Filters.SortSafelist = []string{"faculty_id", "first_name", "last_name", "position", "-faculty_id", "-first_name", "-last_name", "-position"}
+
+	if s := qs.Get("active"); s != "" {
+		active, err := strconv.ParseBool(s)
+		if err != nil {
+			app.failedValidationResponse(w, r, map[string]string{"active": "must be true or false"})
+			return
+		}
+		input.Active = &active
+	}
+
+	faculty, metadata, err := app.models.Faculty.GetAll(input.Name, input.Position, input.Active, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"faculty": faculty, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deactivateFacultyHandler(w http.ResponseWriter, r *http.Request) {
+	app.setFacultyActive(w, r, false)
+}
+
+func (app *application) reactivateFacultyHandler(w http.ResponseWriter, r *http.Request) {
+	app.setFacultyActive(w, r, true)
+}
+
+func (app *application) setFacultyActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// an admin deactivating themselves would lock them out with no way back in
+	if id == app.contextGetFaculty(r).FacultyID && !active {
+		app.failedValidationResponse(w, r, map[string]string{"id": "you cannot deactivate your own account"})
+		return
+	}
+
+	err = app.models.Faculty.SetActive(id, active)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	faculty, err := app.models.Faculty.Get(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"faculty": faculty}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateFacultyAdminHandler grants or revokes the admin rights of a faculty member.
+func (app *application) updateFacultyAdminHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		IsAdmin *bool `json:"is_admin"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	errs := map[string]string{}
+	if input.IsAdmin == nil {
+		errs["is_admin"] = "must be provided"
+	} else if id == app.contextGetFaculty(r).FacultyID && !*input.IsAdmin {
+		// an admin demoting themselves could leave the center without any admin
+		errs["is_admin"] = "you cannot revoke your own admin rights"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Faculty.SetAdmin(id, *input.IsAdmin)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	faculty, err := app.models.Faculty.Get(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"faculty": faculty}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteFacultyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	reassignTo := int64(app.readInt(r.URL.Query(), "reassign_to", 0))
+
+	errs := map[string]string{}
+	if id == app.contextGetFaculty(r).FacultyID {
+		errs["id"] = "you cannot delete your own account"
+	}
+	if reassignTo == id {
+		errs["reassign_to"] = "must be a different faculty member"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Faculty.DeleteAndReassign(id, reassignTo)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrFacultyHasClasses):
+			app.errorResponse(w, r, http.StatusConflict, "the faculty member still has classes, pass reassign_to to hand them over first")
+		case errors.Is(err, data.ErrFacultyHasTimeEntries):
+			app.errorResponse(w, r, http.StatusConflict, "the faculty member has recorded time entries, deactivate them instead")
+		case errors.Is(err, data.ErrInvalidReassignment):
+			app.failedValidationResponse(w, r, map[string]string{"reassign_to": err.Error()})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "faculty deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/billing"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/pdf"
+)
+
+// readFamily returns the family in the URL, having written a response already if there is none.
+func (app *application) readFamily(w http.ResponseWriter, r *http.Request) (*data.Family, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	family, err := app.models.Families.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return family, true
+}
+
+func (app *application) showFamilyHandler(w http.ResponseWriter, r *http.Request) {
+	family, ok := app.readFamily(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.writeEnvelopedJSON(w, http.StatusOK, envelope{"family": family}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showFamilyStatementHandler shows the family's account activity between the from and to query
+// string dates, which default to the start of the year and today.
+func (app *application) showFamilyStatementHandler(w http.ResponseWriter, r *http.Request) {
+	family, ok := app.readFamily(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.Local)
+	to := now
+
+	errs := map[string]string{}
+	qs := r.URL.Query()
+
+	if s := qs.Get("from"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			errs["from"] = "must be a date in the format YYYY-MM-DD"
+		}
+		from = t
+	}
+
+	if s := qs.Get("to"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			errs["to"] = "must be a date in the format YYYY-MM-DD"
+		}
+		to = t
+	}
+
+	if len(errs) == 0 && to.Before(from) {
+		errs["to"] = "must not be before from"
+	}
+
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	statement, err := app.models.Families.GetStatement(family.FamilyID, from, to)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"statement": statement}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	family, ok := app.readFamily(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		AmountCents int64      `json:"amount_cents"`
+		Method      string     `json:"method"`
+		Reference   string     `json:"reference"`
+		ReceivedOn  *data.Date `json:"received_on"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payment := &data.Payment{
+		FamilyID:    family.FamilyID,
+		AmountCents: input.AmountCents,
+		Method:      input.Method,
+		Reference:   strings.TrimSpace(input.Reference),
+		ReceivedOn:  data.Date(time.Now()),
+		RecordedBy:  &app.contextGetFaculty(r).FacultyID,
+	}
+	if input.ReceivedOn != nil {
+		payment.ReceivedOn = *input.ReceivedOn
+	}
+
+	errs := map[string]string{}
+	if payment.AmountCents <= 0 {
+		errs["amount_cents"] = "must be more than zero"
+	}
+	if payment.Method != "cash" && payment.Method != "cheque" && payment.Method != "bank_transfer" {
+		errs["method"] = "must be cash, cheque or bank_transfer"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Payments.Insert(payment)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"payment": payment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createCreditHandler(w http.ResponseWriter, r *http.Request) {
+	app.createAccountAdjustment(w, r, "credit")
+}
+
+func (app *application) createRefundHandler(w http.ResponseWriter, r *http.Request) {
+	app.createAccountAdjustment(w, r, "refund")
+}
+
+func (app *application) createAccountAdjustment(w http.ResponseWriter, r *http.Request, kind string) {
+	family, ok := app.readFamily(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		AmountCents int64  `json:"amount_cents"`
+		Reason      string `json:"reason"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	adjustment := &data.AccountAdjustment{
+		FamilyID:    family.FamilyID,
+		Kind:        kind,
+		AmountCents: input.AmountCents,
+		Reason:      strings.TrimSpace(input.Reason),
+		RecordedBy:  &app.contextGetFaculty(r).FacultyID,
+	}
+
+	errs := map[string]string{}
+	if adjustment.AmountCents <= 0 {
+		errs["amount_cents"] = "must be more than zero"
+	}
+	if adjustment.Reason == "" {
+		errs["reason"] = "must be provided"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Adjustments.Insert(adjustment)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRefundExceedsCredit):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{kind: adjustment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) voidInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	invoice, err := app.models.Invoices.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Invoices.Void(invoice)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvoiceNotOpen):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"invoice": invoice}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// linkStudentGuardianHandler links an existing guardian to a student, so siblings share their
+// guardians. The student stays in their family; updateStudentFamilyHandler moves them.
+func (app *application) linkStudentGuardianHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	guardianID, err := strconv.ParseInt(chi.URLParam(r, "guardianID"), 10, 64)
+	if err != nil || guardianID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Students.Get(id)
+	if err == nil {
+		_, err = app.models.Guardians.Get(guardianID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	link := &data.StudentGuardian{StudentID: id, GuardianID: guardianID}
+
+	err = app.models.StudentGuardian.Insert(link)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"student_guardian": link}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateStudentFamilyHandler moves a student to another family, for example to bill them with
+// a sibling. Their earlier invoices and payments stay on the account they were made on.
+func (app *application) updateStudentFamilyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Students.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		FamilyID int64 `json:"family_id"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.FamilyID < 1 {
+		app.failedValidationResponse(w, r, map[string]string{"family_id": "must be provided"})
+		return
+	}
+
+	err = app.models.Students.SetFamily(id, input.FamilyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrUnknownFamily):
+			app.failedValidationResponse(w, r, map[string]string{"family_id": err.Error()})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	family, err := app.models.Families.Get(input.FamilyID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"family": family}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// writePDF sends the document as a file download.
+func (app *application) writePDF(w http.ResponseWriter, r *http.Request, filename string, doc *pdf.Document) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	_, err := doc.WriteTo(w)
+	if err != nil {
+		app.logError(r, err)
+	}
+}
+
+func (app *application) provider() billing.Provider {
+	return billing.Provider{
+		Name:    app.cfg.provider.name,
+		Address: app.cfg.provider.address,
+		TaxID:   app.cfg.provider.taxID,
+	}
+}
+
+func (app *application) familyInvoicePDFHandler(w http.ResponseWriter, r *http.Request) {
+	family, ok := app.readFamily(w, r)
+	if !ok {
+		return
+	}
+
+	invoiceID, err := strconv.ParseInt(chi.URLParam(r, "invoiceID"), 10, 64)
+	if err != nil || invoiceID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	invoice, err := app.models.Invoices.Get(invoiceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if invoice.GuardianID != family.FamilyID {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	doc := billing.InvoicePDF(app.provider(), invoice, family)
+	app.writePDF(w, r, fmt.Sprintf("invoice-%d.pdf", invoice.InvoiceID), doc)
+}
+
+func (app *application) paymentReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	family, ok := app.readFamily(w, r)
+	if !ok {
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(chi.URLParam(r, "paymentID"), 10, 64)
+	if err != nil || paymentID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	payment, err := app.models.Payments.Get(family.FamilyID, paymentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	doc := billing.ReceiptPDF(app.provider(), payment, family)
+	app.writePDF(w, r, fmt.Sprintf("receipt-%d.pdf", payment.PaymentID), doc)
+}
+
+// showTaxStatementHandler returns the family's childcare expense statement for the year in the
+// query string, by default last year, as a PDF or, with format=json, as JSON.
+func (app *application) showTaxStatementHandler(w http.ResponseWriter, r *http.Request) {
+	family, ok := app.readFamily(w, r)
+	if !ok {
+		return
+	}
+
+	qs := r.URL.Query()
+	year := app.readInt(qs, "year", time.Now().Year()-1)
+	format := app.readString(qs, "format", "pdf")
+
+	errs := map[string]string{}
+	if year < 2000 || year > time.Now().Year() {
+		errs["year"] = "must be a year from 2000 to this year"
+	}
+	if format != "pdf" && format != "json" {
+		errs["format"] = "must be pdf or json"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	statement, err := app.models.Families.GetTaxStatement(family.FamilyID, year)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if format == "json" {
+		err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"tax_statement": statement}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	doc := billing.TaxStatementPDF(app.provider(), statement)
+	app.writePDF(w, r, fmt.Sprintf("childcare-expenses-%d-%d.pdf", year, family.FamilyID), doc)
+}
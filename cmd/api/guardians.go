@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -111,3 +112,57 @@ func (app *application) deleteGuardianHandler(w http.ResponseWriter, r *http.Req
 	}
 }
 
+// linkGuardianFacultyHandler records that the guardian works at the daycare, which makes their
+// children eligible for staff discounts. A faculty_id of null removes the link.
+func (app *application) linkGuardianFacultyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		FacultyID *int64 `json:"faculty_id"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.FacultyID != nil {
+		_, err := app.models.Faculty.Get(*input.FacultyID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.failedValidationResponse(w, r, map[string]string{"faculty_id": "must be an existing faculty member"})
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	err = app.models.Guardians.SetFaculty(id, input.FacultyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	guardian, err := app.models.Guardians.Get(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"guardian": guardian}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/immunization"
+)
+
+// showImmunizationsHandler shows the student's immunizations and exemptions, and whether they
+// are up to date with the schedule today.
+func (app *application) showImmunizationsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	student, err := app.models.Students.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	schedule, err := app.models.DoseSchedule.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	records, err := app.models.Immunizations.GetAllByStudentID(student.StudentID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	exemptions, err := app.models.Exemptions.GetAllByStudentID(student.StudentID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{
+		"immunizations": records,
+		"exemptions":    exemptions,
+		"compliance":    immunization.Check(student, schedule, records, exemptions, time.Now()),
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createImmunizationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	student, err := app.models.Students.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Vaccine        string     `json:"vaccine"`
+		Dose           int        `json:"dose"`
+		AdministeredOn *data.Date `json:"administered_on"`
+		AdministeredBy string     `json:"administered_by"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	record := &data.Immunization{
+		StudentID:      student.StudentID,
+		Vaccine:        strings.TrimSpace(input.Vaccine),
+		Dose:           input.Dose,
+		AdministeredBy: strings.TrimSpace(input.AdministeredBy),
+		RecordedBy:     &app.contextGetFaculty(r).FacultyID,
+	}
+
+	errs := map[string]string{}
+	if record.Vaccine == "" {
+		errs["vaccine"] = "must be provided"
+	}
+	if record.Dose < 1 {
+		errs["dose"] = "must be at least 1"
+	}
+	switch {
+	case input.AdministeredOn == nil:
+		errs["administered_on"] = "must be provided"
+	case time.Time(*input.AdministeredOn).After(time.Now()):
+		errs["administered_on"] = "must not be in the future"
+	case time.Time(*input.AdministeredOn).Before(time.Time(student.DateOfBirth)):
+		errs["administered_on"] = "must not be before the student's date of birth"
+	default:
+		record.AdministeredOn = *input.AdministeredOn
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Immunizations.Insert(record)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateImmunization):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"immunization": record}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteImmunizationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	immunizationID, err := strconv.ParseInt(chi.URLParam(r, "immunizationID"), 10, 64)
+	if err != nil || immunizationID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Immunizations.Delete(id, immunizationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "immunization deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createExemptionHandler records an exemption from one vaccine or, if no vaccine is given, from
+// all of them.
+func (app *application) createExemptionHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Vaccine     string     `json:"vaccine"`
+		Kind        string     `json:"kind"`
+		DocumentRef string     `json:"document_ref"`
+		ExpiresOn   *data.Date `json:"expires_on"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	exemption := &data.ImmunizationExemption{
+		StudentID:   studentID,
+		Vaccine:     strings.TrimSpace(input.Vaccine),
+		Kind:        input.Kind,
+		DocumentRef: strings.TrimSpace(input.DocumentRef),
+		ExpiresOn:   input.ExpiresOn,
+		RecordedBy:  &app.contextGetFaculty(r).FacultyID,
+	}
+
+	errs := map[string]string{}
+	if exemption.Kind != "medical" && exemption.Kind != "religious" && exemption.Kind != "personal" {
+		errs["kind"] = "must be medical, religious or personal"
+	}
+	if exemption.Kind == "medical" && exemption.DocumentRef == "" {
+		errs["document_ref"] = "must be provided for a medical exemption"
+	}
+	if exemption.ExpiresOn != nil && time.Time(*exemption.ExpiresOn).Before(time.Now()) {
+		errs["expires_on"] = "must be in the future"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Exemptions.Insert(exemption)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"exemption": exemption}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteExemptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	exemptionID, err := strconv.ParseInt(chi.URLParam(r, "exemptionID"), 10, 64)
+	if err != nil || exemptionID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Exemptions.Delete(id, exemptionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "exemption deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listDoseScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	schedule, err := app.models.DoseSchedule.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"schedule": schedule}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func validateScheduledDose(dose *data.ScheduledDose) map[string]string {
+	errs := map[string]string{}
+
+	if dose.Vaccine == "" {
+		errs["vaccine"] = "must be provided"
+	}
+	if dose.Dose < 1 {
+		errs["dose"] = "must be at least 1"
+	}
+	if dose.DueAgeMonths < 0 || dose.DueAgeMonths > 216 {
+		errs["due_age_months"] = "must be between 0 and 216"
+	}
+	if dose.GraceMonths < 0 {
+		errs["grace_months"] = "must not be negative"
+	}
+
+	return errs
+}
+
+func (app *application) createScheduledDoseHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Vaccine      string `json:"vaccine"`
+		Dose         int    `json:"dose"`
+		DueAgeMonths int    `json:"due_age_months"`
+		GraceMonths  *int   `json:"grace_months"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	dose := &data.ScheduledDose{
+		Vaccine:      strings.TrimSpace(input.Vaccine),
+		Dose:         input.Dose,
+		DueAgeMonths: input.DueAgeMonths,
+		GraceMonths:  2,
+	}
+	if input.GraceMonths != nil {
+		dose.GraceMonths = *input.GraceMonths
+	}
+
+	if errs := validateScheduledDose(dose); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.DoseSchedule.Insert(dose)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateScheduledDose):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"dose": dose}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateScheduledDoseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	dose, err := app.models.DoseSchedule.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Vaccine      *string `json:"vaccine"`
+		Dose         *int    `json:"dose"`
+		DueAgeMonths *int    `json:"due_age_months"`
+		GraceMonths  *int    `json:"grace_months"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Vaccine != nil {
+		dose.Vaccine = strings.TrimSpace(*input.Vaccine)
+	}
+	if input.Dose != nil {
+		dose.Dose = *input.Dose
+	}
+	if input.DueAgeMonths != nil {
+		dose.DueAgeMonths = *input.DueAgeMonths
+	}
+	if input.GraceMonths != nil {
+		dose.GraceMonths = *input.GraceMonths
+	}
+
+	if errs := validateScheduledDose(dose); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.DoseSchedule.Update(dose)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateScheduledDose):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"dose": dose}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteScheduledDoseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.DoseSchedule.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "scheduled dose deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// immunizationReportHandler lists, class by class, the students who are overdue for a dose as of
+// today or the "on" query string date. The class_id query string limits it to one class.
+func (app *application) immunizationReportHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	classID := int64(app.readInt(qs, "class_id", 0))
+
+	on := time.Now()
+	if s := qs.Get("on"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			app.failedValidationResponse(w, r, map[string]string{"on": "must be a date in the format YYYY-MM-DD"})
+			return
+		}
+		on = t
+	}
+
+	schedule, err := app.models.DoseSchedule.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	enrolled, err := app.models.Immunizations.GetEnrolled(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	records, err := app.models.Immunizations.GetAllByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	exemptions, err := app.models.Exemptions.GetAllByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{
+		"on":      data.Date(on),
+		"classes": immunization.Report(enrolled, schedule, records, exemptions, on),
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,547 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+var incidentKinds = []string{"injury", "illness", "behavior", "other"}
+
+// validateIncident checks the incident and that its class, if it has one, exists. Students listed
+// twice are only kept once.
+func (app *application) validateIncident(incident *data.Incident, errs map[string]string) error {
+	if !slices.Contains(incidentKinds, incident.Kind) {
+		errs["kind"] = "must be injury, illness, behavior or other"
+	}
+	if incident.OccurredAt.IsZero() {
+		errs["occurred_at"] = "must be provided"
+	} else if incident.OccurredAt.After(time.Now()) {
+		errs["occurred_at"] = "must not be in the future"
+	}
+	if incident.Description == "" {
+		errs["description"] = "must be provided"
+	}
+
+	students := []*data.IncidentStudent{}
+	for _, student := range incident.Students {
+		if !slices.ContainsFunc(students, func(s *data.IncidentStudent) bool { return s.StudentID == student.StudentID }) {
+			students = append(students, student)
+		}
+	}
+	incident.Students = students
+	if len(incident.Students) == 0 {
+		errs["student_ids"] = "must have at least one student"
+	}
+
+	if incident.ClassID != nil {
+		_, err := app.models.Classes.Get(*incident.ClassID)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			errs["class_id"] = "must be an existing class"
+		case err != nil:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func incidentStudents(studentIDs []int64) []*data.IncidentStudent {
+	students := make([]*data.IncidentStudent, len(studentIDs))
+	for i, id := range studentIDs {
+		students[i] = &data.IncidentStudent{StudentID: id}
+	}
+	return students
+}
+
+// createIncidentHandler writes up an incident as a draft, reported by the faculty member.
+func (app *application) createIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ClassID     *int64    `json:"class_id"`
+		StudentIDs  []int64   `json:"student_ids"`
+		Kind        string    `json:"kind"`
+		OccurredAt  time.Time `json:"occurred_at"`
+		Location    string    `json:"location"`
+		Description string    `json:"description"`
+		FirstAid    string    `json:"first_aid"`
+		Witnesses   string    `json:"witnesses"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	incident := &data.Incident{
+		ClassID:     input.ClassID,
+		Kind:        strings.TrimSpace(input.Kind),
+		OccurredAt:  input.OccurredAt,
+		Location:    strings.TrimSpace(input.Location),
+		Description: strings.TrimSpace(input.Description),
+		FirstAid:    strings.TrimSpace(input.FirstAid),
+		Witnesses:   strings.TrimSpace(input.Witnesses),
+		ReportedBy:  &app.contextGetFaculty(r).FacultyID,
+		Students:    incidentStudents(input.StudentIDs),
+	}
+
+	errs := map[string]string{}
+	err = app.validateIncident(incident, errs)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Incidents.Insert(incident)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.failedValidationResponse(w, r, map[string]string{"student_ids": "must be existing students"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"incident": incident}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listIncidentsHandler lists incidents, optionally filtered by class_id, student_id, status,
+// kind and the days they occurred on, from and to. Faculty other than admins only see the
+// incidents they reported or that concern their classes.
+func (app *application) listIncidentsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	faculty := app.contextGetFaculty(r)
+
+	filter := data.IncidentFilter{
+		ClassID:   int64(app.readInt(qs, "class_id", 0)),
+		StudentID: int64(app.readInt(qs, "student_id", 0)),
+		Status:    app.readString(qs, "status", ""),
+		Kind:      app.readString(qs, "kind", ""),
+	}
+
+	if !faculty.IsAdmin {
+		filter.FacultyID = faculty.FacultyID
+	}
+
+	errs := map[string]string{}
+	if s := qs.Get("from"); s != "" {
+		from, err := time.ParseInLocation("2006-01-02", s, app.cfg.location)
+		if err != nil {
+			errs["from"] = "must be a date in the format YYYY-MM-DD"
+		}
+		filter.From = from
+	}
+	if s := qs.Get("to"); s != "" {
+		to, err := time.ParseInLocation("2006-01-02", s, app.cfg.location)
+		if err != nil {
+			errs["to"] = "must be a date in the format YYYY-MM-DD"
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	incidents, err := app.models.Incidents.GetAll(filter)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"incidents": incidents}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readIncident returns the incident in the URL, having written a response already if there is
+// no such incident or the faculty member may not see it. With reporterOnly, only the faculty
+// member who reported it and admins are let through.
+func (app *application) readIncident(w http.ResponseWriter, r *http.Request, reporterOnly bool) (*data.Incident, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	incident, err := app.models.Incidents.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	faculty := app.contextGetFaculty(r)
+	if faculty.IsAdmin {
+		return incident, true
+	}
+
+	if reporterOnly && (incident.ReportedBy == nil || *incident.ReportedBy != faculty.FacultyID) {
+		app.notPermittedResponse(w, r)
+		return nil, false
+	}
+
+	accessible, err := app.models.Incidents.IsAccessible(incident.IncidentID, faculty.FacultyID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+
+	if !accessible {
+		app.notPermittedResponse(w, r)
+		return nil, false
+	}
+
+	return incident, true
+}
+
+func (app *application) showIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	incident, ok := app.readIncident(w, r, false)
+	if !ok {
+		return
+	}
+
+	err := app.writeEnvelopedJSON(w, http.StatusOK, envelope{"incident": incident}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateIncidentHandler changes a draft incident. Once submitted, incidents can't be changed.
+func (app *application) updateIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	incident, ok := app.readIncident(w, r, true)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		ClassID     *int64     `json:"class_id"`
+		StudentIDs  []int64    `json:"student_ids"`
+		Kind        *string    `json:"kind"`
+		OccurredAt  *time.Time `json:"occurred_at"`
+		Location    *string    `json:"location"`
+		Description *string    `json:"description"`
+		FirstAid    *string    `json:"first_aid"`
+		Witnesses   *string    `json:"witnesses"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.ClassID != nil {
+		incident.ClassID = input.ClassID
+	}
+	if input.StudentIDs != nil {
+		incident.Students = incidentStudents(input.StudentIDs)
+	}
+	if input.Kind != nil {
+		incident.Kind = strings.TrimSpace(*input.Kind)
+	}
+	if input.OccurredAt != nil {
+		incident.OccurredAt = *input.OccurredAt
+	}
+	if input.Location != nil {
+		incident.Location = strings.TrimSpace(*input.Location)
+	}
+	if input.Description != nil {
+		incident.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.FirstAid != nil {
+		incident.FirstAid = strings.TrimSpace(*input.FirstAid)
+	}
+	if input.Witnesses != nil {
+		incident.Witnesses = strings.TrimSpace(*input.Witnesses)
+	}
+
+	errs := map[string]string{}
+	err = app.validateIncident(incident, errs)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Incidents.Update(incident)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrIncidentNotDraft):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.failedValidationResponse(w, r, map[string]string{"student_ids": "must be existing students"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"incident": incident}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// submitIncidentHandler submits a draft incident for the guardians of the students involved to
+// acknowledge.
+func (app *application) submitIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	incident, ok := app.readIncident(w, r, true)
+	if !ok {
+		return
+	}
+
+	err := app.models.Incidents.Submit(incident)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrIncidentNotDraft):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"incident": incident}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	incident, ok := app.readIncident(w, r, true)
+	if !ok {
+		return
+	}
+
+	err := app.models.Incidents.Delete(incident.IncidentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrIncidentNotDraft):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "incident deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// incidentReportHandler summarises the incidents reported between from and to by class and kind
+// for licensing, and lists them. The range defaults to the current month.
+func (app *application) incidentReportHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	now := time.Now().In(app.cfg.location)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, app.cfg.location)
+	to := from.AddDate(0, 1, -1)
+
+	errs := map[string]string{}
+	if s := qs.Get("from"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, app.cfg.location)
+		if err != nil {
+			errs["from"] = "must be a date in the format YYYY-MM-DD"
+		}
+		from = t
+	}
+	if s := qs.Get("to"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, app.cfg.location)
+		if err != nil {
+			errs["to"] = "must be a date in the format YYYY-MM-DD"
+		}
+		to = t
+	}
+	if len(errs) == 0 && to.Before(from) {
+		errs["to"] = "must not be before from"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	summaries, err := app.models.Incidents.GetSummaries(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	incidents, err := app.models.Incidents.GetAll(data.IncidentFilter{
+		ClassID: int64(app.readInt(qs, "class_id", 0)),
+		From:    from,
+		To:      to.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	incidents = slices.DeleteFunc(incidents, func(incident *data.Incident) bool { return incident.Status == "draft" })
+
+	env := envelope{
+		"from":      data.Date(from),
+		"to":        data.Date(to),
+		"summary":   summaries,
+		"incidents": incidents,
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// kioskPendingIncidentsHandler lists the submitted incidents the guardian has yet to acknowledge.
+// Only the guardian's own children are listed on them.
+func (app *application) kioskPendingIncidentsHandler(w http.ResponseWriter, r *http.Request) {
+	var input kioskCredentials
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	guardian, err := app.authenticateGuardian(r, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidGuardianCredentials):
+			app.invalidCredentialsResponse(w, r)
+		case errors.Is(err, errTooManyPINAttempts):
+			app.tooManyPINAttemptsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	incidents, err := app.models.Incidents.GetPendingByGuardianID(guardian.GuardianID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	children, _, err := app.guardianStudents(guardian.GuardianID, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	for _, incident := range incidents {
+		guardianChildren(incident, children)
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"incidents": incidents}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// kioskAcknowledgeIncidentHandler records the guardian's acknowledgement of an incident for
+// their children involved in it.
+func (app *application) kioskAcknowledgeIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input kioskCredentials
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	guardian, err := app.authenticateGuardian(r, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidGuardianCredentials):
+			app.invalidCredentialsResponse(w, r)
+		case errors.Is(err, errTooManyPINAttempts):
+			app.tooManyPINAttemptsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	incident, err := app.models.Incidents.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	children, _, err := app.guardianStudents(guardian.GuardianID, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Guardians of none of the students involved aren't told the incident exists.
+	involved := slices.ContainsFunc(incident.Students, func(s *data.IncidentStudent) bool {
+		return slices.ContainsFunc(children, func(c *data.Student) bool { return c.StudentID == s.StudentID })
+	})
+	if !involved || incident.Status == "draft" {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Incidents.Acknowledge(incident, guardian.GuardianID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrIncidentNotSubmitted):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	guardianChildren(incident, children)
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"incident": incident}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// guardianChildren leaves only the guardian's children on the incident, so guardians don't see
+// who else was involved.
+func guardianChildren(incident *data.Incident, children []*data.Student) {
+	incident.Students = slices.DeleteFunc(incident.Students, func(s *data.IncidentStudent) bool {
+		return !slices.ContainsFunc(children, func(c *data.Student) bool { return c.StudentID == s.StudentID })
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// startJobs launches the background jobs that run for as long as the server is up.
+func (app *application) startJobs() {
+	app.runPeriodically("absence scan", app.cfg.absences.scanInterval, app.scanAbsences)
+	app.runPeriodically("credential expiry check", 24*time.Hour, app.flagExpiringCredentials)
+	app.runPeriodically("sleep check scan", app.cfg.sleep.scanInterval, app.flagOverdueSleepChecks)
+	app.runPeriodically("lead teacher sync", time.Hour, app.syncLeadTeachers)
+}
+
+// runPeriodically runs job straight away and then once every interval in its own goroutine.
+// Errors and panics are logged so that one failing run doesn't stop the next one.
+func (app *application) runPeriodically(name string, interval time.Duration, job func() error) {
+	go func() {
+		for {
+			app.runJob(name, job)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func (app *application) runJob(name string, job func() error) {
+	defer func() {
+		if err := recover(); err != nil {
+			app.logger.Error(fmt.Sprintf("%v", err), "job", name)
+		}
+	}()
+
+	err := job()
+	if err != nil {
+		app.logger.Error(err.Error(), "job", name)
+	}
+}
+
+// scanAbsences raises an alert for every student who crossed the configured absence threshold
+// and, if enabled, lets the class's faculty member know about it.
+func (app *application) scanAbsences() error {
+	alerts, err := app.models.AbsenceAlerts.Scan(app.cfg.absences.window, app.cfg.absences.threshold)
+	if err != nil {
+		return err
+	}
+
+	if len(alerts) > 0 {
+		app.logger.Info("absence alerts raised", "count", len(alerts))
+	}
+
+	if !app.cfg.absences.notify {
+		return nil
+	}
+
+	for _, alert := range alerts {
+		if alert.FacultyID == 0 {
+			continue
+		}
+
+		notification := &data.Notification{
+			FacultyID: alert.FacultyID,
+			Subject:   fmt.Sprintf("Absence alert for %s %s", alert.FirstName, alert.LastName),
+			Message: fmt.Sprintf("%s %s has missed %d of the last %d sessions of %s.",
+				alert.FirstName, alert.LastName, alert.Absences, alert.Sessions, alert.ClassName),
+		}
+
+		err := app.models.Notifications.Insert(notification)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flagExpiringCredentials flags the credentials that expire within the warning period and lets
+// the faculty member and the admins know, once per credential.
+func (app *application) flagExpiringCredentials() error {
+	before := time.Now().AddDate(0, 0, app.cfg.credentials.warningDays)
+
+	credentials, err := app.models.Credentials.FlagExpiring(before)
+	if err != nil {
+		return err
+	}
+
+	if len(credentials) == 0 {
+		return nil
+	}
+
+	app.logger.Info("expiring credentials flagged", "count", len(credentials))
+
+	adminIDs, err := app.models.Faculty.GetAdminIDs()
+	if err != nil {
+		return err
+	}
+
+	for _, credential := range credentials {
+		expiresOn := time.Time(*credential.ExpiresOn).Format("2006-01-02")
+
+		recipients := []int64{credential.FacultyID}
+		for _, id := range adminIDs {
+			if id != credential.FacultyID {
+				recipients = append(recipients, id)
+			}
+		}
+
+		for _, facultyID := range recipients {
+			notification := &data.Notification{
+				FacultyID: facultyID,
+				Subject:   fmt.Sprintf("%s credential expiring for %s %s", credential.Type, credential.FirstName, credential.LastName),
+				Message: fmt.Sprintf("The %s credential of %s %s expires on %s.",
+					credential.Type, credential.FirstName, credential.LastName, expiresOn),
+			}
+
+			err := app.models.Notifications.Insert(notification)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// flagOverdueSleepChecks alerts the staff of a class, over its event stream, to every sleeping
+// child who is overdue for a sleep check. Alerts repeat every check interval until someone
+// checks on the child.
+func (app *application) flagOverdueSleepChecks() error {
+	sessions, err := app.models.Sleep.FlagOverdue(app.cfg.sleep.checkInterval)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		app.events.Publish(session.ClassID, "sleep.check_overdue", sleepStatusOf(session, app.cfg.sleep.checkInterval, time.Now()))
+	}
+
+	if len(sessions) > 0 {
+		app.logger.Info("overdue sleep checks flagged", "count", len(sessions))
+	}
+
+	return nil
+}
+
+// syncLeadTeachers keeps the faculty member of every class in line with its lead assignments as
+// they start and end.
+func (app *application) syncLeadTeachers() error {
+	changed, err := app.models.ClassFaculty.SyncLeads()
+	if err != nil {
+		return err
+	}
+
+	if changed > 0 {
+		app.logger.Info("lead teachers synced", "classes", changed)
+	}
+
+	return nil
+}
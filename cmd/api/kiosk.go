@@ -0,0 +1,466 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	errInvalidGuardianCredentials = errors.New("invalid guardian credentials")
+	errTooManyPINAttempts         = errors.New("too many incorrect PINs")
+)
+
+// Wrong PINs are counted over pinFailureWindow. A contact number with pinFailuresPerContact of
+// them, or a kiosk with pinFailuresPerDevice, is locked out of PIN sign-in for pinLockout, so a
+// 4 digit PIN can't be guessed by trying them all.
+const (
+	pinFailureWindow      = 15 * time.Minute
+	pinFailuresPerContact = 5
+	pinFailuresPerDevice  = 20
+	pinLockout            = 15 * time.Minute
+)
+
+// pinAttempts counts the wrong PINs entered at the kiosks, by contact number and by device.
+type pinAttempts struct {
+	mu       sync.Mutex
+	failures map[string]*pinFailures
+}
+
+type pinFailures struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func newPINAttempts() *pinAttempts {
+	return &pinAttempts{failures: make(map[string]*pinFailures)}
+}
+
+// locked reports whether any of the keys is locked out.
+func (p *pinAttempts) locked(now time.Time, keys ...string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, key := range keys {
+		if f, ok := p.failures[key]; ok && now.Before(f.lockedUntil) {
+			return true
+		}
+	}
+	return false
+}
+
+// fail counts a wrong PIN against the key, locking it out once it reaches limit failures within
+// the window. Keys that have neither failed lately nor are locked out are forgotten.
+func (p *pinAttempts) fail(now time.Time, key string, limit int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for k, f := range p.failures {
+		if now.Sub(f.lastFailure) > pinFailureWindow && !now.Before(f.lockedUntil) {
+			delete(p.failures, k)
+		}
+	}
+
+	f, ok := p.failures[key]
+	if !ok {
+		f = &pinFailures{}
+		p.failures[key] = f
+	}
+
+	f.count++
+	f.lastFailure = now
+
+	if f.count >= limit {
+		f.count = 0
+		f.lockedUntil = now.Add(pinLockout)
+	}
+}
+
+// reset forgets the failures of the key after a correct PIN.
+func (p *pinAttempts) reset(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.failures, key)
+}
+
+// kioskCredentials identify a guardian at the kiosk, either by their contact number and PIN
+// or by the payload of their QR code.
+type kioskCredentials struct {
+	Contact string `json:"contact"`
+	PIN     string `json:"pin"`
+	QR      string `json:"qr"`
+}
+
+// guardianQRCode returns the QR payload for a guardian, which is their id followed by an
+// HMAC-SHA256 signature of it. Rotating the kiosk secret invalidates every issued code.
+func (app *application) guardianQRCode(guardianID int64) string {
+	return fmt.Sprintf("%d.%s", guardianID, app.signGuardianID(guardianID))
+}
+
+func (app *application) signGuardianID(guardianID int64) string {
+	mac := hmac.New(sha256.New, []byte(app.cfg.kioskSecret))
+	fmt.Fprintf(mac, "guardian:%d", guardianID)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// authenticateGuardian returns the guardian matching the kiosk credentials, or
+// errInvalidGuardianCredentials if there is none. PINs entered for a contact number or at a kiosk
+// that has seen too many wrong ones lately return errTooManyPINAttempts instead.
+func (app *application) authenticateGuardian(r *http.Request, credentials kioskCredentials) (*data.Guardian, error) {
+	if credentials.QR != "" {
+		if app.cfg.kioskSecret == "" {
+			return nil, errInvalidGuardianCredentials
+		}
+
+		id, signature, found := strings.Cut(credentials.QR, ".")
+		if !found {
+			return nil, errInvalidGuardianCredentials
+		}
+
+		guardianID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || !hmac.Equal([]byte(signature), []byte(app.signGuardianID(guardianID))) {
+			return nil, errInvalidGuardianCredentials
+		}
+
+		guardian, err := app.models.Guardians.Get(guardianID)
+		if err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				return nil, errInvalidGuardianCredentials
+			}
+			return nil, err
+		}
+
+		return guardian, nil
+	}
+
+	if credentials.Contact == "" || credentials.PIN == "" {
+		return nil, errInvalidGuardianCredentials
+	}
+
+	now := time.Now()
+	contactKey := "contact:" + strings.TrimSpace(credentials.Contact)
+	deviceKey := fmt.Sprintf("device:%d", app.contextGetKioskDevice(r).DeviceID)
+
+	if app.pinAttempts.locked(now, contactKey, deviceKey) {
+		return nil, errTooManyPINAttempts
+	}
+
+	guardians, err := app.models.Guardians.GetAllWithPINByContact(credentials.Contact)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, guardian := range guardians {
+		err := bcrypt.CompareHashAndPassword(guardian.PINHash, []byte(credentials.PIN))
+		if err == nil {
+			app.pinAttempts.reset(contactKey)
+			return guardian, nil
+		}
+		if !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, err
+		}
+	}
+
+	app.pinAttempts.fail(now, contactKey, pinFailuresPerContact)
+	app.pinAttempts.fail(now, deviceKey, pinFailuresPerDevice)
+
+	return nil, errInvalidGuardianCredentials
+}
+
+// guardianStudents returns the children of the guardian limited to studentIDs, or all of them
+// if studentIDs is empty. ok is false if studentIDs contains a student who isn't their child.
+func (app *application) guardianStudents(guardianID int64, studentIDs []int64) (students []*data.Student, ok bool, err error) {
+	children, err := app.models.StudentGuardian.GetStudentsByGuardianID(guardianID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(studentIDs) == 0 {
+		return children, true, nil
+	}
+
+	for _, id := range studentIDs {
+		found := false
+		for _, child := range children {
+			if child.StudentID == id {
+				students = append(students, child)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, false, nil
+		}
+	}
+
+	return students, true, nil
+}
+
+func (app *application) createKioskDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name string `json:"name"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if strings.TrimSpace(input.Name) == "" {
+		app.failedValidationResponse(w, r, map[string]string{"name": "must be provided"})
+		return
+	}
+
+	device, token, err := app.models.KioskDevices.New(input.Name)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// The token is only ever shown here, it has to be entered on the device straight away.
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"device": device, "token": token}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listKioskDevicesHandler(w http.ResponseWriter, r *http.Request) {
+	devices, err := app.models.KioskDevices.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"devices": devices}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) revokeKioskDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.KioskDevices.Revoke(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "kiosk device revoked successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) setGuardianPINHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		PIN string `json:"pin"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if len(input.PIN) < 4 || len(input.PIN) > 8 || strings.Trim(input.PIN, "0123456789") != "" {
+		app.failedValidationResponse(w, r, map[string]string{"pin": "must be 4 to 8 digits"})
+		return
+	}
+
+	pinHash, err := bcrypt.GenerateFromPassword([]byte(input.PIN), 12)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Guardians.SetPIN(id, pinHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "guardian pin set successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showGuardianQRHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	if app.cfg.kioskSecret == "" {
+		app.errorResponse(w, r, http.StatusServiceUnavailable, "qr codes are not configured on this server")
+		return
+	}
+
+	guardian, err := app.models.Guardians.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"qr": app.guardianQRCode(guardian.GuardianID)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) kioskLookupHandler(w http.ResponseWriter, r *http.Request) {
+	var input kioskCredentials
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	guardian, err := app.authenticateGuardian(r, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidGuardianCredentials):
+			app.invalidCredentialsResponse(w, r)
+		case errors.Is(err, errTooManyPINAttempts):
+			app.tooManyPINAttemptsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	students, err := app.models.StudentGuardian.GetStudentsByGuardianID(guardian.GuardianID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"guardian": guardian, "students": students}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) kioskCheckInHandler(w http.ResponseWriter, r *http.Request) {
+	app.kioskAttendance(w, r, app.models.StudentAttendance.CheckIn)
+}
+
+// kioskCheckOutHandler checks the guardian's children out, charging a late pickup fee for
+// children picked up after closing.
+func (app *application) kioskCheckOutHandler(w http.ResponseWriter, r *http.Request) {
+	app.kioskAttendance(w, r, func(studentID int64, at time.Time) ([]*data.StudentAttendance, error) {
+		records, err := app.models.StudentAttendance.CheckOut(studentID, at)
+		if err == nil && len(records) > 0 {
+			app.chargeLatePickup(r, studentID, at)
+		}
+		return records, err
+	})
+}
+
+// kioskAttendance checks the guardian's children in or out using record. Children the
+// record doesn't apply to, such as ones who weren't checked in when checking out, are
+// listed under "skipped".
+func (app *application) kioskAttendance(w http.ResponseWriter, r *http.Request, record func(int64, time.Time) ([]*data.StudentAttendance, error)) {
+	var input struct {
+		kioskCredentials
+		StudentIDs []int64 `json:"student_ids"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	guardian, err := app.authenticateGuardian(r, input.kioskCredentials)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidGuardianCredentials):
+			app.invalidCredentialsResponse(w, r)
+		case errors.Is(err, errTooManyPINAttempts):
+			app.tooManyPINAttemptsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	students, ok, err := app.guardianStudents(guardian.GuardianID, input.StudentIDs)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !ok {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	// Attendance is kept by the center's day, like late pickup fees.
+	now := time.Now().In(app.cfg.location)
+	attendance := []*data.StudentAttendance{}
+	skipped := []int64{}
+
+	for _, student := range students {
+		records, err := record(student.StudentID, now)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if len(records) == 0 {
+			skipped = append(skipped, student.StudentID)
+		}
+		attendance = append(attendance, records...)
+	}
+
+	device := app.contextGetKioskDevice(r)
+	app.logger.Info("kiosk attendance recorded", "device_id", device.DeviceID, "guardian_id", guardian.GuardianID, "path", r.URL.Path)
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"attendance": attendance, "skipped": skipped}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPINAttempts(t *testing.T) {
+	attempts := newPINAttempts()
+	now := time.Now()
+
+	for i := 1; i < pinFailuresPerContact; i++ {
+		attempts.fail(now, "contact:555-0100", pinFailuresPerContact)
+	}
+	if attempts.locked(now, "contact:555-0100") {
+		t.Fatalf("locked out after %d wrong PINs, want %d", pinFailuresPerContact-1, pinFailuresPerContact)
+	}
+
+	attempts.fail(now, "contact:555-0100", pinFailuresPerContact)
+	if !attempts.locked(now, "contact:555-0100") {
+		t.Fatalf("not locked out after %d wrong PINs", pinFailuresPerContact)
+	}
+	if !attempts.locked(now, "device:1", "contact:555-0100") {
+		t.Errorf("a locked out contact isn't locked out together with a device")
+	}
+	if attempts.locked(now, "contact:555-0199") {
+		t.Errorf("another contact is locked out too")
+	}
+	if attempts.locked(now.Add(pinLockout), "contact:555-0100") {
+		t.Errorf("still locked out after %s", pinLockout)
+	}
+
+	// Failures spread out over more than the window don't add up.
+	for i := 0; i < pinFailuresPerContact; i++ {
+		attempts.fail(now.Add(time.Duration(i)*(pinFailureWindow+time.Minute)), "contact:555-0101", pinFailuresPerContact)
+	}
+	if attempts.locked(now.Add(time.Duration(pinFailuresPerContact-1)*(pinFailureWindow+time.Minute)), "contact:555-0101") {
+		t.Errorf("locked out by wrong PINs far apart")
+	}
+
+	// A correct PIN starts the count again.
+	for i := 1; i < pinFailuresPerContact; i++ {
+		attempts.fail(now, "contact:555-0102", pinFailuresPerContact)
+	}
+	attempts.reset("contact:555-0102")
+	attempts.fail(now, "contact:555-0102", pinFailuresPerContact)
+	if attempts.locked(now, "contact:555-0102") {
+		t.Errorf("locked out by wrong PINs entered before a correct one")
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/billing"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func (app *application) createLateFeeRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		EffectiveFrom *data.Date `json:"effective_from"`
+		ClosesAt      string     `json:"closes_at"`
+		GraceMinutes  int        `json:"grace_minutes"`
+		Charge        string     `json:"charge"`
+		BlockMinutes  int        `json:"block_minutes"`
+		RateCents     int64      `json:"rate_cents"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	rule := &data.LateFeeRule{
+		EffectiveFrom: data.Date(time.Now()),
+		ClosesAt:      input.ClosesAt,
+		GraceMinutes:  input.GraceMinutes,
+		Charge:        input.Charge,
+		BlockMinutes:  input.BlockMinutes,
+		RateCents:     input.RateCents,
+	}
+	if input.EffectiveFrom != nil {
+		rule.EffectiveFrom = *input.EffectiveFrom
+	}
+	if rule.Charge == "per_minute" && rule.BlockMinutes == 0 {
+		rule.BlockMinutes = 1
+	}
+
+	errs := map[string]string{}
+	if _, err := time.Parse("15:04", rule.ClosesAt); err != nil {
+		errs["closes_at"] = "must be a time in the format HH:MM"
+	}
+	if rule.GraceMinutes < 0 {
+		errs["grace_minutes"] = "must not be negative"
+	}
+	if rule.Charge != "per_minute" && rule.Charge != "per_block" {
+		errs["charge"] = "must be per_minute or per_block"
+	}
+	if rule.BlockMinutes < 1 {
+		errs["block_minutes"] = "must be at least 1"
+	} else if rule.Charge == "per_minute" && rule.BlockMinutes != 1 {
+		errs["block_minutes"] = "must be 1 when charging per minute"
+	}
+	if rule.RateCents < 0 {
+		errs["rate_cents"] = "must not be negative"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.LateFeeRules.Insert(rule)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateLateFeeRule):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"rule": rule}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listLateFeeRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := app.models.LateFeeRules.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"rules": rules}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteLateFeeRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.LateFeeRules.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrLateFeeRuleInEffect):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "late fee rule deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) waiveLateFeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	fee, err := app.models.LateFees.Waive(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrLateFeeInvoiced):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"late_fee": fee}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// lateFeeReportHandler lists the late pickups of a month, given as YYYY-MM and defaulting to the
+// current month, with totals per student.
+func (app *application) lateFeeReportHandler(w http.ResponseWriter, r *http.Request) {
+	month := billing.MonthOf(time.Now())
+	if s := r.URL.Query().Get("month"); s != "" {
+		t, err := time.Parse("2006-01", s)
+		if err != nil {
+			app.failedValidationResponse(w, r, map[string]string{"month": "must be a month in the format YYYY-MM"})
+			return
+		}
+		month = billing.MonthOf(t)
+	}
+
+	summaries, err := app.models.LateFees.GetSummaries(month.Start, month.End)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	fees, err := app.models.LateFees.GetAll(month.Start, month.End)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{
+		"from":         data.Date(month.Start),
+		"to":           data.Date(month.End),
+		"students":     summaries,
+		"late_pickups": fees,
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// chargeLatePickup charges a late pickup fee if the student was checked out at after closing.
+// A failure is only logged, the check-out itself has already been recorded.
+func (app *application) chargeLatePickup(r *http.Request, studentID int64, at time.Time) {
+	at = at.In(app.cfg.location)
+
+	rule, err := app.models.LateFeeRules.GetInEffect(at)
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			app.logError(r, err)
+		}
+		return
+	}
+
+	fee, err := billing.LateFee(rule, studentID, at, app.cfg.location)
+	if err != nil || fee == nil {
+		if err != nil {
+			app.logError(r, err)
+		}
+		return
+	}
+
+	inserted, err := app.models.LateFees.Insert(fee)
+	if err != nil {
+		app.logError(r, fmt.Errorf("charging late pickup of student %d: %w", studentID, err))
+		return
+	}
+
+	if inserted {
+		app.logger.Info("late pickup fee charged", "student_id", studentID, "fee_id", fee.FeeID, "minutes_late", fee.MinutesLate, "amount_cents", fee.AmountCents)
+	}
+}
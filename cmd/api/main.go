@@ -6,10 +6,13 @@ import (
 	"flag"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/liamgluna/daycare-server/internal/billing"
 	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/events"
 	_ "github.com/lib/pq"
 )
 
@@ -21,14 +24,47 @@ type config struct {
 		maxIdleConns int
 		maxIdleTime  string
 	}
-	allowCORS string
-	jwtSecret string
+	allowCORS           string
+	jwtSecret           string
+	kioskSecret         string
+	overtimeWeeklyHours float64
+	childrenPerStaff    int
+	absences            struct {
+		window       int
+		threshold    int
+		scanInterval time.Duration
+		notify       bool
+	}
+	credentials struct {
+		required    []string
+		warningDays int
+	}
+	provider struct {
+		name    string
+		address string
+		taxID   string
+	}
+	payments struct {
+		provider      string
+		webhookSecret string
+	}
+	sleep struct {
+		checkInterval time.Duration
+		scanInterval  time.Duration
+	}
+	// location is the center's time zone, which closing times are in.
+	location *time.Location
 }
 
 type application struct {
 	cfg    config
 	logger *slog.Logger
 	models data.Models
+	events *events.Hub
+	// payments takes card payments, and is nil when no payment provider is configured.
+	payments billing.PaymentProvider
+	// pinAttempts locks kiosk PIN sign-in for a while after too many wrong PINs.
+	pinAttempts *pinAttempts
 }
 
 func main() {
@@ -48,9 +84,78 @@ func main() {
 	flag.StringVar(&cfg.db.maxIdleTime, "db-max-idle-time", "15m", "PostgreSQL max connection idle time")
 	flag.StringVar(&cfg.allowCORS, "allowCORS", os.Getenv("ALLOW_CORS"), "Allow CORS")
 	flag.StringVar(&cfg.jwtSecret, "jwt-secret", os.Getenv("JWT_SECRET"), "JWT secret key")
+	flag.StringVar(&cfg.kioskSecret, "kiosk-secret", os.Getenv("KIOSK_SECRET"), "Secret used to sign guardian QR codes")
+	flag.Float64Var(&cfg.overtimeWeeklyHours, "overtime-weekly-hours", 40, "Weekly hours after which staff time counts as overtime")
+	flag.IntVar(&cfg.childrenPerStaff, "children-per-staff", 8, "Children each member of staff may look after")
+	flag.Func("timezone", "IANA time zone of the center, such as America/Chicago (default the server's time zone)", func(s string) error {
+		location, err := time.LoadLocation(s)
+		if err != nil {
+			return err
+		}
+		cfg.location = location
+		return nil
+	})
+	flag.IntVar(&cfg.absences.window, "absence-window", 10, "Number of recent sessions checked for chronic absence")
+	flag.IntVar(&cfg.absences.threshold, "absence-threshold", 5, "Absences within the window that raise an alert")
+	flag.DurationVar(&cfg.absences.scanInterval, "absence-scan-interval", time.Hour, "How often to scan attendance for chronic absence")
+	flag.BoolVar(&cfg.absences.notify, "absence-notify", false, "Notify the class faculty member when an absence alert is raised")
+
+	flag.Func("required-credentials", "Comma separated credential types every active faculty member must hold (default cpr,first_aid,background_check)", func(s string) error {
+		cfg.credentials.required = nil
+		for _, t := range strings.Split(s, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				cfg.credentials.required = append(cfg.credentials.required, t)
+			}
+		}
+		return nil
+	})
+	flag.IntVar(&cfg.credentials.warningDays, "credential-warning-days", 30, "Days before expiry that a credential is flagged")
+	flag.StringVar(&cfg.provider.name, "provider-name", os.Getenv("PROVIDER_NAME"), "Name of the daycare printed on invoices, receipts and tax statements")
+	flag.StringVar(&cfg.provider.address, "provider-address", os.Getenv("PROVIDER_ADDRESS"), "Address of the daycare printed on invoices, receipts and tax statements")
+	flag.StringVar(&cfg.provider.taxID, "provider-tax-id", os.Getenv("PROVIDER_TAX_ID"), "Tax ID of the daycare printed on year-end tax statements")
+	flag.StringVar(&cfg.payments.provider, "payment-provider", os.Getenv("PAYMENT_PROVIDER"), "Payment provider for card payments (fake), card payments are off if empty")
+	flag.StringVar(&cfg.payments.webhookSecret, "payment-webhook-secret", os.Getenv("PAYMENT_WEBHOOK_SECRET"), "Secret the payment provider signs its webhooks with")
+	flag.DurationVar(&cfg.sleep.checkInterval, "sleep-check-interval", 15*time.Minute, "Longest a sleeping child may go without a documented sleep check")
+	flag.DurationVar(&cfg.sleep.scanInterval, "sleep-scan-interval", time.Minute, "How often to look for overdue sleep checks")
+
+	cfg.credentials.required = []string{"cpr", "first_aid", "background_check"}
+	cfg.location = time.Local
 
 	flag.Parse()
 
+	if cfg.childrenPerStaff < 1 {
+		logger.Error("children-per-staff must be at least 1")
+		os.Exit(1)
+	}
+
+	if cfg.absences.threshold < 1 || cfg.absences.threshold > cfg.absences.window {
+		logger.Error("absence-threshold must be between 1 and absence-window")
+		os.Exit(1)
+	}
+
+	if cfg.sleep.checkInterval <= 0 || cfg.sleep.scanInterval <= 0 {
+		logger.Error("sleep-check-interval and sleep-scan-interval must be positive")
+		os.Exit(1)
+	}
+
+	if cfg.provider.name == "" {
+		cfg.provider.name = "Daycare"
+	}
+
+	var payments billing.PaymentProvider
+	switch cfg.payments.provider {
+	case "":
+	case "fake":
+		if cfg.payments.webhookSecret == "" {
+			logger.Error("payment-webhook-secret must be set for the payment provider")
+			os.Exit(1)
+		}
+		payments = billing.NewFakeProvider(cfg.payments.webhookSecret)
+	default:
+		logger.Error("unknown payment-provider " + cfg.payments.provider)
+		os.Exit(1)
+	}
+
 	db, err := openDB(cfg)
 	if err != nil {
 		logger.Error(err.Error())
@@ -61,12 +166,19 @@ func main() {
 
 	defer db.Close()
 
+	hub := events.NewHub(100)
+
 	app := &application{
-		cfg:    cfg,
-		logger: logger,
-		models: data.NewModels(db),
+		cfg:         cfg,
+		logger:      logger,
+		models:      data.NewModels(db, hub),
+		events:      hub,
+		payments:    payments,
+		pinAttempts: newPINAttempts(),
 	}
 
+	app.startJobs()
+
 	err = app.serve()
 	if err != nil {
 		app.logger.Error(err.Error())
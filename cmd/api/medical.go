@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/menu"
+)
+
+var allergySeverities = []string{"mild", "moderate", "severe", "life_threatening"}
+
+// readStudentID returns the ID of the student in the URL, having written a response already if
+// there is no such student.
+func (app *application) readStudentID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return 0, false
+	}
+
+	_, err = app.models.Students.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (app *application) showMedicalProfileHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	profile, err := app.models.MedicalProfiles.Get(studentID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"medical": profile}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateMedicalProfileHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	profile, err := app.models.MedicalProfiles.Get(studentID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Conditions            *string `json:"conditions"`
+		Medications           *string `json:"medications"`
+		DietaryRestrictions   *string `json:"dietary_restrictions"`
+		PhysicianName         *string `json:"physician_name"`
+		PhysicianPhone        *string `json:"physician_phone"`
+		InsuranceProvider     *string `json:"insurance_provider"`
+		InsurancePolicyNumber *string `json:"insurance_policy_number"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Conditions != nil {
+		profile.Conditions = strings.TrimSpace(*input.Conditions)
+	}
+	if input.Medications != nil {
+		profile.Medications = strings.TrimSpace(*input.Medications)
+	}
+	if input.DietaryRestrictions != nil {
+		profile.DietaryRestrictions = strings.TrimSpace(*input.DietaryRestrictions)
+	}
+	if input.PhysicianName != nil {
+		profile.PhysicianName = strings.TrimSpace(*input.PhysicianName)
+	}
+	if input.PhysicianPhone != nil {
+		profile.PhysicianPhone = strings.TrimSpace(*input.PhysicianPhone)
+	}
+	if input.InsuranceProvider != nil {
+		profile.InsuranceProvider = strings.TrimSpace(*input.InsuranceProvider)
+	}
+	if input.InsurancePolicyNumber != nil {
+		profile.InsurancePolicyNumber = strings.TrimSpace(*input.InsurancePolicyNumber)
+	}
+	profile.UpdatedBy = &app.contextGetFaculty(r).FacultyID
+
+	err = app.models.MedicalProfiles.Upsert(profile)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"medical": profile}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func validateAllergy(allergy *data.Allergy) map[string]string {
+	errs := map[string]string{}
+
+	switch {
+	case allergy.Allergen == "":
+		errs["allergen"] = "must be provided"
+	case !menu.IsAllergen(allergy.Allergen):
+		errs["allergen"] = "must be one of " + strings.Join(menu.Allergens, ", ")
+	case allergy.Allergen == "other" && allergy.Details == "":
+		errs["details"] = "must say what the allergen is"
+	}
+
+	valid := false
+	for _, severity := range allergySeverities {
+		valid = valid || allergy.Severity == severity
+	}
+	if !valid {
+		errs["severity"] = "must be mild, moderate, severe or life_threatening"
+	}
+
+	return errs
+}
+
+func (app *application) createAllergyHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Allergen  string `json:"allergen"`
+		Details   string `json:"details"`
+		Severity  string `json:"severity"`
+		Reaction  string `json:"reaction"`
+		Treatment string `json:"treatment"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	allergy := &data.Allergy{
+		StudentID: studentID,
+		Allergen:  menu.Tag(input.Allergen),
+		Details:   strings.TrimSpace(input.Details),
+		Severity:  input.Severity,
+		Reaction:  strings.TrimSpace(input.Reaction),
+		Treatment: strings.TrimSpace(input.Treatment),
+	}
+
+	if errs := validateAllergy(allergy); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Allergies.Insert(allergy)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateAllergy):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"allergy": allergy}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateAllergyHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || studentID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	allergyID, err := strconv.ParseInt(chi.URLParam(r, "allergyID"), 10, 64)
+	if err != nil || allergyID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	allergy, err := app.models.Allergies.Get(studentID, allergyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Allergen  *string `json:"allergen"`
+		Details   *string `json:"details"`
+		Severity  *string `json:"severity"`
+		Reaction  *string `json:"reaction"`
+		Treatment *string `json:"treatment"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Allergen != nil {
+		allergy.Allergen = menu.Tag(*input.Allergen)
+	}
+	if input.Details != nil {
+		allergy.Details = strings.TrimSpace(*input.Details)
+	}
+	if input.Severity != nil {
+		allergy.Severity = *input.Severity
+	}
+	if input.Reaction != nil {
+		allergy.Reaction = strings.TrimSpace(*input.Reaction)
+	}
+	if input.Treatment != nil {
+		allergy.Treatment = strings.TrimSpace(*input.Treatment)
+	}
+
+	if errs := validateAllergy(allergy); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Allergies.Update(allergy)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateAllergy):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"allergy": allergy}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteAllergyHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || studentID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	allergyID, err := strconv.ParseInt(chi.URLParam(r, "allergyID"), 10, 64)
+	if err != nil || allergyID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Allergies.Delete(studentID, allergyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "allergy deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
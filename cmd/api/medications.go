@@ -0,0 +1,394 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/medication"
+)
+
+// createMedicationHandler records a medication authorization for the student. It has to be
+// signed by one of the student's guardians at the kiosk before any dose can be given.
+func (app *application) createMedicationHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Medication         string     `json:"medication"`
+		Dosage             string     `json:"dosage"`
+		Route              string     `json:"route"`
+		Instructions       string     `json:"instructions"`
+		Times              []string   `json:"times"`
+		MinIntervalMinutes *int       `json:"min_interval_minutes"`
+		MaxDailyDoses      *int       `json:"max_daily_doses"`
+		StartsOn           *data.Date `json:"starts_on"`
+		EndsOn             *data.Date `json:"ends_on"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	authorization := &data.MedicationAuthorization{
+		StudentID:          studentID,
+		Medication:         strings.TrimSpace(input.Medication),
+		Dosage:             strings.TrimSpace(input.Dosage),
+		Route:              strings.TrimSpace(input.Route),
+		Instructions:       strings.TrimSpace(input.Instructions),
+		Times:              []string{},
+		MinIntervalMinutes: 120,
+		MaxDailyDoses:      len(input.Times),
+		CreatedBy:          &app.contextGetFaculty(r).FacultyID,
+	}
+	if input.MinIntervalMinutes != nil {
+		authorization.MinIntervalMinutes = *input.MinIntervalMinutes
+	}
+	if input.MaxDailyDoses != nil {
+		authorization.MaxDailyDoses = *input.MaxDailyDoses
+	}
+
+	errs := map[string]string{}
+	if authorization.Medication == "" {
+		errs["medication"] = "must be provided"
+	}
+	if authorization.Dosage == "" {
+		errs["dosage"] = "must be provided"
+	}
+	for _, value := range input.Times {
+		t, err := time.Parse("15:04", strings.TrimSpace(value))
+		if err != nil {
+			errs["times"] = "must be times of day as HH:MM"
+			break
+		}
+		if slot := t.Format("15:04"); !slices.Contains(authorization.Times, slot) {
+			authorization.Times = append(authorization.Times, slot)
+		}
+	}
+	slices.Sort(authorization.Times)
+	if authorization.MinIntervalMinutes < 0 {
+		errs["min_interval_minutes"] = "must not be negative"
+	}
+	if authorization.MaxDailyDoses < 1 {
+		errs["max_daily_doses"] = "must be at least 1"
+	}
+	switch {
+	case input.StartsOn == nil:
+		errs["starts_on"] = "must be provided"
+	case input.EndsOn == nil:
+		errs["ends_on"] = "must be provided"
+	case time.Time(*input.EndsOn).Before(time.Time(*input.StartsOn)):
+		errs["ends_on"] = "must not be before starts_on"
+	default:
+		authorization.StartsOn = *input.StartsOn
+		authorization.EndsOn = *input.EndsOn
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Medications.Insert(authorization)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"medication": authorization}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listMedicationsHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	authorizations, err := app.models.Medications.GetAllByStudentID(studentID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"medications": authorizations}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readMedication returns the student's authorization in the URL, having written a response
+// already if there is no such authorization.
+func (app *application) readMedication(w http.ResponseWriter, r *http.Request) (*data.MedicationAuthorization, bool) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	authorizationID, err := strconv.ParseInt(chi.URLParam(r, "authorizationID"), 10, 64)
+	if err != nil || authorizationID < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	authorization, err := app.models.Medications.Get(studentID, authorizationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return authorization, true
+}
+
+func (app *application) revokeMedicationHandler(w http.ResponseWriter, r *http.Request) {
+	authorization, ok := app.readMedication(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.Medications.Revoke(authorization)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAuthorizationRevoked):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"medication": authorization}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createMedicationDoseHandler records a dose given under the authorization. Doses given too
+// soon after another, or beyond the day's maximum, are refused with a conflict so a child can't
+// be given the same medication twice by different staff.
+func (app *application) createMedicationDoseHandler(w http.ResponseWriter, r *http.Request) {
+	authorization, ok := app.readMedication(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		GivenAt   *time.Time `json:"given_at"`
+		DoseGiven string     `json:"dose_given"`
+		Notes     string     `json:"notes"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	administration := &data.MedicationAdministration{
+		AuthorizationID: authorization.AuthorizationID,
+		StudentID:       authorization.StudentID,
+		GivenAt:         time.Now().Truncate(time.Second),
+		GivenBy:         &app.contextGetFaculty(r).FacultyID,
+		DoseGiven:       strings.TrimSpace(input.DoseGiven),
+		Notes:           strings.TrimSpace(input.Notes),
+	}
+	if input.GivenAt != nil {
+		administration.GivenAt = input.GivenAt.Local()
+	}
+	if administration.DoseGiven == "" {
+		administration.DoseGiven = authorization.Dosage
+	}
+
+	if administration.GivenAt.After(time.Now()) {
+		app.failedValidationResponse(w, r, map[string]string{"given_at": "must not be in the future"})
+		return
+	}
+
+	err = app.models.MedicationDoses.Insert(administration)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrAuthorizationNotActive),
+			errors.Is(err, data.ErrDoseTooSoon),
+			errors.Is(err, data.ErrDailyDosesGiven):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"dose": administration}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listMedicationDosesHandler(w http.ResponseWriter, r *http.Request) {
+	authorization, ok := app.readMedication(w, r)
+	if !ok {
+		return
+	}
+
+	administrations, err := app.models.MedicationDoses.GetAllByAuthorizationID(authorization.AuthorizationID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"doses": administrations}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listDueMedicationsHandler lists the class's scheduled doses that are due now. A dose is due
+// from window minutes before its time, 30 by default, and overdue window minutes after it.
+func (app *application) listDueMedicationsHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	window := app.readInt(r.URL.Query(), "window", 30)
+	if window < 0 {
+		app.failedValidationResponse(w, r, map[string]string{"window": "must not be negative"})
+		return
+	}
+
+	now := time.Now()
+	year, month, day := now.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	authorizations, err := app.models.Medications.GetActiveByClassID(classID, now)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	administrations, err := app.models.MedicationDoses.GetAllByClassID(classID, midnight, midnight.AddDate(0, 0, 1))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	due := medication.Due(authorizations, administrations, now, time.Duration(window)*time.Minute)
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"due": due}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// kioskPendingMedicationsHandler lists the authorizations for the guardian's children that are
+// waiting for their signature.
+func (app *application) kioskPendingMedicationsHandler(w http.ResponseWriter, r *http.Request) {
+	var input kioskCredentials
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	guardian, err := app.authenticateGuardian(r, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidGuardianCredentials):
+			app.invalidCredentialsResponse(w, r)
+		case errors.Is(err, errTooManyPINAttempts):
+			app.tooManyPINAttemptsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	pending, err := app.models.Medications.GetPendingByGuardianID(guardian.GuardianID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"medications": pending}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// kioskSignMedicationHandler signs an authorization on behalf of the guardian, who has to be a
+// guardian of the student it is for.
+func (app *application) kioskSignMedicationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input kioskCredentials
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	guardian, err := app.authenticateGuardian(r, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidGuardianCredentials):
+			app.invalidCredentialsResponse(w, r)
+		case errors.Is(err, errTooManyPINAttempts):
+			app.tooManyPINAttemptsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	pending, err := app.models.Medications.GetPendingByGuardianID(guardian.GuardianID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	index := slices.IndexFunc(pending, func(m *data.ClassMedication) bool { return m.AuthorizationID == id })
+	if index < 0 {
+		app.notFoundResponse(w, r)
+		return
+	}
+	authorization := pending[index]
+
+	err = app.models.Medications.Sign(&authorization.MedicationAuthorization, guardian.GuardianID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAuthorizationNotPending):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"medication": authorization}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
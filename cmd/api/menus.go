@@ -0,0 +1,375 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/menu"
+)
+
+func validateMenuItem(item *data.MenuItem, errs map[string]string) {
+	if time.Time(item.ServedOn).IsZero() {
+		errs["served_on"] = "must be provided"
+	}
+	if !slices.Contains(menu.Meals, item.Meal) {
+		errs["meal"] = "must be breakfast, am_snack, lunch, pm_snack or dinner"
+	}
+	if item.Name == "" {
+		errs["name"] = "must be provided"
+	}
+	if slices.ContainsFunc(item.Allergens, func(tag string) bool { return !menu.IsAllergen(tag) }) {
+		errs["allergens"] = "must each be one of " + strings.Join(menu.Allergens, ", ")
+	}
+}
+
+// listMenuHandler shows the menu for the week containing the "week" query string date, or for
+// the current week.
+func (app *application) listMenuHandler(w http.ResponseWriter, r *http.Request) {
+	weekStart, err := app.readWeek(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	items, err := app.models.MenuItems.GetAll(weekStart, weekStart.AddDate(0, 0, 6))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"week_start": data.Date(weekStart), "items": items}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createMenuItemHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ServedOn  data.Date `json:"served_on"`
+		Meal      string    `json:"meal"`
+		Name      string    `json:"name"`
+		Allergens []string  `json:"allergens"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	item := &data.MenuItem{
+		ServedOn:  input.ServedOn,
+		Meal:      strings.TrimSpace(input.Meal),
+		Name:      strings.TrimSpace(input.Name),
+		Allergens: menu.Tags(input.Allergens),
+		CreatedBy: &app.contextGetFaculty(r).FacultyID,
+	}
+
+	errs := map[string]string{}
+	validateMenuItem(item, errs)
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.MenuItems.Insert(item)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateMenuItem):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"item": item}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readMenuItem returns the menu item in the URL, having written a response already if there is
+// no such item.
+func (app *application) readMenuItem(w http.ResponseWriter, r *http.Request) (*data.MenuItem, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	item, err := app.models.MenuItems.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return item, true
+}
+
+// showMenuItemHandler shows the item with the substitutions recorded for it.
+func (app *application) showMenuItemHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := app.readMenuItem(w, r)
+	if !ok {
+		return
+	}
+
+	substitutions, err := app.models.Substitutions.GetAllByItemID(item.ItemID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"item": item, "substitutions": substitutions}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateMenuItemHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := app.readMenuItem(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		ServedOn  *data.Date `json:"served_on"`
+		Meal      *string    `json:"meal"`
+		Name      *string    `json:"name"`
+		Allergens []string   `json:"allergens"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.ServedOn != nil {
+		item.ServedOn = *input.ServedOn
+	}
+	if input.Meal != nil {
+		item.Meal = strings.TrimSpace(*input.Meal)
+	}
+	if input.Name != nil {
+		item.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Allergens != nil {
+		item.Allergens = menu.Tags(input.Allergens)
+	}
+
+	errs := map[string]string{}
+	validateMenuItem(item, errs)
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.MenuItems.Update(item)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateMenuItem):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"item": item}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteMenuItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.MenuItems.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "menu item deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createSubstitutionHandler records what a student gets instead of the menu item. Recording
+// another substitution for the same student replaces it.
+func (app *application) createSubstitutionHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := app.readMenuItem(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		StudentID  int64  `json:"student_id"`
+		Substitute string `json:"substitute"`
+		Notes      string `json:"notes"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	substitution := &data.MenuSubstitution{
+		ItemID:     item.ItemID,
+		StudentID:  input.StudentID,
+		Substitute: strings.TrimSpace(input.Substitute),
+		Notes:      strings.TrimSpace(input.Notes),
+		RecordedBy: &app.contextGetFaculty(r).FacultyID,
+	}
+
+	errs := map[string]string{}
+	if substitution.Substitute == "" {
+		errs["substitute"] = "must be provided"
+	}
+
+	_, err = app.models.Students.Get(substitution.StudentID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		errs["student_id"] = "must be an existing student"
+	case err != nil:
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Substitutions.Upsert(substitution)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"substitution": substitution}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteSubstitutionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	substitutionID, err := strconv.ParseInt(chi.URLParam(r, "substitutionID"), 10, 64)
+	if err != nil || substitutionID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Substitutions.Delete(id, substitutionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "substitution deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// menuConflictsHandler lists the students of the class who are allergic to something on the
+// menu today or on the "date" query string day, or whose dietary restrictions rule it out, with
+// any substitution recorded for them.
+func (app *application) menuConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	day, err := app.readDay(r)
+	if err != nil {
+		app.failedValidationResponse(w, r, map[string]string{"date": "must be a date in the format YYYY-MM-DD"})
+		return
+	}
+
+	items, err := app.models.MenuItems.GetAll(day, day)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	students, err := app.models.ClassStudents.GetStudentsByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	allergies, err := app.models.Allergies.GetFlagsByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	restrictions, err := app.models.MedicalProfiles.GetDietaryRestrictionsByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	substitutions, err := app.models.Substitutions.GetAllByClassID(classID, day)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	conflicts := menu.Conflicts(items, students, allergies, restrictions, substitutions)
+	unresolved := 0
+	for _, conflict := range conflicts {
+		if conflict.Substitution == nil {
+			unresolved++
+		}
+	}
+
+	env := envelope{
+		"date":       data.Date(day),
+		"conflicts":  conflicts,
+		"unresolved": unresolved,
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
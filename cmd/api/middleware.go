@@ -1,11 +1,18 @@
 package main
 
 import (
+	"errors"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
 	"golang.org/x/time/rate"
 )
 
@@ -70,3 +77,181 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// requireAuthenticatedFaculty only lets a request through if it carries a valid jwt cookie for an
+// active faculty member, who is then available to the handler through contextGetFaculty.
+func (app *application) requireAuthenticatedFaculty(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// already authenticated further up the chain
+		if _, ok := r.Context().Value(facultyContextKey).(*data.Faculty); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie("jwt")
+		if err != nil {
+			switch {
+			case errors.Is(err, http.ErrNoCookie):
+				app.authenticationRequiredResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		token, err := jwt.ParseWithClaims(cookie.Value, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+			return []byte(app.cfg.jwtSecret), nil
+		})
+		if err != nil {
+			app.invalidCredentialsResponse(w, r)
+			return
+		}
+
+		claims := token.Claims.(*jwt.RegisteredClaims)
+
+		id, err := strconv.ParseInt(claims.Issuer, 10, 64)
+		if err != nil {
+			app.invalidCredentialsResponse(w, r)
+			return
+		}
+
+		faculty, err := app.models.Faculty.Get(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.invalidCredentialsResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if !faculty.Active {
+			app.inactiveAccountResponse(w, r)
+			return
+		}
+
+		r = app.contextSetFaculty(r, faculty)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdmin is like requireAuthenticatedFaculty, but also requires the faculty member to be an admin.
+func (app *application) requireAdmin(next http.Handler) http.Handler {
+	return app.requireAuthenticatedFaculty(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		faculty := app.contextGetFaculty(r)
+
+		if !faculty.IsAdmin {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// requireClassAccess only lets authenticated faculty through to a class's routes if they are an
+// admin, are assigned to the class, or are covering it under an approved coverage grant. Every
+// request made under a coverage grant is recorded in the grant's audit trail.
+func (app *application) requireClassAccess(next http.Handler) http.Handler {
+	return app.requireAccess(next, "classID", app.models.ClassFaculty.IsAssigned, app.models.Coverage.GetActive)
+}
+
+// requireStudentAccess is requireClassAccess for a student's routes: faculty get through if they
+// have access to any class the student is enrolled in.
+func (app *application) requireStudentAccess(next http.Handler) http.Handler {
+	return app.requireAccess(next, "id", app.models.ClassFaculty.IsAssignedToStudent, app.models.Coverage.GetActiveForStudent)
+}
+
+// requireAccess lets admins through, and other authenticated faculty if assigned reports them
+// assigned to the class or student in the URL parameter, or if covered finds a coverage grant
+// for it, in which case the request is audited.
+func (app *application) requireAccess(
+	next http.Handler,
+	param string,
+	assigned func(facultyID, id int64) (bool, error),
+	covered func(facultyID, id int64, at time.Time) (*data.CoverageGrant, error),
+) http.Handler {
+	return app.requireAuthenticatedFaculty(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		faculty := app.contextGetFaculty(r)
+
+		if faculty.IsAdmin {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+		if err != nil || id < 1 {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		ok, err := assigned(faculty.FacultyID, id)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		grant, err := covered(faculty.FacultyID, id, time.Now())
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notPermittedResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		audit := &data.CoverageAudit{
+			GrantID:   grant.GrantID,
+			FacultyID: &faculty.FacultyID,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Status:    ww.Status(),
+		}
+
+		err = app.models.Coverage.InsertAudit(audit)
+		if err != nil {
+			app.logError(r, err)
+		}
+	}))
+}
+
+// requireKioskDevice only lets a request through if it carries the bearer token of a registered,
+// unrevoked kiosk device in its Authorization header.
+func (app *application) requireKioskDevice(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || token == "" {
+			app.invalidDeviceTokenResponse(w, r)
+			return
+		}
+
+		device, err := app.models.KioskDevices.GetByToken(token)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.invalidDeviceTokenResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		r = app.contextSetKioskDevice(r, device)
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func (app *application) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	faculty := app.contextGetFaculty(r)
+
+	unreadOnly := app.readString(r.URL.Query(), "unread", "") == "true"
+
+	notifications, err := app.models.Notifications.GetAllByFacultyID(faculty.FacultyID, unreadOnly)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"notifications": notifications}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	faculty := app.contextGetFaculty(r)
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Notifications.MarkRead(id, faculty.FacultyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "notification marked as read"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
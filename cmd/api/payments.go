@@ -0,0 +1,323 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/billing"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// createPaymentIntentHandler starts a card payment of what is left to pay of an open invoice with
+// the payment provider. The client secret in the response is what the payer confirms their card
+// with.
+func (app *application) createPaymentIntentHandler(w http.ResponseWriter, r *http.Request) {
+	if app.payments == nil {
+		app.paymentsDisabledResponse(w, r)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	invoice, err := app.models.Invoices.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if invoice.Status != "open" {
+		app.errorResponse(w, r, http.StatusConflict, "only open invoices can be paid by card")
+		return
+	}
+
+	unpaid, err := app.models.Invoices.GetUnpaidCents(invoice)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if unpaid <= 0 {
+		app.errorResponse(w, r, http.StatusConflict, "the invoice is already covered by the family's payments and credit")
+		return
+	}
+
+	providerIntent, err := app.payments.CreateIntent(unpaid, fmt.Sprintf("Invoice %d", invoice.InvoiceID))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	intent := &data.PaymentIntent{
+		Provider:    app.payments.Name(),
+		ProviderRef: providerIntent.Ref,
+		InvoiceID:   invoice.InvoiceID,
+		FamilyID:    invoice.GuardianID,
+		AmountCents: providerIntent.AmountCents,
+		Status:      providerIntent.Status,
+		CreatedBy:   &app.contextGetFaculty(r).FacultyID,
+	}
+
+	err = app.models.PaymentIntents.Insert(intent)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{"payment_intent": intent, "client_secret": providerIntent.ClientSecret}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// capturePaymentIntentHandler takes a card payment the payer has confirmed and records it
+// against the family's account, unless the provider's webhook has already done so.
+func (app *application) capturePaymentIntentHandler(w http.ResponseWriter, r *http.Request) {
+	if app.payments == nil {
+		app.paymentsDisabledResponse(w, r)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	intent, err := app.models.PaymentIntents.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if intent.Provider != app.payments.Name() {
+		app.errorResponse(w, r, http.StatusConflict, "the payment intent was made with another payment provider")
+		return
+	}
+
+	_, err = app.payments.Capture(intent.ProviderRef)
+	if err != nil {
+		switch {
+		case errors.Is(err, billing.ErrUnknownIntent), errors.Is(err, billing.ErrIntentNotCapturable):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	payment, err := app.recordCardPayment(intent, &app.contextGetFaculty(r).FacultyID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{"payment_intent": intent}
+	if payment != nil {
+		env["payment"] = payment
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// recordCardPayment records the succeeded intent as a payment, which settles the family's
+// invoices. The payment is nil if it had already been recorded.
+func (app *application) recordCardPayment(intent *data.PaymentIntent, recordedBy *int64) (*data.Payment, error) {
+	if intent.Status != billing.IntentSucceeded {
+		err := app.models.PaymentIntents.SetStatus(intent, billing.IntentSucceeded)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payment := &data.Payment{
+		FamilyID:    intent.FamilyID,
+		AmountCents: intent.AmountCents,
+		Method:      "card",
+		Reference:   fmt.Sprintf("Invoice %d", intent.InvoiceID),
+		ReceivedOn:  data.Date(time.Now()),
+		ProviderRef: &intent.ProviderRef,
+		RecordedBy:  recordedBy,
+	}
+
+	err := app.models.Payments.Insert(payment)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicatePayment):
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	app.logger.Info("card payment recorded", "family_id", payment.FamilyID, "payment_id", payment.PaymentID, "provider_ref", intent.ProviderRef)
+
+	return payment, nil
+}
+
+// refundCardPaymentHandler pays credit on the family's account back to the card a payment was
+// made with.
+func (app *application) refundCardPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	if app.payments == nil {
+		app.paymentsDisabledResponse(w, r)
+		return
+	}
+
+	family, ok := app.readFamily(w, r)
+	if !ok {
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(chi.URLParam(r, "paymentID"), 10, 64)
+	if err != nil || paymentID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	payment, err := app.models.Payments.Get(family.FamilyID, paymentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if payment.ProviderRef == nil {
+		app.errorResponse(w, r, http.StatusConflict, "only card payments can be refunded to the card")
+		return
+	}
+
+	var input struct {
+		AmountCents int64  `json:"amount_cents"`
+		Reason      string `json:"reason"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	adjustment := &data.AccountAdjustment{
+		FamilyID:    family.FamilyID,
+		Kind:        "refund",
+		AmountCents: input.AmountCents,
+		Reason:      strings.TrimSpace(input.Reason),
+		RecordedBy:  &app.contextGetFaculty(r).FacultyID,
+	}
+
+	errs := map[string]string{}
+	if adjustment.AmountCents <= 0 {
+		errs["amount_cents"] = "must be more than zero"
+	} else if adjustment.AmountCents > payment.AmountCents {
+		errs["amount_cents"] = "must not be more than the payment"
+	}
+	if adjustment.Reason == "" {
+		errs["reason"] = "must be provided"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	var refund *billing.ProviderRefund
+	err = app.models.Adjustments.InsertPaidOut(adjustment, func() (err error) {
+		refund, err = app.payments.Refund(*payment.ProviderRef, adjustment.AmountCents)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRefundExceedsCredit), errors.Is(err, billing.ErrRefundExceedsCharge), errors.Is(err, billing.ErrUnknownIntent):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"refund": adjustment, "provider_refund": refund}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// paymentWebhookHandler takes the payment provider's notifications. Events for intents the
+// server doesn't know are acknowledged and ignored, so the provider stops sending them.
+func (app *application) paymentWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if app.payments == nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// 64KB request body limit
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 65_536))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	event, err := app.payments.VerifyWebhook(body, r.Header.Get("Payment-Signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, billing.ErrInvalidSignature):
+			app.invalidWebhookSignatureResponse(w, r)
+		default:
+			app.badRequestResponse(w, r, err)
+		}
+		return
+	}
+
+	intent, err := app.models.PaymentIntents.GetByProviderRef(app.payments.Name(), event.IntentRef)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	message := "event ignored"
+	if intent != nil {
+		switch {
+		case event.Type == billing.EventPaymentSucceeded:
+			_, err = app.recordCardPayment(intent, nil)
+			message = "event processed"
+		case event.Type == billing.EventPaymentFailed && intent.Status == billing.IntentRequiresCapture:
+			err = app.models.PaymentIntents.SetStatus(intent, billing.IntentFailed)
+			message = "event processed"
+		}
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": message}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/billing"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/events"
+)
+
+// openTestDB connects to the database in DAYCARE_TEST_DB_DSN, which must have every migration
+// applied. Tests that need a database are skipped when it isn't set.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("DAYCARE_TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("DAYCARE_TEST_DB_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// TestRecordCardPaymentOnce captures an intent while the provider's webhook for it arrives, and
+// checks that the payment is recorded once whichever gets there first.
+func TestRecordCardPaymentOnce(t *testing.T) {
+	db := openTestDB(t)
+	provider := billing.NewFakeProvider("whsec_test")
+
+	hub := events.NewHub(1)
+	app := &application{
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		models:   data.NewModels(db, hub),
+		events:   hub,
+		payments: provider,
+	}
+
+	student := &data.Student{FirstName: "Ada", LastName: "Lovelace", Gender: "female", DateOfBirth: data.Date(time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC))}
+	guardian := &data.Guardian{FirstName: "Anne", LastName: "Lovelace", Gender: "female", Relationship: "mother", Contact: "555-0100"}
+
+	err := app.models.Students.InsertWithGuardian(student, guardian)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var familyID, invoiceID int64
+	err = db.QueryRow(`SELECT family_id FROM students WHERE student_id = $1`, student.StudentID).Scan(&familyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM payments WHERE guardian_id = $1`, familyID)
+		db.Exec(`DELETE FROM payment_intents WHERE guardian_id = $1`, familyID)
+		db.Exec(`DELETE FROM invoices WHERE guardian_id = $1`, familyID)
+		db.Exec(`DELETE FROM students WHERE student_id = $1`, student.StudentID)
+	})
+
+	err = db.QueryRow(`
+		INSERT INTO invoices (guardian_id, period_start, period_end, total_cents, due_on)
+		VALUES ($1, '2026-10-01', '2026-10-31', 62000, '2026-10-15')
+		RETURNING invoice_id`, familyID).Scan(&invoiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	providerIntent, err := provider.CreateIntent(62000, "Invoice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intent := &data.PaymentIntent{
+		Provider:    provider.Name(),
+		ProviderRef: providerIntent.Ref,
+		InvoiceID:   invoiceID,
+		FamilyID:    familyID,
+		AmountCents: providerIntent.AmountCents,
+		Status:      providerIntent.Status,
+	}
+
+	err = app.models.PaymentIntents.Insert(intent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(billing.WebhookEvent{ID: "evt_1", Type: billing.EventPaymentSucceeded, IntentRef: intent.ProviderRef, AmountCents: 62000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg         sync.WaitGroup
+		webhook    = httptest.NewRecorder()
+		captureErr error
+	)
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/webhooks/payments", bytes.NewReader(payload))
+		r.Header.Set("Payment-Signature", provider.Sign(payload, time.Now()))
+		app.paymentWebhookHandler(webhook, r)
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		captured := *intent
+		_, captureErr = provider.Capture(captured.ProviderRef)
+		if captureErr == nil {
+			_, captureErr = app.recordCardPayment(&captured, nil)
+		}
+	}()
+
+	wg.Wait()
+
+	if captureErr != nil {
+		t.Fatalf("capture: %v", captureErr)
+	}
+	if webhook.Code != http.StatusOK {
+		t.Fatalf("webhook: got status %d: %s", webhook.Code, webhook.Body)
+	}
+
+	// The webhook arriving again later is recorded as nothing new.
+	again, err := app.recordCardPayment(intent, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != nil {
+		t.Errorf("a repeated webhook recorded payment %d", again.PaymentID)
+	}
+
+	var payments int
+	var paid int64
+	err = db.QueryRow(`SELECT count(*), COALESCE(sum(amount_cents), 0) FROM payments WHERE provider_ref = $1`, intent.ProviderRef).Scan(&payments, &paid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payments != 1 || paid != 62000 {
+		t.Errorf("recorded %d payment(s) of %d in total, want one of 62000", payments, paid)
+	}
+
+	var status string
+	err = db.QueryRow(`SELECT status FROM invoices WHERE invoice_id = $1`, invoiceID).Scan(&status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != "paid" {
+		t.Errorf("invoice is %s, want paid", status)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func (app *application) attendanceReportHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	classID := int64(app.readInt(qs, "class_id", 0))
+	studentID := int64(app.readInt(qs, "student_id", 0))
+	format := app.readString(qs, "format", "json")
+
+	from, err := time.Parse("2006-01-02", qs.Get("from"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	to, err := time.Parse("2006-01-02", qs.Get("to"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	errs := map[string]string{}
+	if to.Before(from) {
+		errs["to"] = "must not be before from"
+	}
+	if format != "json" && format != "csv" {
+		errs["format"] = "must be json or csv"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	if format == "csv" {
+		app.writeAttendanceReportCSV(w, r, classID, studentID, from, to)
+		return
+	}
+
+	summaries := []*data.AttendanceSummary{}
+	err = app.models.StudentAttendance.GetAttendanceSummaries(classID, studentID, from, to, func(summary *data.AttendanceSummary) error {
+		summaries = append(summaries, summary)
+		return nil
+	})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{
+		"from":       data.Date(from),
+		"to":         data.Date(to),
+		"attendance": summaries,
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// writeAttendanceReportCSV streams the attendance summaries as CSV rows while they are read
+// from the database. The header row is only written once the query has succeeded, so a
+// failing query can still be reported with a proper error response.
+func (app *application) writeAttendanceReportCSV(w http.ResponseWriter, r *http.Request, classID, studentID int64, from, to time.Time) {
+	cw := csv.NewWriter(w)
+	started := false
+
+	start := func() error {
+		started = true
+
+		filename := fmt.Sprintf("attendance_%s_%s.csv", from.Format("2006-01-02"), to.Format("2006-01-02"))
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+
+		return cw.Write([]string{"class_id", "class_name", "student_id", "first_name", "last_name", "days_present", "days_absent", "days_excused", "attendance_rate"})
+	}
+
+	err := app.models.StudentAttendance.GetAttendanceSummaries(classID, studentID, from, to, func(summary *data.AttendanceSummary) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		return cw.Write([]string{
+			strconv.FormatInt(summary.ClassID, 10),
+			summary.ClassName,
+			strconv.FormatInt(summary.StudentID, 10),
+			summary.FirstName,
+			summary.LastName,
+			strconv.Itoa(summary.DaysPresent),
+			strconv.Itoa(summary.DaysAbsent),
+			strconv.Itoa(summary.DaysExcused),
+			strconv.FormatFloat(summary.AttendanceRate, 'f', 4, 64),
+		})
+	})
+	if err != nil && !started {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if err == nil && !started {
+		err = start()
+	}
+
+	cw.Flush()
+	if err == nil {
+		err = cw.Error()
+	}
+	if err != nil {
+		app.logError(r, err)
+	}
+}
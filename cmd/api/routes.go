@@ -34,6 +34,20 @@ func (app *application) routes() http.Handler {
 	router.Route("/students", app.loadStudentRoutes)
 	router.Route("/faculty", app.loadFacultyRoutes)
 	router.Route("/classes", app.loadClassRoutes)
+	router.Route("/reports", app.loadReportRoutes)
+	router.Route("/alerts", app.loadAlertRoutes)
+	router.Route("/guardians", app.loadGuardianRoutes)
+	router.Route("/kiosk", app.loadKioskRoutes)
+	router.Route("/timeclock", app.loadTimeClockRoutes)
+	router.Route("/shifts", app.loadShiftRoutes)
+	router.Route("/coverage", app.loadCoverageRoutes)
+	router.Route("/billing", app.loadBillingRoutes)
+	router.Route("/families", app.loadFamilyRoutes)
+	router.Route("/subsidies", app.loadSubsidyRoutes)
+	router.Route("/immunizations", app.loadImmunizationRoutes)
+	router.Route("/incidents", app.loadIncidentRoutes)
+	router.Route("/menus", app.loadMenuRoutes)
+	router.Post("/webhooks/payments", app.paymentWebhookHandler)
 	router.Post("/login", app.loginFacultyHandler)
 	router.Post("/logout", app.logoutFacultyHandler)
 	return router
@@ -44,6 +58,38 @@ func (app *application) loadStudentRoutes(router chi.Router) {
 	router.Get("/", app.listStudentsHandler)
 	router.Get("/{id}/guardian", app.showStudentGuardiansHandler)
 	router.Patch("/{id}/guardian", app.updateStudentAndGuardianHandler)
+	router.With(app.requireAdmin).Get("/{id}/plans", app.listStudentPlansHandler)
+	router.With(app.requireAdmin).Post("/{id}/plans", app.createStudentPlanHandler)
+	router.With(app.requireAdmin).Patch("/{id}/plans/{studentPlanID}", app.updateStudentPlanHandler)
+	router.With(app.requireAdmin).Post("/{id}/guardians/{guardianID}", app.linkStudentGuardianHandler)
+	router.With(app.requireAdmin).Patch("/{id}/family", app.updateStudentFamilyHandler)
+	router.With(app.requireAdmin).Get("/{id}/subsidies", app.listSubsidyAuthorizationsHandler)
+	router.With(app.requireAdmin).Post("/{id}/subsidies", app.createSubsidyAuthorizationHandler)
+	router.With(app.requireAdmin).Patch("/{id}/subsidies/{authorizationID}", app.updateSubsidyAuthorizationHandler)
+	router.With(app.requireStudentAccess).Get("/{id}/medical", app.showMedicalProfileHandler)
+	router.With(app.requireStudentAccess).Patch("/{id}/medical", app.updateMedicalProfileHandler)
+	router.With(app.requireStudentAccess).Post("/{id}/medical/allergies", app.createAllergyHandler)
+	router.With(app.requireStudentAccess).Patch("/{id}/medical/allergies/{allergyID}", app.updateAllergyHandler)
+	router.With(app.requireStudentAccess).Delete("/{id}/medical/allergies/{allergyID}", app.deleteAllergyHandler)
+	router.With(app.requireStudentAccess).Get("/{id}/immunizations", app.showImmunizationsHandler)
+	router.With(app.requireStudentAccess).Post("/{id}/immunizations", app.createImmunizationHandler)
+	router.With(app.requireStudentAccess).Delete("/{id}/immunizations/{immunizationID}", app.deleteImmunizationHandler)
+	router.With(app.requireAdmin).Post("/{id}/immunizations/exemptions", app.createExemptionHandler)
+	router.With(app.requireAdmin).Delete("/{id}/immunizations/exemptions/{exemptionID}", app.deleteExemptionHandler)
+	router.With(app.requireStudentAccess).Get("/{id}/medications", app.listMedicationsHandler)
+	router.With(app.requireStudentAccess).Post("/{id}/medications", app.createMedicationHandler)
+	router.With(app.requireStudentAccess).Post("/{id}/medications/{authorizationID}/revoke", app.revokeMedicationHandler)
+	router.With(app.requireStudentAccess).Get("/{id}/medications/{authorizationID}/doses", app.listMedicationDosesHandler)
+	router.With(app.requireStudentAccess).Post("/{id}/medications/{authorizationID}/doses", app.createMedicationDoseHandler)
+	router.With(app.requireStudentAccess).Get("/{id}/daily-log", app.showDailyLogHandler)
+	router.With(app.requireStudentAccess).Post("/{id}/daily-log", app.createDailyLogEntryHandler)
+	router.With(app.requireStudentAccess).Patch("/{id}/daily-log/{entryID}", app.updateDailyLogEntryHandler)
+	router.With(app.requireStudentAccess).Delete("/{id}/daily-log/{entryID}", app.deleteDailyLogEntryHandler)
+	router.With(app.requireStudentAccess).Get("/{id}/sleep", app.listStudentSleepHandler)
+	router.With(app.requireStudentAccess).Get("/{id}/emergency-contacts", app.listEmergencyContactsHandler)
+	router.With(app.requireStudentAccess).Post("/{id}/emergency-contacts", app.createEmergencyContactHandler)
+	router.With(app.requireStudentAccess).Patch("/{id}/emergency-contacts/{contactID}", app.updateEmergencyContactHandler)
+	router.With(app.requireStudentAccess).Delete("/{id}/emergency-contacts/{contactID}", app.deleteEmergencyContactHandler)
 	router.Get("/{id}", app.showStudentHandler)
 	router.Patch("/{id}", app.updateStudentHandler)
 	router.Delete("/{id}", app.deleteStudentHandler)
@@ -51,10 +97,25 @@ func (app *application) loadStudentRoutes(router chi.Router) {
 
 func (app *application) loadFacultyRoutes(router chi.Router) {
 	router.Post("/", app.createFacultyHandler)
+	router.With(app.requireAuthenticatedFaculty).Get("/", app.listFacultyHandler)
 	router.Get("/{id}", app.showFacultyHandler)
-	router.Patch("/profile", app.updateFacultyHandler)
-	router.Get("/profile", app.getUserWithTokenHandler)
-	// router.Delete("/{id}", app.deleteFacultyHandler)
+	router.With(app.requireAuthenticatedFaculty).Patch("/profile", app.updateFacultyHandler)
+	router.With(app.requireAuthenticatedFaculty).Get("/profile", app.getUserWithTokenHandler)
+	router.With(app.requireAuthenticatedFaculty).Get("/profile/notifications", app.listNotificationsHandler)
+	router.With(app.requireAuthenticatedFaculty).Post("/profile/notifications/{id}/read", app.readNotificationHandler)
+	router.With(app.requireAdmin).Delete("/{id}", app.deleteFacultyHandler)
+	router.With(app.requireAdmin).Post("/{id}/deactivate", app.deactivateFacultyHandler)
+	router.With(app.requireAdmin).Post("/{id}/reactivate", app.reactivateFacultyHandler)
+	router.With(app.requireAdmin).Patch("/{id}/admin", app.updateFacultyAdminHandler)
+
+	router.With(app.requireAuthenticatedFaculty).Get("/{id}/schedule", app.showFacultyScheduleHandler)
+	router.With(app.requireAuthenticatedFaculty).Post("/{id}/time-off", app.createTimeOffHandler)
+	router.With(app.requireAuthenticatedFaculty).Delete("/{id}/time-off/{timeOffID}", app.deleteTimeOffHandler)
+
+	router.With(app.requireAuthenticatedFaculty).Get("/{id}/credentials", app.listCredentialsHandler)
+	router.With(app.requireAdmin).Post("/{id}/credentials", app.createCredentialHandler)
+	router.With(app.requireAdmin).Patch("/{id}/credentials/{credentialID}", app.updateCredentialHandler)
+	router.With(app.requireAdmin).Delete("/{id}/credentials/{credentialID}", app.deleteCredentialHandler)
 	
 	// get number of classes
 	router.Get("/{id}/classes", app.showNumberofClassesByFacultyHandler)
@@ -69,10 +130,194 @@ func (app *application) loadClassRoutes(router chi.Router) {
 	router.Patch("/{id}", app.updateClassHandler)
 	router.Delete("/{id}", app.deleteClassHandler)
 
-	router.Get("/{classID}/students", app.listClassStudentsHandler)
+	router.With(app.requireClassAccess).Get("/{classID}/students", app.listClassStudentsHandler)
 	router.Post("/{classID}/students", app.createClassStudentHandler)
 	router.Delete("/{classID}/students/{studentID}", app.deleteClassStudentHandler)
 
-	router.Post("/{classID}/attendance/{studentID}", app.addStudentAttendance)
-	router.Get("/{classID}/attendance", app.getClassAttendance)
+	router.With(app.requireClassAccess).Post("/{classID}/attendance/{studentID}", app.addStudentAttendance)
+	router.With(app.requireClassAccess).Get("/{classID}/attendance", app.getClassAttendance)
+
+	router.With(app.requireClassAccess).Get("/{classID}/events", app.classEventsHandler)
+
+	router.With(app.requireAuthenticatedFaculty).Get("/{classID}/faculty", app.listClassFacultyHandler)
+	router.With(app.requireAdmin).Post("/{classID}/faculty", app.createClassFacultyHandler)
+	router.With(app.requireAdmin).Delete("/{classID}/faculty/{assignmentID}", app.deleteClassFacultyHandler)
+
+	router.Get("/{classID}/sessions", app.listClassSessionsHandler)
+	router.With(app.requireAdmin).Post("/{classID}/sessions", app.createClassSessionHandler)
+	router.With(app.requireAdmin).Delete("/{classID}/sessions/{sessionID}", app.deleteClassSessionHandler)
+	router.With(app.requireAuthenticatedFaculty).Get("/{classID}/schedule", app.showClassScheduleHandler)
+	router.With(app.requireAuthenticatedFaculty).Get("/{classID}/staffing", app.showClassStaffingHandler)
+	router.With(app.requireClassAccess).Get("/{classID}/medications/due", app.listDueMedicationsHandler)
+	router.With(app.requireClassAccess).Get("/{classID}/daily-log", app.showClassDailyLogHandler)
+	router.With(app.requireClassAccess).Post("/{classID}/daily-log", app.createClassDailyLogHandler)
+	router.With(app.requireClassAccess).Get("/{classID}/menu/conflicts", app.menuConflictsHandler)
+	router.With(app.requireClassAccess).Get("/{classID}/sleep", app.listSleepingHandler)
+	router.With(app.requireClassAccess).Post("/{classID}/sleep", app.startSleepHandler)
+	router.With(app.requireClassAccess).Get("/{classID}/sleep/{sessionID}", app.showSleepHandler)
+	router.With(app.requireClassAccess).Post("/{classID}/sleep/{sessionID}/checks", app.createSleepCheckHandler)
+	router.With(app.requireClassAccess).Post("/{classID}/sleep/{sessionID}/end", app.endSleepHandler)
+	router.With(app.requireClassAccess).Get("/{classID}/emergency-roster", app.emergencyRosterHandler)
+}
+
+func (app *application) loadReportRoutes(router chi.Router) {
+	router.With(app.requireAdmin).Get("/attendance", app.attendanceReportHandler)
+	router.With(app.requireAdmin).Get("/compliance", app.complianceReportHandler)
+	router.With(app.requireAdmin).Get("/late-pickups", app.lateFeeReportHandler)
+	router.With(app.requireAdmin).Get("/immunizations", app.immunizationReportHandler)
+	router.With(app.requireAdmin).Get("/incidents", app.incidentReportHandler)
+}
+
+func (app *application) loadAlertRoutes(router chi.Router) {
+	router.Use(app.requireAdmin)
+
+	router.Get("/absences", app.listAbsenceAlertsHandler)
+	router.Post("/absences/{id}/resolve", app.resolveAbsenceAlertHandler)
+}
+
+func (app *application) loadGuardianRoutes(router chi.Router) {
+	router.Use(app.requireAuthenticatedFaculty)
+
+	router.Post("/{id}/pin", app.setGuardianPINHandler)
+	router.Get("/{id}/qr", app.showGuardianQRHandler)
+	router.With(app.requireAdmin).Patch("/{id}/faculty", app.linkGuardianFacultyHandler)
+}
+
+func (app *application) loadKioskRoutes(router chi.Router) {
+	// managed by admins
+	router.Group(func(router chi.Router) {
+		router.Use(app.requireAdmin)
+
+		router.Post("/devices", app.createKioskDeviceHandler)
+		router.Get("/devices", app.listKioskDevicesHandler)
+		router.Delete("/devices/{id}", app.revokeKioskDeviceHandler)
+	})
+
+	// used by the kiosk devices themselves
+	router.Group(func(router chi.Router) {
+		router.Use(app.requireKioskDevice)
+
+		router.Post("/lookup", app.kioskLookupHandler)
+		router.Post("/check-in", app.kioskCheckInHandler)
+		router.Post("/check-out", app.kioskCheckOutHandler)
+		router.Post("/medications/pending", app.kioskPendingMedicationsHandler)
+		router.Post("/medications/{id}/sign", app.kioskSignMedicationHandler)
+		router.Post("/incidents/pending", app.kioskPendingIncidentsHandler)
+		router.Post("/incidents/{id}/acknowledge", app.kioskAcknowledgeIncidentHandler)
+		router.Post("/daily-summary", app.kioskDailySummaryHandler)
+	})
+}
+
+func (app *application) loadTimeClockRoutes(router chi.Router) {
+	router.Use(app.requireAuthenticatedFaculty)
+
+	router.Get("/", app.showTimeClockStatusHandler)
+	router.Post("/clock-in", app.clockInHandler)
+	router.Post("/clock-out", app.clockOutHandler)
+	router.Post("/breaks/start", app.startBreakHandler)
+	router.Post("/breaks/end", app.endBreakHandler)
+	router.Get("/timesheet", app.showTimesheetHandler)
+
+	router.With(app.requireAdmin).Get("/timesheets", app.listTimesheetsHandler)
+	router.With(app.requireAdmin).Patch("/entries/{id}", app.updateTimeEntryHandler)
+	router.With(app.requireAdmin).Get("/entries/{id}/edits", app.listTimeEntryEditsHandler)
+}
+
+func (app *application) loadShiftRoutes(router chi.Router) {
+	router.Use(app.requireAuthenticatedFaculty)
+
+	router.Get("/{id}", app.showShiftHandler)
+	router.With(app.requireAdmin).Post("/", app.createShiftHandler)
+	router.With(app.requireAdmin).Patch("/{id}", app.updateShiftHandler)
+	router.With(app.requireAdmin).Delete("/{id}", app.deleteShiftHandler)
+}
+
+func (app *application) loadCoverageRoutes(router chi.Router) {
+	router.Use(app.requireAuthenticatedFaculty)
+
+	router.Post("/", app.createCoverageRequestHandler)
+	router.Get("/", app.listCoverageHandler)
+	router.Get("/{id}", app.showCoverageHandler)
+	router.With(app.requireAdmin).Post("/{id}/approve", app.approveCoverageHandler)
+	router.With(app.requireAdmin).Post("/{id}/reject", app.rejectCoverageHandler)
+	router.With(app.requireAdmin).Get("/{id}/audit", app.listCoverageAuditHandler)
+}
+
+func (app *application) loadBillingRoutes(router chi.Router) {
+	router.Use(app.requireAdmin)
+
+	router.Get("/plans", app.listTuitionPlansHandler)
+	router.Post("/plans", app.createTuitionPlanHandler)
+	router.Get("/plans/{id}", app.showTuitionPlanHandler)
+	router.Patch("/plans/{id}", app.updateTuitionPlanHandler)
+
+	router.Get("/discounts", app.listDiscountRulesHandler)
+	router.Post("/discounts", app.createDiscountRuleHandler)
+	router.Get("/discounts/{id}", app.showDiscountRuleHandler)
+	router.Patch("/discounts/{id}", app.updateDiscountRuleHandler)
+
+	router.Get("/invoices", app.listInvoicesHandler)
+	router.Get("/invoices/{id}", app.showInvoiceHandler)
+	router.Post("/invoices/{id}/void", app.voidInvoiceHandler)
+	router.Post("/invoices/{id}/payment-intent", app.createPaymentIntentHandler)
+	router.Post("/payment-intents/{id}/capture", app.capturePaymentIntentHandler)
+
+	router.Get("/late-fee-rules", app.listLateFeeRulesHandler)
+	router.Post("/late-fee-rules", app.createLateFeeRuleHandler)
+	router.Delete("/late-fee-rules/{id}", app.deleteLateFeeRuleHandler)
+	router.Post("/late-fees/{id}/waive", app.waiveLateFeeHandler)
+}
+
+func (app *application) loadFamilyRoutes(router chi.Router) {
+	router.Use(app.requireAdmin)
+
+	router.Get("/{id}", app.showFamilyHandler)
+	router.Get("/{id}/statement", app.showFamilyStatementHandler)
+	router.Get("/{id}/tax-statement", app.showTaxStatementHandler)
+	router.Get("/{id}/invoices/{invoiceID}/pdf", app.familyInvoicePDFHandler)
+	router.Post("/{id}/payments", app.createPaymentHandler)
+	router.Get("/{id}/payments/{paymentID}/receipt", app.paymentReceiptHandler)
+	router.Post("/{id}/payments/{paymentID}/refund", app.refundCardPaymentHandler)
+	router.Post("/{id}/credits", app.createCreditHandler)
+	router.Post("/{id}/refunds", app.createRefundHandler)
+}
+
+func (app *application) loadImmunizationRoutes(router chi.Router) {
+	router.With(app.requireAuthenticatedFaculty).Get("/schedule", app.listDoseScheduleHandler)
+	router.With(app.requireAdmin).Post("/schedule", app.createScheduledDoseHandler)
+	router.With(app.requireAdmin).Patch("/schedule/{id}", app.updateScheduledDoseHandler)
+	router.With(app.requireAdmin).Delete("/schedule/{id}", app.deleteScheduledDoseHandler)
+}
+
+func (app *application) loadIncidentRoutes(router chi.Router) {
+	router.Use(app.requireAuthenticatedFaculty)
+
+	router.Post("/", app.createIncidentHandler)
+	router.Get("/", app.listIncidentsHandler)
+	router.Get("/{id}", app.showIncidentHandler)
+	router.Patch("/{id}", app.updateIncidentHandler)
+	router.Delete("/{id}", app.deleteIncidentHandler)
+	router.Post("/{id}/submit", app.submitIncidentHandler)
+}
+
+func (app *application) loadMenuRoutes(router chi.Router) {
+	router.Use(app.requireAuthenticatedFaculty)
+
+	router.Get("/", app.listMenuHandler)
+	router.Get("/items/{id}", app.showMenuItemHandler)
+	router.With(app.requireAdmin).Post("/items", app.createMenuItemHandler)
+	router.With(app.requireAdmin).Patch("/items/{id}", app.updateMenuItemHandler)
+	router.With(app.requireAdmin).Delete("/items/{id}", app.deleteMenuItemHandler)
+	router.Post("/items/{id}/substitutions", app.createSubstitutionHandler)
+	router.Delete("/items/{id}/substitutions/{substitutionID}", app.deleteSubstitutionHandler)
+}
+
+func (app *application) loadSubsidyRoutes(router chi.Router) {
+	router.Use(app.requireAdmin)
+
+	router.Get("/agencies", app.listSubsidyAgenciesHandler)
+	router.Post("/agencies", app.createSubsidyAgencyHandler)
+	router.Patch("/agencies/{id}", app.updateSubsidyAgencyHandler)
+
+	router.Get("/claims", app.subsidyClaimHandler)
 }
@@ -20,6 +20,10 @@ func (app *application) serve() error {
 		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
 	}
 
+	// Event streams never finish on their own, so end them rather than wait out the shutdown
+	// timeout. Clients reconnect to another instance and catch up from Last-Event-ID.
+	srv.RegisterOnShutdown(app.events.Close)
+
 	shutdownError := make(chan error)
 
 	go func() {
@@ -0,0 +1,590 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func (app *application) shiftConflictResponse(w http.ResponseWriter, r *http.Request, conflicts []*data.ShiftConflict) {
+	env := envelope{
+		"error":     "the shift overlaps another shift or time off of the faculty member",
+		"conflicts": conflicts,
+	}
+
+	err := app.writeEnvelopedJSON(w, http.StatusConflict, env, nil)
+	if err != nil {
+		app.logError(r, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// validateShift checks the shift's times and that its faculty member and class exist.
+func (app *application) validateShift(shift *data.Shift) (map[string]string, error) {
+	errs := map[string]string{}
+
+	if !shift.EndsAt.After(shift.StartsAt) {
+		errs["ends_at"] = "must be after starts_at"
+	}
+
+	faculty, err := app.models.Faculty.Get(shift.FacultyID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		errs["faculty_id"] = "must be an existing faculty member"
+	case err != nil:
+		return nil, err
+	case !faculty.Active:
+		errs["faculty_id"] = "must be an active faculty member"
+	}
+
+	_, err = app.models.Classes.Get(shift.ClassID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		errs["class_id"] = "must be an existing class"
+	case err != nil:
+		return nil, err
+	}
+
+	return errs, nil
+}
+
+func (app *application) createShiftHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		FacultyID int64     `json:"faculty_id"`
+		ClassID   int64     `json:"class_id"`
+		StartsAt  time.Time `json:"starts_at"`
+		EndsAt    time.Time `json:"ends_at"`
+		Notes     string    `json:"notes"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	shift := &data.Shift{
+		FacultyID: input.FacultyID,
+		ClassID:   input.ClassID,
+		StartsAt:  input.StartsAt,
+		EndsAt:    input.EndsAt,
+		Notes:     input.Notes,
+	}
+
+	errs, err := app.validateShift(shift)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	conflicts, err := app.models.Shifts.Insert(shift)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(conflicts) > 0 {
+		app.shiftConflictResponse(w, r, conflicts)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/shifts/%d", shift.ShiftID))
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"shift": shift}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showShiftHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	shift, err := app.models.Shifts.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"shift": shift}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateShiftHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	shift, err := app.models.Shifts.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		FacultyID *int64     `json:"faculty_id"`
+		ClassID   *int64     `json:"class_id"`
+		StartsAt  *time.Time `json:"starts_at"`
+		EndsAt    *time.Time `json:"ends_at"`
+		Notes     *string    `json:"notes"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.FacultyID != nil {
+		shift.FacultyID = *input.FacultyID
+	}
+
+	if input.ClassID != nil {
+		shift.ClassID = *input.ClassID
+	}
+
+	if input.StartsAt != nil {
+		shift.StartsAt = *input.StartsAt
+	}
+
+	if input.EndsAt != nil {
+		shift.EndsAt = *input.EndsAt
+	}
+
+	if input.Notes != nil {
+		shift.Notes = *input.Notes
+	}
+
+	errs, err := app.validateShift(shift)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	conflicts, err := app.models.Shifts.Update(shift)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if len(conflicts) > 0 {
+		app.shiftConflictResponse(w, r, conflicts)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"shift": shift}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteShiftHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Shifts.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "shift deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createTimeOffHandler books time off for a faculty member, which they can do for themselves
+// and admins can do for anyone. Time off during shifts the faculty member is already scheduled
+// for is refused with the shifts, which have to be reassigned or deleted first.
+func (app *application) createTimeOffHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	faculty := app.contextGetFaculty(r)
+	if faculty.FacultyID != id && !faculty.IsAdmin {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	var input struct {
+		StartsAt time.Time `json:"starts_at"`
+		EndsAt   time.Time `json:"ends_at"`
+		Reason   string    `json:"reason"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !input.EndsAt.After(input.StartsAt) {
+		app.failedValidationResponse(w, r, map[string]string{"ends_at": "must be after starts_at"})
+		return
+	}
+
+	timeOff := &data.TimeOff{
+		FacultyID: id,
+		StartsAt:  input.StartsAt,
+		EndsAt:    input.EndsAt,
+		Reason:    input.Reason,
+	}
+
+	conflicts, err := app.models.TimeOff.Insert(timeOff)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(conflicts) > 0 {
+		env := envelope{
+			"error":     "the faculty member is scheduled for shifts during the time off, reassign them first",
+			"conflicts": conflicts,
+		}
+
+		err = app.writeEnvelopedJSON(w, http.StatusConflict, env, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"time_off": timeOff}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteTimeOffHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	timeOffID, err := strconv.ParseInt(chi.URLParam(r, "timeOffID"), 10, 64)
+	if err != nil || timeOffID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	faculty := app.contextGetFaculty(r)
+	if faculty.FacultyID != id && !faculty.IsAdmin {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	err = app.models.TimeOff.Delete(timeOffID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "time off deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showFacultyScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	weekStart, err := app.readWeek(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	shifts, err := app.models.Shifts.GetAllByFacultyID(id, weekStart, weekEnd)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	timeOff, err := app.models.TimeOff.GetAllByFacultyID(id, weekStart, weekEnd)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{
+		"faculty_id": id,
+		"week_start": data.Date(weekStart),
+		"shifts":     shifts,
+		"time_off":   timeOff,
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createClassSessionHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Weekday  int    `json:"weekday"`
+		StartsAt string `json:"starts_at"`
+		EndsAt   string `json:"ends_at"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	errs := map[string]string{}
+	if input.Weekday < 0 || input.Weekday > 6 {
+		errs["weekday"] = "must be between 0 (Sunday) and 6 (Saturday)"
+	}
+	startsAt, err := time.Parse("15:04", input.StartsAt)
+	if err != nil {
+		errs["starts_at"] = "must be a time of day like 08:30"
+	}
+	endsAt, err := time.Parse("15:04", input.EndsAt)
+	if err != nil {
+		errs["ends_at"] = "must be a time of day like 15:00"
+	} else if !endsAt.After(startsAt) {
+		errs["ends_at"] = "must be after starts_at"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	_, err = app.models.Classes.Get(classID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	session := &data.ClassSession{
+		ClassID:  classID,
+		Weekday:  input.Weekday,
+		StartsAt: input.StartsAt,
+		EndsAt:   input.EndsAt,
+	}
+
+	err = app.models.ClassSessions.Insert(session)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/classes/%d/sessions", classID))
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"session": session}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listClassSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	sessions, err := app.models.ClassSessions.GetAllByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"sessions": sessions}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteClassSessionHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(chi.URLParam(r, "sessionID"), 10, 64)
+	if err != nil || sessionID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.ClassSessions.Delete(classID, sessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"message": "class session deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showClassScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	weekStart, err := app.readWeek(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	sessions, err := app.models.ClassSessions.GetAllByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	shifts, err := app.models.Shifts.GetAllByClassID(classID, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{
+		"class_id":   classID,
+		"week_start": data.Date(weekStart),
+		"sessions":   data.Occurrences(sessions, weekStart),
+		"shifts":     shifts,
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showClassStaffingHandler checks that enough staff are scheduled for the class's enrolled
+// students during every one of its sessions in the week.
+func (app *application) showClassStaffingHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	weekStart, err := app.readWeek(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	sessions, err := app.models.ClassSessions.GetAllByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	shifts, err := app.models.Shifts.GetAllByClassID(classID, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	enrolled, err := app.models.ClassStudents.NumberOfStudentsByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	staffing := data.CheckStaffing(data.Occurrences(sessions, weekStart), shifts, enrolled, app.cfg.childrenPerStaff)
+
+	understaffed := 0
+	for _, session := range staffing {
+		if !session.Sufficient {
+			understaffed++
+		}
+	}
+
+	env := envelope{
+		"class_id":           classID,
+		"week_start":         data.Date(weekStart),
+		"children_per_staff": app.cfg.childrenPerStaff,
+		"understaffed":       understaffed,
+		"sessions":           staffing,
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
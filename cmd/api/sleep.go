@@ -0,0 +1,304 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+var (
+	sleepPositions = []string{"back", "side", "stomach"}
+	sleepBreathing = []string{"normal", "irregular", "labored"}
+)
+
+// sleepStatus is a sleeping student's session with when the next check is due.
+type sleepStatus struct {
+	*data.SleepSession
+	CheckDueAt time.Time `json:"check_due_at"`
+	Overdue    bool      `json:"overdue"`
+}
+
+func sleepStatusOf(session *data.SleepSession, every time.Duration, now time.Time) *sleepStatus {
+	due := session.CheckDueAt(every)
+	return &sleepStatus{SleepSession: session, CheckDueAt: due, Overdue: now.After(due)}
+}
+
+// startSleepHandler records a student of the class falling asleep, now or at started_at.
+func (app *application) startSleepHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		StudentID int64      `json:"student_id"`
+		StartedAt *time.Time `json:"started_at"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	students, err := app.models.ClassStudents.GetStudentsByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	session := &data.SleepSession{
+		StudentID: input.StudentID,
+		ClassID:   classID,
+		StartedAt: time.Now().Truncate(time.Second),
+		StartedBy: &app.contextGetFaculty(r).FacultyID,
+	}
+	if input.StartedAt != nil {
+		session.StartedAt = *input.StartedAt
+	}
+
+	errs := map[string]string{}
+	index := slices.IndexFunc(students, func(s *data.Student) bool { return s.StudentID == input.StudentID })
+	if index < 0 {
+		errs["student_id"] = "must be a student in the class"
+	} else {
+		session.FirstName = students[index].FirstName
+		session.LastName = students[index].LastName
+	}
+	if session.StartedAt.After(time.Now()) {
+		errs["started_at"] = "must not be in the future"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Sleep.Start(session)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAlreadyAsleep):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	status := sleepStatusOf(session, app.cfg.sleep.checkInterval, time.Now())
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"sleep": status}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listSleepingHandler lists the students of the class who are asleep with when their next check
+// is due. With overdue=true, only students overdue for a check are listed.
+func (app *application) listSleepingHandler(w http.ResponseWriter, r *http.Request) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	overdueOnly := app.readString(r.URL.Query(), "overdue", "false") == "true"
+
+	sessions, err := app.models.Sleep.GetAsleepByClassID(classID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	sleeping := []*sleepStatus{}
+	for _, session := range sessions {
+		status := sleepStatusOf(session, app.cfg.sleep.checkInterval, now)
+		if overdueOnly && !status.Overdue {
+			continue
+		}
+		sleeping = append(sleeping, status)
+	}
+
+	env := envelope{
+		"check_interval_minutes": int(app.cfg.sleep.checkInterval.Minutes()),
+		"sleeping":               sleeping,
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readSleepSession returns the class's sleep session in the URL, having written a response
+// already if there is no such session.
+func (app *application) readSleepSession(w http.ResponseWriter, r *http.Request) (*data.SleepSession, bool) {
+	classID, err := strconv.ParseInt(chi.URLParam(r, "classID"), 10, 64)
+	if err != nil || classID < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	sessionID, err := strconv.ParseInt(chi.URLParam(r, "sessionID"), 10, 64)
+	if err != nil || sessionID < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	session, err := app.models.Sleep.Get(classID, sessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return session, true
+}
+
+func (app *application) showSleepHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := app.readSleepSession(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	session.Checks, err = app.models.Sleep.GetChecks(session.SessionID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	status := sleepStatusOf(session, app.cfg.sleep.checkInterval, time.Now())
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"sleep": status}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createSleepCheckHandler records a check on a sleeping student, now or at checked_at.
+func (app *application) createSleepCheckHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := app.readSleepSession(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		CheckedAt *time.Time `json:"checked_at"`
+		Position  string     `json:"position"`
+		Breathing string     `json:"breathing"`
+		Notes     string     `json:"notes"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	check := &data.SleepCheck{
+		CheckedAt: time.Now().Truncate(time.Second),
+		Position:  input.Position,
+		Breathing: input.Breathing,
+		CheckedBy: &app.contextGetFaculty(r).FacultyID,
+		Notes:     input.Notes,
+	}
+	if input.CheckedAt != nil {
+		check.CheckedAt = *input.CheckedAt
+	}
+
+	errs := map[string]string{}
+	if !slices.Contains(sleepPositions, check.Position) {
+		errs["position"] = "must be back, side or stomach"
+	}
+	if !slices.Contains(sleepBreathing, check.Breathing) {
+		errs["breathing"] = "must be normal, irregular or labored"
+	}
+	if check.CheckedAt.After(time.Now()) {
+		errs["checked_at"] = "must not be in the future"
+	} else if check.CheckedAt.Before(session.StartedAt) {
+		errs["checked_at"] = "must not be before the student fell asleep"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Sleep.InsertCheck(session, check)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotAsleep):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	status := sleepStatusOf(session, app.cfg.sleep.checkInterval, time.Now())
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"check": check, "sleep": status}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// endSleepHandler records a student waking up.
+func (app *application) endSleepHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := app.readSleepSession(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.Sleep.End(session, time.Now(), app.contextGetFaculty(r).FacultyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotAsleep):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"sleep": session}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listStudentSleepHandler lists the student's sleep sessions and checks for today or the "date"
+// query string day.
+func (app *application) listStudentSleepHandler(w http.ResponseWriter, r *http.Request) {
+	studentID, ok := app.readStudentID(w, r)
+	if !ok {
+		return
+	}
+
+	day, err := app.readDay(r)
+	if err != nil {
+		app.failedValidationResponse(w, r, map[string]string{"date": "must be a date in the format YYYY-MM-DD"})
+		return
+	}
+
+	sessions, err := app.models.Sleep.GetAllByStudentID(studentID, day)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"sleep": sessions}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
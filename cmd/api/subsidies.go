@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/billing"
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/subsidy"
+)
+
+func validateSubsidyAgency(agency *data.SubsidyAgency) map[string]string {
+	errs := map[string]string{}
+
+	if agency.Name == "" {
+		errs["name"] = "must be provided"
+	}
+	if agency.Code == "" {
+		errs["code"] = "must be provided"
+	} else if len(agency.Code) > 10 {
+		errs["code"] = "must not be more than 10 characters long"
+	}
+
+	return errs
+}
+
+func (app *application) createSubsidyAgencyHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name string `json:"name"`
+		Code string `json:"code"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	agency := &data.SubsidyAgency{
+		Name:   strings.TrimSpace(input.Name),
+		Code:   strings.TrimSpace(input.Code),
+		Active: true,
+	}
+
+	if errs := validateSubsidyAgency(agency); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.SubsidyAgencies.Insert(agency)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateAgencyCode):
+			app.failedValidationResponse(w, r, map[string]string{"code": err.Error()})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"agency": agency}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listSubsidyAgenciesHandler(w http.ResponseWriter, r *http.Request) {
+	activeOnly := app.readString(r.URL.Query(), "all", "false") != "true"
+
+	agencies, err := app.models.SubsidyAgencies.GetAll(activeOnly)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"agencies": agencies}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateSubsidyAgencyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	agency, err := app.models.SubsidyAgencies.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Name   *string `json:"name"`
+		Code   *string `json:"code"`
+		Active *bool   `json:"active"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Name != nil {
+		agency.Name = strings.TrimSpace(*input.Name)
+	}
+
+	if input.Code != nil {
+		agency.Code = strings.TrimSpace(*input.Code)
+	}
+
+	if input.Active != nil {
+		agency.Active = *input.Active
+	}
+
+	if errs := validateSubsidyAgency(agency); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.SubsidyAgencies.Update(agency)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateAgencyCode):
+			app.failedValidationResponse(w, r, map[string]string{"code": err.Error()})
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"agency": agency}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func validateSubsidyAuthorization(auth *data.SubsidyAuthorization) map[string]string {
+	errs := map[string]string{}
+
+	if auth.CaseNumber == "" {
+		errs["case_number"] = "must be provided"
+	} else if len(auth.CaseNumber) > 15 {
+		errs["case_number"] = "must not be more than 15 characters long"
+	}
+	if auth.EndsOn != nil && time.Time(*auth.EndsOn).Before(time.Time(auth.StartsOn)) {
+		errs["ends_on"] = "must not be before starts_on"
+	}
+	if auth.AuthorizedDays < 1 || auth.AuthorizedDays > 31 {
+		errs["authorized_days"] = "must be between 1 and 31"
+	}
+	if auth.DailyRateCents < 0 {
+		errs["daily_rate_cents"] = "must not be negative"
+	}
+	if auth.CopayCents < 0 {
+		errs["copay_cents"] = "must not be negative"
+	}
+
+	return errs
+}
+
+func (app *application) createSubsidyAuthorizationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		AgencyID       int64      `json:"agency_id"`
+		CaseNumber     string     `json:"case_number"`
+		StartsOn       *data.Date `json:"starts_on"`
+		EndsOn         *data.Date `json:"ends_on"`
+		AuthorizedDays int        `json:"authorized_days"`
+		DailyRateCents int64      `json:"daily_rate_cents"`
+		CopayCents     int64      `json:"copay_cents"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	auth := &data.SubsidyAuthorization{
+		StudentID:      id,
+		AgencyID:       input.AgencyID,
+		CaseNumber:     strings.TrimSpace(input.CaseNumber),
+		StartsOn:       data.Date(time.Now()),
+		EndsOn:         input.EndsOn,
+		AuthorizedDays: input.AuthorizedDays,
+		DailyRateCents: input.DailyRateCents,
+		CopayCents:     input.CopayCents,
+	}
+	if input.StartsOn != nil {
+		auth.StartsOn = *input.StartsOn
+	}
+
+	errs := validateSubsidyAuthorization(auth)
+
+	agency, err := app.models.SubsidyAgencies.Get(auth.AgencyID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		errs["agency_id"] = "must be an existing agency"
+	case err != nil:
+		app.serverErrorResponse(w, r, err)
+		return
+	case !agency.Active:
+		errs["agency_id"] = "must be an agency that is still in use"
+	}
+
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	_, err = app.models.Students.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Subsidies.Insert(auth)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrOverlappingAuthorization):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	auth.AgencyName = agency.Name
+
+	err = app.writeEnvelopedJSON(w, http.StatusCreated, envelope{"subsidy": auth}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listSubsidyAuthorizationsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	auths, err := app.models.Subsidies.GetAllByStudentID(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"subsidies": auths}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateSubsidyAuthorizationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	authorizationID, err := strconv.ParseInt(chi.URLParam(r, "authorizationID"), 10, 64)
+	if err != nil || authorizationID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	auth, err := app.models.Subsidies.Get(id, authorizationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		CaseNumber     *string    `json:"case_number"`
+		StartsOn       *data.Date `json:"starts_on"`
+		EndsOn         *data.Date `json:"ends_on"`
+		AuthorizedDays *int       `json:"authorized_days"`
+		DailyRateCents *int64     `json:"daily_rate_cents"`
+		CopayCents     *int64     `json:"copay_cents"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.CaseNumber != nil {
+		auth.CaseNumber = strings.TrimSpace(*input.CaseNumber)
+	}
+
+	if input.StartsOn != nil {
+		auth.StartsOn = *input.StartsOn
+	}
+
+	if input.EndsOn != nil {
+		auth.EndsOn = input.EndsOn
+	}
+
+	if input.AuthorizedDays != nil {
+		auth.AuthorizedDays = *input.AuthorizedDays
+	}
+
+	if input.DailyRateCents != nil {
+		auth.DailyRateCents = *input.DailyRateCents
+	}
+
+	if input.CopayCents != nil {
+		auth.CopayCents = *input.CopayCents
+	}
+
+	if errs := validateSubsidyAuthorization(auth); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.Subsidies.Update(auth)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrOverlappingAuthorization):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"subsidy": auth}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// subsidyClaimHandler generates an agency's claim for a month, given as YYYY-MM and defaulting
+// to the previous month, as JSON, CSV or the fixed-width format.
+func (app *application) subsidyClaimHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	agencyID := int64(app.readInt(qs, "agency_id", 0))
+	format := app.readString(qs, "format", "json")
+
+	errs := map[string]string{}
+
+	month := billing.MonthOf(time.Now().AddDate(0, -1, 0))
+	if s := qs.Get("month"); s != "" {
+		t, err := time.Parse("2006-01", s)
+		if err != nil {
+			errs["month"] = "must be a month in the format YYYY-MM"
+		}
+		month = billing.MonthOf(t)
+	}
+
+	if format != "json" && format != "csv" && format != "fixed" {
+		errs["format"] = "must be json, csv or fixed"
+	}
+
+	agency, err := app.models.SubsidyAgencies.Get(agencyID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		errs["agency_id"] = "must be an existing agency"
+	case err != nil:
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	auths, err := app.models.Subsidies.GetClaimable(agency.AgencyID, month.Start, month.End)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	days, err := app.models.Subsidies.GetAttendedDays(agency.AgencyID, month.Start, month.End)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	claim := subsidy.BuildClaim(agency, month.Start, month.End, auths, days, time.Now())
+
+	if format == "json" {
+		err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"claim": claim}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// the claim is written to a buffer first so that a record that doesn't fit the fixed-width
+	// format can still be reported with a proper error response
+	var buf bytes.Buffer
+	contentType, extension := "text/csv", "csv"
+
+	if format == "csv" {
+		err = subsidy.WriteCSV(&buf, claim)
+	} else {
+		contentType, extension = "text/plain; charset=us-ascii", "txt"
+		err = subsidy.WriteFixedWidth(&buf, claim)
+	}
+	if err != nil {
+		app.errorResponse(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("claim_%s_%s.%s", agency.Code, month.Start.Format("2006-01"), extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		app.logError(r, err)
+	}
+}
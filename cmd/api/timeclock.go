@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func (app *application) clockInHandler(w http.ResponseWriter, r *http.Request) {
+	app.recordTimeClock(w, r, app.models.TimeEntries.ClockIn)
+}
+
+func (app *application) clockOutHandler(w http.ResponseWriter, r *http.Request) {
+	app.recordTimeClock(w, r, app.models.TimeEntries.ClockOut)
+}
+
+func (app *application) startBreakHandler(w http.ResponseWriter, r *http.Request) {
+	app.recordTimeClock(w, r, app.models.TimeEntries.StartBreak)
+}
+
+func (app *application) endBreakHandler(w http.ResponseWriter, r *http.Request) {
+	app.recordTimeClock(w, r, app.models.TimeEntries.EndBreak)
+}
+
+// recordTimeClock punches the time clock of the authenticated faculty member using record.
+func (app *application) recordTimeClock(w http.ResponseWriter, r *http.Request, record func(int64, time.Time) (*data.TimeEntry, error)) {
+	faculty := app.contextGetFaculty(r)
+
+	entry, err := record(faculty.FacultyID, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAlreadyClockedIn),
+			errors.Is(err, data.ErrNotClockedIn),
+			errors.Is(err, data.ErrAlreadyOnBreak),
+			errors.Is(err, data.ErrNotOnBreak):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"time_entry": entry}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showTimeClockStatusHandler(w http.ResponseWriter, r *http.Request) {
+	faculty := app.contextGetFaculty(r)
+
+	entry, err := app.models.TimeEntries.GetOpen(faculty.FacultyID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{"clocked_in": entry != nil, "time_entry": entry}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateTimeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	entry, err := app.models.TimeEntries.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		ClockIn  *time.Time `json:"clock_in"`
+		ClockOut *time.Time `json:"clock_out"`
+		Reason   string     `json:"reason"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.ClockIn != nil {
+		entry.ClockIn = *input.ClockIn
+	}
+
+	if input.ClockOut != nil {
+		entry.ClockOut = input.ClockOut
+	}
+
+	errs := map[string]string{}
+	if strings.TrimSpace(input.Reason) == "" {
+		errs["reason"] = "must be provided"
+	}
+	if entry.ClockOut != nil && entry.ClockOut.Before(entry.ClockIn) {
+		errs["clock_out"] = "must not be before clock_in"
+	}
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.models.TimeEntries.Update(entry, app.contextGetFaculty(r).FacultyID, input.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrAlreadyClockedIn):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"time_entry": entry}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listTimeEntryEditsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	edits, err := app.models.TimeEntries.GetEdits(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"edits": edits}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readWeek returns the Monday of the week containing the "week" query string date, or of the
+// current week if there is none, at midnight in the center's time zone.
+func (app *application) readWeek(r *http.Request) (time.Time, error) {
+	s := r.URL.Query().Get("week")
+	if s == "" {
+		return data.WeekStart(time.Now().In(app.cfg.location)), nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", s, app.cfg.location)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return data.WeekStart(t), nil
+}
+
+// showTimesheetHandler shows the weekly timesheet of the authenticated faculty member. Admins
+// can look at anyone's timesheet with the faculty_id query string parameter.
+func (app *application) showTimesheetHandler(w http.ResponseWriter, r *http.Request) {
+	faculty := app.contextGetFaculty(r)
+
+	weekStart, err := app.readWeek(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	facultyID := int64(app.readInt(r.URL.Query(), "faculty_id", int(faculty.FacultyID)))
+	if facultyID != faculty.FacultyID {
+		if !faculty.IsAdmin {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		faculty, err = app.models.Faculty.Get(facultyID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	entries, err := app.models.TimeEntries.GetAllForPeriod(faculty.FacultyID, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	timesheet := data.NewTimesheet(faculty, weekStart, entries, app.overtimeAfter(), time.Now())
+
+	err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"timesheet": timesheet}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listTimesheetsHandler returns the weekly timesheets of every faculty member who clocked in
+// during the week, as JSON or as CSV for payroll.
+func (app *application) listTimesheetsHandler(w http.ResponseWriter, r *http.Request) {
+	weekStart, err := app.readWeek(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	format := app.readString(r.URL.Query(), "format", "json")
+	if format != "json" && format != "csv" {
+		app.failedValidationResponse(w, r, map[string]string{"format": "must be json or csv"})
+		return
+	}
+
+	entries, err := app.models.TimeEntries.GetAllForPeriod(0, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// entries are ordered by faculty member, so each run of entries makes up one timesheet
+	timesheets := []*data.Timesheet{}
+	now := time.Now()
+
+	for start := 0; start < len(entries); {
+		end := start
+		for end < len(entries) && entries[end].FacultyID == entries[start].FacultyID {
+			end++
+		}
+
+		faculty, err := app.models.Faculty.Get(entries[start].FacultyID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		timesheets = append(timesheets, data.NewTimesheet(faculty, weekStart, entries[start:end], app.overtimeAfter(), now))
+		start = end
+	}
+
+	if format == "json" {
+		err = app.writeEnvelopedJSON(w, http.StatusOK, envelope{"timesheets": timesheets}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "timesheets_"+weekStart.Format("2006-01-02")+".csv"))
+	w.WriteHeader(http.StatusOK)
+
+	header := []string{"faculty_id", "first_name", "last_name", "week_start"}
+	for i := 0; i < 7; i++ {
+		header = append(header, weekStart.AddDate(0, 0, i).Format("2006-01-02"))
+	}
+	header = append(header, "total_hours", "regular_hours", "overtime_hours")
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+
+	for _, timesheet := range timesheets {
+		record := []string{
+			strconv.FormatInt(timesheet.FacultyID, 10),
+			timesheet.FirstName,
+			timesheet.LastName,
+			weekStart.Format("2006-01-02"),
+		}
+		for _, day := range timesheet.Days {
+			record = append(record, strconv.FormatFloat(day.Hours, 'f', 2, 64))
+		}
+		record = append(record,
+			strconv.FormatFloat(timesheet.TotalHours, 'f', 2, 64),
+			strconv.FormatFloat(timesheet.RegularHours, 'f', 2, 64),
+			strconv.FormatFloat(timesheet.OvertimeHours, 'f', 2, 64),
+		)
+		cw.Write(record)
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		app.logError(r, err)
+	}
+}
+
+func (app *application) overtimeAfter() time.Duration {
+	return time.Duration(app.cfg.overtimeWeeklyHours * float64(time.Hour))
+}
@@ -0,0 +1,128 @@
+// Command billing-run invoices every family for a billing period, by default the current month.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/liamgluna/daycare-server/internal/billing"
+	"github.com/liamgluna/daycare-server/internal/data"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	// the environment may already be set up, for example when run from cron
+	_ = godotenv.Load()
+
+	var (
+		dsn     string
+		from    string
+		to      string
+		dueDays int
+		dryRun  bool
+	)
+
+	flag.StringVar(&dsn, "db-dsn", os.Getenv("DAYCARE_DB_DSN"), "PostgreSQL DSN")
+	flag.StringVar(&from, "from", "", "First day of the billing period, YYYY-MM-DD (default first day of the current month)")
+	flag.StringVar(&to, "to", "", "Last day of the billing period, YYYY-MM-DD (default last day of the month of -from)")
+	flag.IntVar(&dueDays, "due-days", 14, "Days after the run that invoices are due")
+	flag.BoolVar(&dryRun, "dry-run", false, "Work out the invoices without saving them")
+	flag.Parse()
+
+	period, err := readPeriod(from, to)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	db, err := openDB(dsn)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	biller := billing.Biller{
+		Models:  data.NewModels(db, nil),
+		DueDays: dueDays,
+	}
+
+	summary, err := biller.Run(period, time.Now(), dryRun)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	for _, invoice := range summary.Invoices {
+		fmt.Printf("guardian %d: %s (%d lines)\n", invoice.GuardianID, billing.FormatCents(invoice.TotalCents), len(invoice.Lines))
+	}
+
+	for _, plan := range summary.Unbilled {
+		logger.Warn("student has no guardian to bill", "student_id", plan.StudentID, "student_plan_id", plan.StudentPlanID)
+	}
+
+	for _, fee := range summary.UnbilledFees {
+		logger.Warn("student has no guardian to bill", "student_id", fee.StudentID, "late_fee_id", fee.FeeID)
+	}
+
+	logger.Info("billing run finished",
+		"period", summary.Period.String(),
+		"invoices", len(summary.Invoices),
+		"already_invoiced", summary.AlreadyInvoiced,
+		"total", billing.FormatCents(summary.TotalCents),
+		"dry_run", dryRun,
+	)
+}
+
+// readPeriod returns the billing period given by the -from and -to flags.
+func readPeriod(from, to string) (billing.Period, error) {
+	start := time.Now()
+	if from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return billing.Period{}, fmt.Errorf("invalid -from date: %w", err)
+		}
+		start = t
+	}
+
+	if to == "" {
+		month := billing.MonthOf(start)
+		if from == "" {
+			return month, nil
+		}
+		return billing.NewPeriod(start, month.End)
+	}
+
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return billing.Period{}, fmt.Errorf("invalid -to date: %w", err)
+	}
+
+	return billing.NewPeriod(start, end)
+}
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
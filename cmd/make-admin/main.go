@@ -0,0 +1,83 @@
+// Command make-admin makes a faculty member who has signed up an active admin. It is how the
+// first admin of a new center is set up, since signing up never makes anyone an admin.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/liamgluna/daycare-server/internal/data"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	_ = godotenv.Load()
+
+	var (
+		dsn   string
+		email string
+	)
+
+	flag.StringVar(&dsn, "db-dsn", os.Getenv("DAYCARE_DB_DSN"), "PostgreSQL DSN")
+	flag.StringVar(&email, "email", "", "Email the faculty member signed up with")
+	flag.Parse()
+
+	if email == "" {
+		logger.Error("-email must be provided")
+		os.Exit(1)
+	}
+
+	db, err := openDB(dsn)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	models := data.NewModels(db, nil)
+
+	faculty, err := models.Faculty.GetByEmail(email)
+	if err != nil {
+		logger.Error("looking up faculty member", "email", email, "error", err.Error())
+		os.Exit(1)
+	}
+
+	err = models.Faculty.SetActive(faculty.FacultyID, true)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	err = models.Faculty.SetAdmin(faculty.FacultyID, true)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger.Info("faculty member is now an active admin", "faculty_id", faculty.FacultyID, "email", faculty.Email)
+}
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,221 @@
+// Package billing turns the tuition plans students are on into invoices for their families.
+// All amounts are integer cents.
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// Period is the range of days a billing run covers, both ends included. Its days are kept as
+// midnight UTC so that day arithmetic isn't thrown off by time zones.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// NewPeriod returns the period from the day of start to the day of end.
+func NewPeriod(start, end time.Time) (Period, error) {
+	period := Period{Start: day(start), End: day(end)}
+	if period.End.Before(period.Start) {
+		return Period{}, errors.New("the period must not end before it starts")
+	}
+
+	return period, nil
+}
+
+// MonthOf returns the calendar month containing t.
+func MonthOf(t time.Time) Period {
+	start := monthStart(day(t))
+	return Period{Start: start, End: start.AddDate(0, 1, -1)}
+}
+
+func (p Period) String() string {
+	return p.Start.Format("2006-01-02") + " to " + p.End.Format("2006-01-02")
+}
+
+// Summary describes the outcome of a billing run.
+type Summary struct {
+	Period          Period
+	Invoices        []*data.Invoice
+	TotalCents      int64
+	AlreadyInvoiced int
+	// Unbilled are the plans of students without a guardian to bill.
+	Unbilled []*data.BillablePlan
+	// UnbilledFees are the late pickup fees of students without a guardian to bill.
+	UnbilledFees []*data.BillableLateFee
+}
+
+// BuildInvoices groups the lines of the plans and the late pickup fees by family into one
+// invoice per billing guardian, and adds the discounts the rules give each family. Families with
+// nothing to pay for the period get no invoice.
+func BuildInvoices(plans []*data.BillablePlan, fees []*data.BillableLateFee, rules []*data.DiscountRule, period Period, dueOn time.Time) ([]*data.Invoice, []*data.BillablePlan, []*data.BillableLateFee) {
+	invoices := []*data.Invoice{}
+	unbilled := []*data.BillablePlan{}
+	unbilledFees := []*data.BillableLateFee{}
+	byGuardian := map[int64]*data.Invoice{}
+	familyPlans := map[int64][]*data.BillablePlan{}
+
+	addLines := func(guardianID int64, lines []*data.InvoiceLine) {
+		invoice, ok := byGuardian[guardianID]
+		if !ok {
+			invoice = &data.Invoice{
+				GuardianID:  guardianID,
+				PeriodStart: data.Date(period.Start),
+				PeriodEnd:   data.Date(period.End),
+				DueOn:       data.Date(day(dueOn)),
+				Lines:       []*data.InvoiceLine{},
+			}
+			byGuardian[guardianID] = invoice
+			invoices = append(invoices, invoice)
+		}
+
+		invoice.Lines = append(invoice.Lines, lines...)
+	}
+
+	for _, plan := range plans {
+		if plan.GuardianID == 0 {
+			unbilled = append(unbilled, plan)
+			continue
+		}
+
+		lines := TuitionLines(plan, period)
+		if len(lines) == 0 {
+			continue
+		}
+
+		addLines(plan.GuardianID, lines)
+		familyPlans[plan.GuardianID] = append(familyPlans[plan.GuardianID], plan)
+	}
+
+	for _, fee := range fees {
+		if fee.GuardianID == 0 {
+			unbilledFees = append(unbilledFees, fee)
+			continue
+		}
+
+		addLines(fee.GuardianID, []*data.InvoiceLine{LateFeeLine(fee)})
+	}
+
+	for _, invoice := range invoices {
+		invoice.Lines = append(invoice.Lines, DiscountLines(invoice.Lines, familyPlans[invoice.GuardianID], rules)...)
+		invoice.TotalCents = Total(invoice.Lines)
+	}
+
+	return invoices, unbilled, unbilledFees
+}
+
+// Total adds up the amounts of the lines.
+func Total(lines []*data.InvoiceLine) int64 {
+	var total int64
+	for _, line := range lines {
+		total += line.AmountCents
+	}
+	return total
+}
+
+// Biller runs billing against the database.
+type Biller struct {
+	Models data.Models
+	// DueDays is the number of days after the run that invoices are due.
+	DueDays int
+}
+
+// Run invoices every family for the period, together with any late pickup fees up to the end of
+// the period that haven't been billed yet. Families that were already invoiced for any day of
+// the period are skipped, so a run can safely be repeated or overlap an earlier one. With dryRun
+// set the invoices are worked out but not saved.
+func (b Biller) Run(period Period, now time.Time, dryRun bool) (*Summary, error) {
+	plans, err := b.Models.StudentPlans.GetBillable(period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := b.Models.LateFees.GetBillable(period.End)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := b.Models.DiscountRules.GetAll(true)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices, unbilled, unbilledFees := BuildInvoices(plans, fees, rules, period, now.AddDate(0, 0, b.DueDays))
+
+	summary := &Summary{
+		Period:       period,
+		Invoices:     []*data.Invoice{},
+		Unbilled:     unbilled,
+		UnbilledFees: unbilledFees,
+	}
+
+	for _, invoice := range invoices {
+		if !dryRun {
+			err := b.Models.Invoices.Insert(invoice)
+			if err != nil {
+				if errors.Is(err, data.ErrDuplicateInvoice) {
+					summary.AlreadyInvoiced++
+					continue
+				}
+				return nil, fmt.Errorf("invoicing guardian %d: %w", invoice.GuardianID, err)
+			}
+		}
+
+		summary.Invoices = append(summary.Invoices, invoice)
+		summary.TotalCents += invoice.TotalCents
+	}
+
+	return summary, nil
+}
+
+// FormatCents formats an amount of cents as a decimal amount, like 1234.50.
+func FormatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func day(t time.Time) time.Time {
+	year, month, d := t.Date()
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// firstMonday returns the first Monday on or after t.
+func firstMonday(t time.Time) time.Time {
+	return t.AddDate(0, 0, (8-int(t.Weekday()))%7)
+}
+
+// overlapDays returns the number of days in both from1 to to1 and from2 to to2, ends included.
+func overlapDays(from1, to1, from2, to2 time.Time) int {
+	from := maxTime(from1, from2)
+	to := minTime(to1, to2)
+	if to.Before(from) {
+		return 0
+	}
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,103 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func TestBuildInvoices(t *testing.T) {
+	october := MonthOf(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	dueOn := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+
+	plan := func(studentID, guardianID int64, startsOn data.Date, rate int64) *data.BillablePlan {
+		return &data.BillablePlan{
+			StudentPlan: data.StudentPlan{StudentPlanID: studentID, StudentID: studentID, PlanName: "Full time", StartsOn: startsOn},
+			Frequency:   "monthly",
+			RateCents:   rate,
+			GuardianID:  guardianID,
+		}
+	}
+
+	fee := func(feeID, studentID, guardianID int64, amount int64) *data.BillableLateFee {
+		return &data.BillableLateFee{
+			LatePickupFee: data.LatePickupFee{FeeID: feeID, StudentID: studentID, ClassDate: date(2026, 10, 7), MinutesLate: 10, AmountCents: amount},
+			GuardianID:    guardianID,
+		}
+	}
+
+	plans := []*data.BillablePlan{
+		plan(10, 1, date(2026, 1, 1), 62000),
+		plan(11, 1, date(2026, 1, 1), 40000),
+		plan(12, 0, date(2026, 1, 1), 62000),
+		plan(13, 2, date(2026, 11, 1), 62000),
+	}
+	fees := []*data.BillableLateFee{
+		fee(1, 10, 1, 1500),
+		fee(2, 12, 0, 1500),
+		fee(3, 14, 3, 2000),
+	}
+	rules := []*data.DiscountRule{
+		{RuleID: 1, Name: "Sibling discount", AppliesTo: "sibling", Calculation: "percent", Value: 10, FromChild: 2, Stackable: true},
+	}
+
+	invoices, unbilled, unbilledFees := BuildInvoices(plans, fees, rules, october, dueOn)
+
+	if len(unbilled) != 1 || unbilled[0].StudentID != 12 {
+		t.Errorf("unbilled plans = %v, want the plan of student 12", unbilled)
+	}
+	if len(unbilledFees) != 1 || unbilledFees[0].FeeID != 2 {
+		t.Errorf("unbilled fees = %v, want fee 2", unbilledFees)
+	}
+
+	want := []struct {
+		guardianID int64
+		lines      int
+		total      int64
+	}{
+		// two children's tuition, a late fee and 10% off the cheaper place
+		{1, 4, 62000 + 40000 + 1500 - 4000},
+		// only a late fee, the family's plan hasn't started yet
+		{3, 1, 2000},
+	}
+
+	if len(invoices) != len(want) {
+		t.Fatalf("got %d invoices, want %d", len(invoices), len(want))
+	}
+
+	for i, want := range want {
+		invoice := invoices[i]
+
+		if invoice.GuardianID != want.guardianID {
+			t.Errorf("invoice %d is for guardian %d, want %d", i, invoice.GuardianID, want.guardianID)
+		}
+		if len(invoice.Lines) != want.lines {
+			t.Errorf("invoice %d has %d lines, want %d", i, len(invoice.Lines), want.lines)
+		}
+		if invoice.TotalCents != want.total || Total(invoice.Lines) != want.total {
+			t.Errorf("invoice %d totals %d, want %d", i, invoice.TotalCents, want.total)
+		}
+		if !time.Time(invoice.PeriodStart).Equal(october.Start) || !time.Time(invoice.PeriodEnd).Equal(october.End) {
+			t.Errorf("invoice %d covers %s to %s, want %s", i, time.Time(invoice.PeriodStart), time.Time(invoice.PeriodEnd), october)
+		}
+		if !time.Time(invoice.DueOn).Equal(time.Time(date(2026, 10, 15))) {
+			t.Errorf("invoice %d is due on %s, want 2026-10-15", i, time.Time(invoice.DueOn))
+		}
+	}
+}
+
+func TestNewPeriod(t *testing.T) {
+	_, err := NewPeriod(time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if err == nil {
+		t.Error("a period ending before it starts was accepted")
+	}
+
+	p, err := NewPeriod(time.Date(2026, 10, 15, 23, 0, 0, 0, time.UTC), time.Date(2026, 10, 15, 1, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("a single day period was refused: %v", err)
+	}
+	if !p.Start.Equal(p.End) {
+		t.Errorf("period = %s, want a single day", p)
+	}
+}
@@ -0,0 +1,142 @@
+package billing
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// KindDiscount is the kind of invoice lines taking a discount off tuition.
+const KindDiscount = "discount"
+
+// DiscountLines returns a line for every discount the family's children get on the tuition
+// lines of their invoice. Discounts only ever apply to tuition, not to registration or late
+// pickup fees, and never come to more than a child's tuition.
+//
+// Children are counted for sibling discounts from the one with the highest tuition on the
+// invoice, so that the discount goes to the cheaper places. Of the rules that apply to a child,
+// either all stackable ones together or a single rule that isn't stackable is given, whichever
+// takes off more.
+func DiscountLines(lines []*data.InvoiceLine, plans []*data.BillablePlan, rules []*data.DiscountRule) []*data.InvoiceLine {
+	type child struct {
+		studentID  int64
+		name       string
+		staffChild bool
+		tuition    int64
+	}
+
+	children := []*child{}
+	byStudent := map[int64]*child{}
+
+	for _, plan := range plans {
+		if _, ok := byStudent[plan.StudentID]; !ok {
+			c := &child{studentID: plan.StudentID, name: plan.FirstName + " " + plan.LastName}
+			byStudent[plan.StudentID] = c
+			children = append(children, c)
+		}
+		byStudent[plan.StudentID].staffChild = byStudent[plan.StudentID].staffChild || plan.StaffChild
+	}
+
+	for _, line := range lines {
+		if line.Kind != KindTuition || line.StudentID == nil {
+			continue
+		}
+		if c, ok := byStudent[*line.StudentID]; ok {
+			c.tuition += line.AmountCents
+		}
+	}
+
+	sort.SliceStable(children, func(i, j int) bool {
+		if children[i].tuition != children[j].tuition {
+			return children[i].tuition > children[j].tuition
+		}
+		return children[i].studentID < children[j].studentID
+	})
+
+	discounts := []*data.InvoiceLine{}
+	position := 0
+
+	for _, c := range children {
+		if c.tuition <= 0 {
+			continue
+		}
+		position++
+
+		applicable := []*data.DiscountRule{}
+		for _, rule := range rules {
+			switch {
+			case rule.AppliesTo == "sibling" && position >= rule.FromChild:
+				applicable = append(applicable, rule)
+			case rule.AppliesTo == "staff" && c.staffChild:
+				applicable = append(applicable, rule)
+			}
+		}
+
+		best := []*data.DiscountRule{}
+		bestCents := int64(0)
+
+		stacked := []*data.DiscountRule{}
+		for _, rule := range applicable {
+			if rule.Stackable {
+				stacked = append(stacked, rule)
+			}
+		}
+		if cents := discountCents(stacked, c.tuition); cents > bestCents {
+			best, bestCents = stacked, cents
+		}
+
+		for _, rule := range applicable {
+			if rule.Stackable {
+				continue
+			}
+			if cents := discountCents([]*data.DiscountRule{rule}, c.tuition); cents > bestCents {
+				best, bestCents = []*data.DiscountRule{rule}, cents
+			}
+		}
+
+		remaining := c.tuition
+		for _, rule := range best {
+			cents := min(ruleCents(rule, c.tuition), remaining)
+			if cents <= 0 {
+				continue
+			}
+			remaining -= cents
+
+			description := fmt.Sprintf("%s for %s", rule.Name, c.name)
+			if rule.Calculation == "percent" {
+				description += fmt.Sprintf(" (%d%%)", rule.Value)
+			}
+
+			studentID := c.studentID
+			discounts = append(discounts, &data.InvoiceLine{
+				StudentID:   &studentID,
+				Kind:        KindDiscount,
+				Description: description,
+				Quantity:    1,
+				UnitCents:   -cents,
+				AmountCents: -cents,
+			})
+		}
+	}
+
+	return discounts
+}
+
+// discountCents returns what the rules take off tuition together, at most the tuition itself.
+func discountCents(rules []*data.DiscountRule, tuition int64) int64 {
+	var cents int64
+	for _, rule := range rules {
+		cents += ruleCents(rule, tuition)
+	}
+	return min(cents, tuition)
+}
+
+// ruleCents returns what the rule on its own takes off tuition. Percentages are worked out on
+// the full tuition, not on what is left after other discounts, and rounded half up.
+func ruleCents(rule *data.DiscountRule, tuition int64) int64 {
+	if rule.Calculation == "percent" {
+		return prorate(tuition, int(rule.Value), 100)
+	}
+	return rule.Value
+}
@@ -0,0 +1,152 @@
+package billing
+
+import (
+	"testing"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func TestDiscountLines(t *testing.T) {
+	sibling := &data.DiscountRule{RuleID: 1, Name: "Sibling discount", AppliesTo: "sibling", Calculation: "percent", Value: 10, FromChild: 2, Stackable: true}
+	thirdChild := &data.DiscountRule{RuleID: 2, Name: "Third child", AppliesTo: "sibling", Calculation: "amount", Value: 5000, FromChild: 3, Stackable: true}
+	staff := &data.DiscountRule{RuleID: 3, Name: "Staff discount", AppliesTo: "staff", Calculation: "percent", Value: 20, Stackable: true}
+	staffRate := func(cents int64) *data.DiscountRule {
+		return &data.DiscountRule{RuleID: 4, Name: "Staff rate", AppliesTo: "staff", Calculation: "amount", Value: cents}
+	}
+
+	type child struct {
+		studentID  int64
+		staffChild bool
+		tuition    int64
+	}
+
+	type discount struct {
+		studentID int64
+		cents     int64
+	}
+
+	tests := []struct {
+		name     string
+		children []child
+		extra    []*data.InvoiceLine
+		rules    []*data.DiscountRule
+		want     []discount
+	}{
+		{
+			name:     "an only child gets no sibling discount",
+			children: []child{{10, false, 62000}},
+			rules:    []*data.DiscountRule{sibling},
+			want:     []discount{},
+		},
+		{
+			name:     "the cheaper place gets the sibling discount",
+			children: []child{{10, false, 40000}, {11, false, 62000}},
+			rules:    []*data.DiscountRule{sibling},
+			want:     []discount{{10, 4000}},
+		},
+		{
+			name:     "equal places are counted by student",
+			children: []child{{11, false, 62000}, {10, false, 62000}},
+			rules:    []*data.DiscountRule{sibling},
+			want:     []discount{{11, 6200}},
+		},
+		{
+			name:     "children without tuition aren't counted",
+			children: []child{{10, false, 62000}, {12, false, 0}, {11, false, 40000}},
+			rules:    []*data.DiscountRule{sibling, thirdChild},
+			want:     []discount{{11, 4000}},
+		},
+		{
+			name:     "every child from the rule's position on",
+			children: []child{{10, false, 62000}, {11, false, 40000}, {12, false, 30000}},
+			rules:    []*data.DiscountRule{sibling, thirdChild},
+			want:     []discount{{11, 4000}, {12, 3000}, {12, 5000}},
+		},
+		{
+			name:     "stackable rules add up",
+			children: []child{{10, false, 62000}, {11, true, 40000}},
+			rules:    []*data.DiscountRule{sibling, staff},
+			want:     []discount{{11, 4000}, {11, 8000}},
+		},
+		{
+			name:     "a rule that isn't stackable is given when it takes off more",
+			children: []child{{10, false, 62000}, {11, true, 40000}},
+			rules:    []*data.DiscountRule{sibling, staff, staffRate(30000)},
+			want:     []discount{{11, 30000}},
+		},
+		{
+			name:     "stackable rules are given when they take off more",
+			children: []child{{10, false, 62000}, {11, true, 40000}},
+			rules:    []*data.DiscountRule{sibling, staff, staffRate(10000)},
+			want:     []discount{{11, 4000}, {11, 8000}},
+		},
+		{
+			name:     "percentages round half up",
+			children: []child{{10, false, 62000}, {11, false, 12345}},
+			rules:    []*data.DiscountRule{sibling},
+			want:     []discount{{11, 1235}},
+		},
+		{
+			name:     "a discount never comes to more than the tuition",
+			children: []child{{10, true, 40000}},
+			rules:    []*data.DiscountRule{staffRate(100000)},
+			want:     []discount{{10, 40000}},
+		},
+		{
+			name:     "stacked discounts never come to more than the tuition",
+			children: []child{{10, false, 62000}, {11, false, 40000}, {12, false, 3000}},
+			rules:    []*data.DiscountRule{sibling, thirdChild},
+			want:     []discount{{11, 4000}, {12, 300}, {12, 2700}},
+		},
+		{
+			name:     "registration and late pickup fees aren't discounted",
+			children: []child{{10, false, 62000}, {11, false, 40000}},
+			extra: []*data.InvoiceLine{
+				{StudentID: studentID(11), Kind: KindRegistration, AmountCents: 5000},
+				{StudentID: studentID(11), Kind: KindLatePickup, AmountCents: 1500},
+			},
+			rules: []*data.DiscountRule{sibling},
+			want:  []discount{{11, 4000}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := []*data.InvoiceLine{}
+			plans := []*data.BillablePlan{}
+
+			for _, c := range tt.children {
+				plans = append(plans, &data.BillablePlan{
+					StudentPlan: data.StudentPlan{StudentID: c.studentID},
+					FirstName:   "Child",
+					StaffChild:  c.staffChild,
+				})
+				if c.tuition > 0 {
+					lines = append(lines, &data.InvoiceLine{StudentID: studentID(c.studentID), Kind: KindTuition, AmountCents: c.tuition})
+				}
+			}
+			lines = append(lines, tt.extra...)
+
+			discounts := DiscountLines(lines, plans, tt.rules)
+
+			if len(discounts) != len(tt.want) {
+				t.Fatalf("got %d discounts, want %d", len(discounts), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				got := discounts[i]
+				if got.StudentID == nil {
+					t.Fatalf("discount %d is for no student", i)
+				}
+				if got.Kind != KindDiscount || *got.StudentID != want.studentID || got.AmountCents != -want.cents {
+					t.Errorf("discount %d = %s %d for student %d, want %d off for student %d",
+						i, got.Kind, got.AmountCents, *got.StudentID, want.cents, want.studentID)
+				}
+			}
+		})
+	}
+}
+
+func studentID(id int64) *int64 {
+	return &id
+}
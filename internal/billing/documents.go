@@ -0,0 +1,251 @@
+package billing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+	"github.com/liamgluna/daycare-server/internal/pdf"
+)
+
+// Provider is the daycare as it appears on invoices, receipts and tax statements.
+type Provider struct {
+	Name    string
+	Address string
+	TaxID   string
+}
+
+// InvoicePDF returns the invoice as a printable document addressed to the family.
+func InvoicePDF(provider Provider, invoice *data.Invoice, family *data.Family) *pdf.Document {
+	s := newSheet(provider, fmt.Sprintf("Invoice %d", invoice.InvoiceID))
+
+	s.details([][2]string{
+		{"Invoice date", invoice.IssuedAt.Format("January 2, 2006")},
+		{"Billing period", formatDate(invoice.PeriodStart) + " to " + formatDate(invoice.PeriodEnd)},
+		{"Due date", formatDate(invoice.DueOn)},
+		{"Status", strings.ToUpper(invoice.Status[:1]) + invoice.Status[1:]},
+	})
+	s.billTo("Bill to", family.FamilyID, family.Guardians)
+
+	s.columns(pdf.Bold, "Description", "Qty", "Unit price", "Amount")
+	s.rule()
+	for _, line := range invoice.Lines {
+		s.columns(pdf.Regular, line.Description, strconv.Itoa(line.Quantity), FormatCents(line.UnitCents), FormatCents(line.AmountCents))
+	}
+	s.rule()
+	s.columns(pdf.Bold, "Total", "", "", FormatCents(invoice.TotalCents))
+
+	if invoice.Status == "void" {
+		s.space(12)
+		s.paragraph(pdf.Bold, "This invoice has been voided and is not payable.")
+	}
+
+	return s.doc
+}
+
+// ReceiptPDF returns a receipt for the payment addressed to the family.
+func ReceiptPDF(provider Provider, payment *data.Payment, family *data.Family) *pdf.Document {
+	s := newSheet(provider, fmt.Sprintf("Receipt %d", payment.PaymentID))
+
+	method := strings.ReplaceAll(payment.Method, "_", " ")
+	details := [][2]string{
+		{"Date received", formatDate(payment.ReceivedOn)},
+		{"Payment method", strings.ToUpper(method[:1]) + method[1:]},
+	}
+	if payment.Reference != "" {
+		details = append(details, [2]string{"Reference", payment.Reference})
+	}
+	details = append(details, [2]string{"Amount received", FormatCents(payment.AmountCents)})
+
+	s.details(details)
+	s.billTo("Received from", family.FamilyID, family.Guardians)
+	s.paragraph(pdf.Regular, "Thank you for your payment. It has been applied to the oldest open invoices on your account.")
+
+	return s.doc
+}
+
+// TaxStatementPDF returns the family's year-end childcare expense statement.
+func TaxStatementPDF(provider Provider, statement *data.TaxStatement) *pdf.Document {
+	s := newSheet(provider, fmt.Sprintf("Childcare Expenses %d", statement.Year))
+
+	s.details([][2]string{
+		{"Tax year", strconv.Itoa(statement.Year)},
+		{"Issued", time.Now().Format("January 2, 2006")},
+	})
+	s.billTo("Issued to", statement.FamilyID, statement.Guardians)
+
+	s.columns(pdf.Bold, "Child", "", "Date of birth", "Charged")
+	s.rule()
+	for _, child := range statement.Children {
+		s.columns(pdf.Regular, child.FirstName+" "+child.LastName, "", formatDate(child.DateOfBirth), FormatCents(child.ChargedCents))
+	}
+	s.rule()
+	s.columns(pdf.Bold, "Total charged", "", "", FormatCents(statement.ChargedCents))
+	s.space(12)
+
+	s.columns(pdf.Regular, "Payments received", "", "", FormatCents(statement.PaidCents))
+	if statement.RefundedCents > 0 {
+		s.columns(pdf.Regular, "Refunds", "", "", FormatCents(-statement.RefundedCents))
+	}
+	s.rule()
+	s.columns(pdf.Bold, fmt.Sprintf("Total paid for childcare in %d", statement.Year), "", "", FormatCents(statement.NetPaidCents))
+	s.space(12)
+
+	s.paragraph(pdf.Regular, fmt.Sprintf(
+		"This statement lists the childcare fees charged to the family for %d and the payments %s received from the family during %d, less refunds.",
+		statement.Year, provider.Name, statement.Year))
+	if provider.TaxID != "" {
+		s.paragraph(pdf.Regular, "Provider tax ID: "+provider.TaxID)
+	}
+
+	return s.doc
+}
+
+// Layout of the documents, in points.
+const (
+	marginLeft   = 54.0
+	marginRight  = pdf.PageWidth - 54
+	marginTop    = 60.0
+	marginBottom = pdf.PageHeight - 54
+	fontSize     = 10.0
+	lineHeight   = 14.0
+)
+
+// Right edges of the columns after the description.
+var columnEdges = [3]float64{marginRight - 170, marginRight - 85, marginRight}
+
+// sheet lays out a document from top to bottom, starting new pages as they fill up.
+type sheet struct {
+	doc   *pdf.Document
+	page  *pdf.Page
+	title string
+	y     float64
+}
+
+func newSheet(provider Provider, title string) *sheet {
+	s := &sheet{doc: pdf.New(title), title: title}
+	s.newPage()
+
+	s.page.Text(marginLeft, s.y, pdf.Bold, 16, provider.Name)
+	s.page.TextRight(marginRight, s.y, pdf.Bold, 16, title)
+	s.y += 20
+
+	for _, line := range strings.Split(provider.Address, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			s.page.Text(marginLeft, s.y, pdf.Regular, fontSize, line)
+			s.y += lineHeight
+		}
+	}
+	s.space(lineHeight)
+
+	return s
+}
+
+func (s *sheet) newPage() {
+	s.page = s.doc.AddPage()
+	s.y = marginTop
+
+	if len(s.doc.Pages()) > 1 {
+		s.page.Text(marginLeft, s.y, pdf.Bold, fontSize, s.title+" (continued)")
+		s.y += 2 * lineHeight
+	}
+}
+
+// need starts a new page unless there is room for height more points on this one.
+func (s *sheet) need(height float64) {
+	if s.y+height > marginBottom {
+		s.newPage()
+	}
+}
+
+func (s *sheet) space(height float64) {
+	s.y += height
+}
+
+// details writes labelled values, one a line.
+func (s *sheet) details(rows [][2]string) {
+	for _, row := range rows {
+		s.need(lineHeight)
+		s.page.Text(marginLeft, s.y, pdf.Bold, fontSize, row[0])
+		s.page.Text(marginLeft+110, s.y, pdf.Regular, fontSize, row[1])
+		s.y += lineHeight
+	}
+	s.space(lineHeight)
+}
+
+// billTo writes the names of the family's guardians under the heading.
+func (s *sheet) billTo(heading string, familyID int64, guardians []*data.Guardian) {
+	s.need(2 * lineHeight)
+	s.page.Text(marginLeft, s.y, pdf.Bold, fontSize, heading)
+	s.y += lineHeight
+
+	for _, guardian := range guardians {
+		s.need(lineHeight)
+		s.page.Text(marginLeft, s.y, pdf.Regular, fontSize, guardian.FirstName+" "+guardian.LastName)
+		s.y += lineHeight
+	}
+
+	s.need(lineHeight)
+	s.page.Text(marginLeft, s.y, pdf.Regular, fontSize, fmt.Sprintf("Family account %d", familyID))
+	s.y += 2 * lineHeight
+}
+
+// columns writes a row of a table: a description, wrapped if it's too long, followed by three
+// columns aligned to the right.
+func (s *sheet) columns(font pdf.Font, description string, cols ...string) {
+	lines := wrap(font, fontSize, description, columnEdges[0]-60-marginLeft)
+
+	s.need(float64(len(lines)) * lineHeight)
+	for i, line := range lines {
+		s.page.Text(marginLeft, s.y+float64(i)*lineHeight, font, fontSize, line)
+	}
+	for i, col := range cols {
+		s.page.TextRight(columnEdges[i], s.y, font, fontSize, col)
+	}
+
+	s.y += float64(len(lines)) * lineHeight
+}
+
+// rule draws a line across the page under the last row.
+func (s *sheet) rule() {
+	s.need(6)
+	s.page.Line(marginLeft, s.y-9, marginRight, s.y-9)
+	s.y += 6
+}
+
+// paragraph writes text wrapped to the width of the page.
+func (s *sheet) paragraph(font pdf.Font, text string) {
+	for _, line := range wrap(font, fontSize, text, marginRight-marginLeft) {
+		s.need(lineHeight)
+		s.page.Text(marginLeft, s.y, font, fontSize, line)
+		s.y += lineHeight
+	}
+}
+
+// wrap splits text into lines no wider than width, breaking between words.
+func wrap(font pdf.Font, size float64, text string, width float64) []string {
+	lines := []string{}
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if line != "" && pdf.TextWidth(font, size, candidate) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line = candidate
+	}
+
+	return append(lines, line)
+}
+
+func formatDate(d data.Date) string {
+	return time.Time(d).Format("January 2, 2006")
+}
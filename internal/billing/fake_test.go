@@ -0,0 +1,101 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFakeProviderVerifyWebhook(t *testing.T) {
+	provider := NewFakeProvider("whsec_test")
+	payload := []byte(`{"id":"evt_1","type":"payment.succeeded","intent_ref":"fake_pi_1","amount_cents":62000}`)
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		signature string
+	}{
+		{"no signature", payload, ""},
+		{"no timestamp", payload, "v1=" + mac(provider.Sign(payload, now))},
+		{"signed with another secret", payload, NewFakeProvider("whsec_other").Sign(payload, now)},
+		{"body changed after signing", []byte(`{"id":"evt_1","type":"payment.succeeded","intent_ref":"fake_pi_1","amount_cents":1}`), provider.Sign(payload, now)},
+		{"malformed signature", payload, fmt.Sprintf("t=%d,v1=not-hex", now.Unix())},
+		{"stale timestamp", payload, provider.Sign(payload, now.Add(-webhookTolerance-time.Minute))},
+		{"timestamp in the future", payload, provider.Sign(payload, now.Add(webhookTolerance+time.Minute))},
+		{
+			name:      "replayed with a new timestamp",
+			payload:   payload,
+			signature: fmt.Sprintf("t=%d,v1=%s", now.Unix(), mac(provider.Sign(payload, now.Add(-webhookTolerance-time.Minute)))),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyWebhook(tt.payload, tt.signature)
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("got error %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		event, err := provider.VerifyWebhook(payload, provider.Sign(payload, now.Add(-time.Minute)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.ID != "evt_1" || event.Type != EventPaymentSucceeded || event.IntentRef != "fake_pi_1" || event.AmountCents != 62000 {
+			t.Errorf("event = %+v", event)
+		}
+	})
+
+	t.Run("valid signature on a malformed body", func(t *testing.T) {
+		body := []byte(`not json`)
+		_, err := provider.VerifyWebhook(body, provider.Sign(body, now))
+		if err == nil || errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("got error %v, want a malformed event error", err)
+		}
+	})
+}
+
+// mac returns the HMAC part of a signature header.
+func mac(signature string) string {
+	_, mac, _ := strings.Cut(signature, ",v1=")
+	return mac
+}
+
+func TestFakeProviderCapture(t *testing.T) {
+	provider := NewFakeProvider("whsec_test")
+
+	intent, err := provider.CreateIntent(62000, "Invoice 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intent.Status != IntentRequiresCapture || intent.ClientSecret == "" {
+		t.Errorf("new intent = %+v, want a client secret and status %s", intent, IntentRequiresCapture)
+	}
+
+	// Capturing twice, as when the front desk retries, reports the same succeeded intent.
+	for i := 0; i < 2; i++ {
+		captured, err := provider.Capture(intent.Ref)
+		if err != nil {
+			t.Fatalf("capture %d: %v", i+1, err)
+		}
+		if captured.Status != IntentSucceeded || captured.AmountCents != 62000 || captured.ClientSecret != "" {
+			t.Errorf("capture %d = %+v", i+1, captured)
+		}
+	}
+
+	if _, err := provider.Capture("fake_pi_unknown"); !errors.Is(err, ErrUnknownIntent) {
+		t.Errorf("capturing an unknown intent: got error %v, want %v", err, ErrUnknownIntent)
+	}
+
+	if _, err := provider.Refund(intent.Ref, 50000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Refund(intent.Ref, 12001); !errors.Is(err, ErrRefundExceedsCharge) {
+		t.Errorf("refunding more than is left: got error %v, want %v", err, ErrRefundExceedsCharge)
+	}
+}
@@ -0,0 +1,64 @@
+package billing
+
+import "errors"
+
+// Statuses of payment intents, as reported by the payment provider.
+const (
+	IntentRequiresCapture = "requires_capture"
+	IntentSucceeded       = "succeeded"
+	IntentFailed          = "failed"
+)
+
+// Types of the webhook events the payment provider sends.
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+)
+
+var (
+	ErrInvalidSignature    = errors.New("invalid webhook signature")
+	ErrUnknownIntent       = errors.New("the payment provider doesn't know the payment intent")
+	ErrIntentNotCapturable = errors.New("the payment intent can't be captured")
+	ErrRefundExceedsCharge = errors.New("the refund is more than what is left of the card payment")
+)
+
+// PaymentIntent is a card payment as the payment provider sees it. The client secret lets the
+// guardian's browser or the front desk terminal confirm the card details with the provider
+// directly, so card numbers never pass through the server.
+type PaymentIntent struct {
+	Ref          string `json:"ref"`
+	AmountCents  int64  `json:"amount_cents"`
+	Status       string `json:"status"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// ProviderRefund is money the payment provider paid back to the card of a payment.
+type ProviderRefund struct {
+	Ref         string `json:"ref"`
+	IntentRef   string `json:"intent_ref"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+// WebhookEvent is a notification from the payment provider that an intent changed.
+type WebhookEvent struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	IntentRef   string `json:"intent_ref"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+// PaymentProvider takes card payments. Intents are created for an amount, confirmed by the
+// payer with the provider, and then captured, after which the provider also reports that the
+// payment succeeded by webhook. Either way the payment is recorded once, by the intent's Ref.
+type PaymentProvider interface {
+	// Name identifies the provider in the intents stored for it.
+	Name() string
+
+	CreateIntent(amountCents int64, description string) (*PaymentIntent, error)
+	Capture(ref string) (*PaymentIntent, error)
+	Refund(ref string, amountCents int64) (*ProviderRefund, error)
+
+	// VerifyWebhook checks the signature of a webhook request body and returns the event in
+	// it, or ErrInvalidSignature.
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
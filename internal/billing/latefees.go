@@ -0,0 +1,63 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// KindLatePickup is the kind of invoice lines for late pickup fees.
+const KindLatePickup = "late_pickup"
+
+// LateFee works out the fee for a student checked out at checkedOutAt under the rule, which
+// applies that day. The rule's closing time is in the center's time zone, location, whatever
+// zone checkedOutAt is in. Only whole minutes count, and once the grace period is over every
+// minute since closing is charged. A student who wasn't late, or was late within the grace
+// period, gets no fee and nil is returned.
+func LateFee(rule *data.LateFeeRule, studentID int64, checkedOutAt time.Time, location *time.Location) (*data.LatePickupFee, error) {
+	closesAt, err := time.Parse("15:04", rule.ClosesAt)
+	if err != nil {
+		return nil, fmt.Errorf("late fee rule %d: invalid closing time %q", rule.RuleID, rule.ClosesAt)
+	}
+
+	year, month, d := checkedOutAt.In(location).Date()
+	closing := time.Date(year, month, d, closesAt.Hour(), closesAt.Minute(), 0, 0, location)
+
+	minutes := int(checkedOutAt.Sub(closing) / time.Minute)
+	if minutes <= 0 || minutes <= rule.GraceMinutes {
+		return nil, nil
+	}
+
+	amount := int64(minutes) * rule.RateCents
+	if rule.Charge == "per_block" {
+		blocks := (minutes + rule.BlockMinutes - 1) / rule.BlockMinutes
+		amount = int64(blocks) * rule.RateCents
+	}
+
+	return &data.LatePickupFee{
+		StudentID:    studentID,
+		ClassDate:    data.Date(time.Date(year, month, d, 0, 0, 0, 0, location)),
+		RuleID:       rule.RuleID,
+		CheckedOutAt: checkedOutAt,
+		MinutesLate:  minutes,
+		AmountCents:  amount,
+	}, nil
+}
+
+// LateFeeLine returns the invoice line billing the fee.
+func LateFeeLine(fee *data.BillableLateFee) *data.InvoiceLine {
+	studentID := fee.StudentID
+	feeID := fee.FeeID
+
+	return &data.InvoiceLine{
+		StudentID: &studentID,
+		LateFeeID: &feeID,
+		Kind:      KindLatePickup,
+		Description: fmt.Sprintf("Late pickup of %s %s on %s, %d minute(s)",
+			fee.FirstName, fee.LastName, time.Time(fee.ClassDate).Format("2006-01-02"), fee.MinutesLate),
+		Quantity:    1,
+		UnitCents:   fee.AmountCents,
+		AmountCents: fee.AmountCents,
+	}
+}
@@ -0,0 +1,76 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func TestLateFee(t *testing.T) {
+	center := time.FixedZone("CST", -6*60*60)
+
+	perMinute := &data.LateFeeRule{RuleID: 1, ClosesAt: "18:00", GraceMinutes: 5, Charge: "per_minute", BlockMinutes: 1, RateCents: 100}
+	perBlock := &data.LateFeeRule{RuleID: 2, ClosesAt: "18:00", Charge: "per_block", BlockMinutes: 15, RateCents: 1000}
+
+	at := func(hour, min, sec int) time.Time {
+		return time.Date(2026, 10, 19, hour, min, sec, 0, center)
+	}
+
+	tests := []struct {
+		name         string
+		rule         *data.LateFeeRule
+		checkedOutAt time.Time
+		wantMinutes  int
+		wantAmount   int64
+	}{
+		{"before closing", perMinute, at(17, 59, 0), 0, 0},
+		{"at closing", perMinute, at(18, 0, 0), 0, 0},
+		{"within the grace period", perMinute, at(18, 5, 0), 0, 0},
+		{"after the grace period charges every minute", perMinute, at(18, 6, 0), 6, 600},
+		{"part minutes don't count", perMinute, at(18, 10, 59), 10, 1000},
+		{"a whole block", perBlock, at(18, 15, 0), 15, 1000},
+		{"a started block", perBlock, at(18, 16, 0), 16, 2000},
+		{"under a minute", perBlock, at(18, 0, 59), 0, 0},
+		{"checked out in another time zone", perMinute, time.Date(2026, 10, 20, 0, 10, 0, 0, time.UTC), 10, 1000},
+		{"before closing in another time zone", perMinute, time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC), 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, err := LateFee(tt.rule, 10, tt.checkedOutAt, center)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantMinutes == 0 {
+				if fee != nil {
+					t.Errorf("charged %d for %d minute(s), want no fee", fee.AmountCents, fee.MinutesLate)
+				}
+				return
+			}
+
+			if fee == nil {
+				t.Fatalf("no fee, want %d for %d minute(s)", tt.wantAmount, tt.wantMinutes)
+			}
+			if fee.MinutesLate != tt.wantMinutes || fee.AmountCents != tt.wantAmount {
+				t.Errorf("charged %d for %d minute(s), want %d for %d minute(s)", fee.AmountCents, fee.MinutesLate, tt.wantAmount, tt.wantMinutes)
+			}
+			if got := time.Time(fee.ClassDate).Format("2006-01-02"); got != "2026-10-19" {
+				t.Errorf("fee is for %s, want 2026-10-19", got)
+			}
+			if fee.StudentID != 10 || fee.RuleID != tt.rule.RuleID {
+				t.Errorf("fee is for student %d under rule %d, want student 10 under rule %d", fee.StudentID, fee.RuleID, tt.rule.RuleID)
+			}
+		})
+	}
+}
+
+func TestLateFeeInvalidClosingTime(t *testing.T) {
+	rule := &data.LateFeeRule{RuleID: 1, ClosesAt: "6pm", Charge: "per_minute", BlockMinutes: 1, RateCents: 100}
+
+	_, err := LateFee(rule, 10, time.Date(2026, 10, 19, 19, 0, 0, 0, time.UTC), time.UTC)
+	if err == nil {
+		t.Error("a rule with an invalid closing time was applied")
+	}
+}
@@ -0,0 +1,94 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// Kinds of invoice lines.
+const (
+	KindTuition      = "tuition"
+	KindRegistration = "registration"
+)
+
+// TuitionLines returns the lines a student's plan adds to the invoice for the period.
+//
+// Weekly plans charge their full rate for every week whose Monday falls within the period and
+// during which the plan is in effect on at least one day, so consecutive periods never charge the
+// same week twice. Monthly plans charge their rate for every calendar month of the period,
+// prorated by day when the plan only covers part of the month.
+func TuitionLines(plan *data.BillablePlan, period Period) []*data.InvoiceLine {
+	lines := []*data.InvoiceLine{}
+
+	starts := day(time.Time(plan.StartsOn))
+	ends := period.End
+	if plan.EndsOn != nil {
+		ends = day(time.Time(*plan.EndsOn))
+	}
+
+	switch plan.Frequency {
+	case "weekly":
+		weeks := 0
+		for monday := firstMonday(period.Start); !monday.After(period.End); monday = monday.AddDate(0, 0, 7) {
+			if overlapDays(monday, monday.AddDate(0, 0, 6), starts, ends) > 0 {
+				weeks++
+			}
+		}
+
+		if weeks > 0 {
+			lines = append(lines, planLine(plan, KindTuition,
+				fmt.Sprintf("%s tuition for %s %s, %d week(s)", plan.PlanName, plan.FirstName, plan.LastName, weeks),
+				weeks, plan.RateCents))
+		}
+
+	case "monthly":
+		for month := monthStart(period.Start); !month.After(period.End); month = month.AddDate(0, 1, 0) {
+			monthEnd := month.AddDate(0, 1, -1)
+			covered := overlapDays(month, monthEnd, maxTime(starts, period.Start), minTime(ends, period.End))
+			if covered == 0 {
+				continue
+			}
+
+			description := fmt.Sprintf("%s tuition for %s %s, %s", plan.PlanName, plan.FirstName, plan.LastName, month.Format("January 2006"))
+			amount := plan.RateCents
+			daysInMonth := overlapDays(month, monthEnd, month, monthEnd)
+
+			if covered < daysInMonth {
+				amount = prorate(plan.RateCents, covered, daysInMonth)
+				description += fmt.Sprintf(" (%d of %d days)", covered, daysInMonth)
+			}
+
+			lines = append(lines, planLine(plan, KindTuition, description, 1, amount))
+		}
+	}
+
+	if plan.RegistrationFeeCents > 0 && !plan.RegistrationBilled && !starts.After(period.End) {
+		lines = append(lines, planLine(plan, KindRegistration,
+			fmt.Sprintf("%s registration fee for %s %s", plan.PlanName, plan.FirstName, plan.LastName),
+			1, plan.RegistrationFeeCents))
+	}
+
+	return lines
+}
+
+func planLine(plan *data.BillablePlan, kind, description string, quantity int, unitCents int64) *data.InvoiceLine {
+	studentID := plan.StudentID
+	studentPlanID := plan.StudentPlanID
+
+	return &data.InvoiceLine{
+		StudentID:     &studentID,
+		StudentPlanID: &studentPlanID,
+		Kind:          kind,
+		Description:   description,
+		Quantity:      quantity,
+		UnitCents:     unitCents,
+		AmountCents:   int64(quantity) * unitCents,
+	}
+}
+
+// prorate returns the share of cents for days out of total days, rounded half up.
+func prorate(cents int64, days, total int) int64 {
+	return (cents*int64(days)*2 + int64(total)) / (int64(total) * 2)
+}
@@ -0,0 +1,214 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func date(year int, month time.Month, d int) data.Date {
+	return data.Date(time.Date(year, month, d, 0, 0, 0, 0, time.UTC))
+}
+
+func datePtr(year int, month time.Month, d int) *data.Date {
+	date := date(year, month, d)
+	return &date
+}
+
+func period(t *testing.T, start, end data.Date) Period {
+	t.Helper()
+
+	p, err := NewPeriod(time.Time(start), time.Time(end))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProrate(t *testing.T) {
+	tests := []struct {
+		name  string
+		cents int64
+		days  int
+		total int
+		want  int64
+	}{
+		{"whole month", 100000, 30, 30, 100000},
+		{"exact share", 3100, 15, 31, 1500},
+		{"rounds down below half", 1000, 1, 3, 333},
+		{"rounds up above half", 1000, 2, 3, 667},
+		{"rounds half up", 5, 1, 2, 3},
+		{"nothing to share", 0, 10, 30, 0},
+		{"no days", 62000, 0, 31, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prorate(tt.cents, tt.days, tt.total)
+			if got != tt.want {
+				t.Errorf("prorate(%d, %d, %d) = %d, want %d", tt.cents, tt.days, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTuitionLines(t *testing.T) {
+	october := MonthOf(time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC))
+
+	monthly := func(startsOn data.Date, endsOn *data.Date) *data.BillablePlan {
+		return &data.BillablePlan{
+			StudentPlan: data.StudentPlan{StudentPlanID: 1, StudentID: 10, PlanName: "Full time", StartsOn: startsOn, EndsOn: endsOn},
+			FirstName:   "Ada",
+			LastName:    "Lovelace",
+			Frequency:   "monthly",
+			RateCents:   62000,
+		}
+	}
+
+	weekly := func(startsOn data.Date, endsOn *data.Date) *data.BillablePlan {
+		plan := monthly(startsOn, endsOn)
+		plan.Frequency = "weekly"
+		plan.RateCents = 15000
+		return plan
+	}
+
+	withRegistration := func(plan *data.BillablePlan, billed bool) *data.BillablePlan {
+		plan.RegistrationFeeCents = 5000
+		plan.RegistrationBilled = billed
+		return plan
+	}
+
+	type line struct {
+		kind     string
+		quantity int
+		amount   int64
+	}
+
+	tests := []struct {
+		name   string
+		plan   *data.BillablePlan
+		period Period
+		want   []line
+	}{
+		{
+			name:   "monthly plan covering the whole month",
+			plan:   monthly(date(2026, 1, 1), nil),
+			period: october,
+			want:   []line{{KindTuition, 1, 62000}},
+		},
+		{
+			name:   "monthly plan starting mid month",
+			plan:   monthly(date(2026, 10, 15), nil),
+			period: october,
+			want:   []line{{KindTuition, 1, 34000}},
+		},
+		{
+			name:   "monthly plan ending mid month",
+			plan:   monthly(date(2026, 1, 1), datePtr(2026, 10, 10)),
+			period: october,
+			want:   []line{{KindTuition, 1, 20000}},
+		},
+		{
+			name:   "monthly plan starting mid month in a leap february",
+			plan:   monthly(date(2028, 2, 15), nil),
+			period: MonthOf(time.Date(2028, 2, 1, 0, 0, 0, 0, time.UTC)),
+			want:   []line{{KindTuition, 1, 32069}},
+		},
+		{
+			name:   "period covering two months",
+			plan:   monthly(date(2026, 10, 15), nil),
+			period: period(t, date(2026, 10, 1), date(2026, 11, 30)),
+			want:   []line{{KindTuition, 1, 34000}, {KindTuition, 1, 62000}},
+		},
+		{
+			name:   "period starting mid month only charges its own days",
+			plan:   monthly(date(2026, 1, 1), nil),
+			period: period(t, date(2026, 10, 15), date(2026, 10, 31)),
+			want:   []line{{KindTuition, 1, 34000}},
+		},
+		{
+			name:   "monthly plan starting after the period",
+			plan:   monthly(date(2026, 11, 1), nil),
+			period: october,
+			want:   []line{},
+		},
+		{
+			name:   "weekly plan covering the whole month",
+			plan:   weekly(date(2026, 1, 1), nil),
+			period: october,
+			want:   []line{{KindTuition, 4, 60000}},
+		},
+		{
+			name:   "weekly plan starting mid week charges that week",
+			plan:   weekly(date(2026, 10, 14), nil),
+			period: october,
+			want:   []line{{KindTuition, 3, 45000}},
+		},
+		{
+			name:   "weekly plan ending before the first monday of the period",
+			plan:   weekly(date(2026, 1, 1), datePtr(2026, 10, 4)),
+			period: october,
+			want:   []line{},
+		},
+		{
+			name:   "registration fee on the first invoice",
+			plan:   withRegistration(monthly(date(2026, 10, 15), nil), false),
+			period: october,
+			want:   []line{{KindTuition, 1, 34000}, {KindRegistration, 1, 5000}},
+		},
+		{
+			name:   "registration fee already billed",
+			plan:   withRegistration(monthly(date(2026, 10, 15), nil), true),
+			period: october,
+			want:   []line{{KindTuition, 1, 34000}},
+		},
+		{
+			name:   "registration fee of a plan starting after the period",
+			plan:   withRegistration(monthly(date(2026, 11, 1), nil), false),
+			period: october,
+			want:   []line{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := TuitionLines(tt.plan, tt.period)
+
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d", len(lines), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				got := lines[i]
+				if got.Kind != want.kind || got.Quantity != want.quantity || got.AmountCents != want.amount {
+					t.Errorf("line %d = %s x%d %d, want %s x%d %d",
+						i, got.Kind, got.Quantity, got.AmountCents, want.kind, want.quantity, want.amount)
+				}
+				if got.StudentID == nil || *got.StudentID != tt.plan.StudentID {
+					t.Errorf("line %d is not for student %d", i, tt.plan.StudentID)
+				}
+			}
+		})
+	}
+}
+
+func TestTuitionLinesConsecutivePeriodsChargeEachWeekOnce(t *testing.T) {
+	plan := &data.BillablePlan{
+		StudentPlan: data.StudentPlan{StudentPlanID: 1, StudentID: 10, StartsOn: date(2026, 1, 1)},
+		Frequency:   "weekly",
+		RateCents:   15000,
+	}
+
+	weeks := 0
+	for month := time.October; month <= time.December; month++ {
+		for _, line := range TuitionLines(plan, MonthOf(time.Date(2026, month, 1, 0, 0, 0, 0, time.UTC))) {
+			weeks += line.Quantity
+		}
+	}
+
+	// Mondays from October 5 to December 28, 2026.
+	if weeks != 13 {
+		t.Errorf("charged %d weeks over the quarter, want 13", weeks)
+	}
+}
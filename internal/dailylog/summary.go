@@ -0,0 +1,141 @@
+// Package dailylog turns the entries on a student's daily sheet into the end of day summary
+// guardians get at pickup.
+package dailylog
+
+import (
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// Kinds of entries, and the values their details and amounts take.
+var (
+	Kinds   = []string{"meal", "nap", "diaper", "toilet", "mood", "note"}
+	Amounts = []string{"none", "some", "most", "all"}
+	// Results are what a diaper change or toilet visit found.
+	Results = []string{"wet", "bm", "wet_bm", "dry"}
+	Moods   = []string{"happy", "content", "tired", "fussy", "sad", "unwell"}
+)
+
+type Meal struct {
+	At      time.Time `json:"at"`
+	Details string    `json:"details"`
+	Amount  string    `json:"amount"`
+	Notes   string    `json:"notes,omitempty"`
+}
+
+// Nap is a nap the student had, or is still having if EndedAt is nil.
+type Nap struct {
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Minutes   int        `json:"minutes"`
+}
+
+// Eliminations counts diaper changes or toilet visits by what they found. Wet and BM include
+// changes that were both.
+type Eliminations struct {
+	Total int        `json:"total"`
+	Wet   int        `json:"wet"`
+	BM    int        `json:"bm"`
+	Dry   int        `json:"dry"`
+	Last  *time.Time `json:"last,omitempty"`
+}
+
+type Mood struct {
+	At   time.Time `json:"at"`
+	Mood string    `json:"mood"`
+}
+
+type Note struct {
+	At   time.Time `json:"at"`
+	Note string    `json:"note"`
+}
+
+// Summary is a student's day.
+type Summary struct {
+	StudentID  int64        `json:"student_id"`
+	FirstName  string       `json:"first_name"`
+	LastName   string       `json:"last_name"`
+	Date       data.Date    `json:"date"`
+	Meals      []Meal       `json:"meals"`
+	Naps       []Nap        `json:"naps"`
+	NapMinutes int          `json:"nap_minutes"`
+	Diapers    Eliminations `json:"diapers"`
+	Toilet     Eliminations `json:"toilet"`
+	Moods      []Mood       `json:"moods"`
+	Notes      []Note       `json:"notes"`
+}
+
+// Summarize sums up the student's entries, which are expected in time order, on the day. A nap
+// that hasn't ended is counted up to now, or the end of the day if that is earlier.
+func Summarize(student *data.Student, entries []*data.DailyLogEntry, day, now time.Time) *Summary {
+	summary := &Summary{
+		StudentID: student.StudentID,
+		FirstName: student.FirstName,
+		LastName:  student.LastName,
+		Date:      data.Date(day),
+		Meals:     []Meal{},
+		Naps:      []Nap{},
+		Moods:     []Mood{},
+		Notes:     []Note{},
+	}
+
+	for _, entry := range entries {
+		if entry.StudentID != student.StudentID {
+			continue
+		}
+
+		switch entry.Kind {
+		case "meal":
+			meal := Meal{At: entry.OccurredAt, Details: entry.Details, Notes: entry.Notes}
+			if entry.Amount != nil {
+				meal.Amount = *entry.Amount
+			}
+			summary.Meals = append(summary.Meals, meal)
+		case "nap":
+			year, month, date := day.Date()
+			end := time.Date(year, month, date+1, 0, 0, 0, 0, day.Location())
+			if now.Before(end) {
+				end = now
+			}
+			if entry.EndedAt != nil {
+				end = *entry.EndedAt
+			}
+			nap := Nap{StartedAt: entry.OccurredAt, EndedAt: entry.EndedAt, Minutes: max(int(end.Sub(entry.OccurredAt).Minutes()), 0)}
+			summary.Naps = append(summary.Naps, nap)
+			summary.NapMinutes += nap.Minutes
+		case "diaper":
+			summary.Diapers.add(entry)
+		case "toilet":
+			summary.Toilet.add(entry)
+		case "mood":
+			summary.Moods = append(summary.Moods, Mood{At: entry.OccurredAt, Mood: entry.Details})
+		}
+
+		if entry.Kind == "note" || (entry.Kind != "meal" && entry.Notes != "") {
+			note := entry.Notes
+			if note == "" {
+				note = entry.Details
+			}
+			summary.Notes = append(summary.Notes, Note{At: entry.OccurredAt, Note: note})
+		}
+	}
+
+	return summary
+}
+
+func (e *Eliminations) add(entry *data.DailyLogEntry) {
+	e.Total++
+	switch entry.Details {
+	case "wet":
+		e.Wet++
+	case "bm":
+		e.BM++
+	case "wet_bm":
+		e.Wet++
+		e.BM++
+	case "dry":
+		e.Dry++
+	}
+	e.Last = &entry.OccurredAt
+}
@@ -0,0 +1,196 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type AbsenceAlertModel struct {
+	DB *sql.DB
+}
+
+type AbsenceAlert struct {
+	AlertID    int64      `json:"alert_id"`
+	StudentID  int64      `json:"student_id"`
+	FirstName  string     `json:"first_name"`
+	LastName   string     `json:"last_name"`
+	ClassID    int64      `json:"class_id"`
+	ClassName  string     `json:"class_name"`
+	FacultyID  int64      `json:"faculty_id"`
+	Absences   int        `json:"absences"`
+	Sessions   int        `json:"sessions"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Scan looks at the last window sessions of every class and opens an alert for each enrolled
+// student who was absent (and not excused) from at least threshold of them. A session is any
+// date on which attendance was taken for the class. Sessions before a student's first
+// attendance record in the class are ignored so newly enrolled children aren't flagged, and so
+// are sessions on or before the day the student's last alert for the class was resolved, so a
+// resolved alert isn't reopened for the same absences. Students who already have an open alert
+// for the class are skipped. The newly opened alerts are returned.
+func (m AbsenceAlertModel) Scan(window, threshold int) ([]*AbsenceAlert, error) {
+	query := `
+		WITH sessions AS (
+			SELECT class_id, class_date, row_number() OVER (PARTITION BY class_id ORDER BY class_date DESC) AS n
+			FROM (SELECT DISTINCT class_id, class_date FROM student_attendance) d
+		), tally AS (
+			SELECT cs.student_id, cs.class_id,
+			count(*) FILTER (WHERE sa.present IS NOT TRUE AND sa.excused IS NOT TRUE) AS absences,
+			count(*) AS sessions
+			FROM class_students cs
+			INNER JOIN sessions se ON se.class_id = cs.class_id AND se.n <= $1
+			LEFT JOIN student_attendance sa ON sa.student_id = cs.student_id
+				AND sa.class_id = se.class_id AND sa.class_date = se.class_date
+			WHERE se.class_date >= (
+				SELECT min(class_date) FROM student_attendance
+				WHERE student_id = cs.student_id AND class_id = cs.class_id
+			)
+			AND se.class_date > COALESCE((
+				SELECT max(resolved_at)::date FROM absence_alerts
+				WHERE student_id = cs.student_id AND class_id = cs.class_id
+			), '-infinity'::date)
+			GROUP BY cs.student_id, cs.class_id
+		), inserted AS (
+			INSERT INTO absence_alerts (student_id, class_id, absences, sessions)
+			SELECT student_id, class_id, absences, sessions
+			FROM tally
+			WHERE absences >= $2
+			ON CONFLICT (student_id, class_id) WHERE resolved_at IS NULL DO NOTHING
+			RETURNING alert_id, student_id, class_id, absences, sessions, created_at
+		)
+		SELECT i.alert_id, i.student_id, s.first_name, s.last_name, i.class_id, c.class_name,
+		COALESCE(` + currentLead + `, 0), i.absences, i.sessions, i.created_at
+		FROM inserted i
+		INNER JOIN students s ON i.student_id = s.student_id
+		INNER JOIN classes c ON i.class_id = c.class_id
+		ORDER BY i.alert_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, window, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []*AbsenceAlert{}
+	for rows.Next() {
+		var alert AbsenceAlert
+		err := rows.Scan(
+			&alert.AlertID,
+			&alert.StudentID,
+			&alert.FirstName,
+			&alert.LastName,
+			&alert.ClassID,
+			&alert.ClassName,
+			&alert.FacultyID,
+			&alert.Absences,
+			&alert.Sessions,
+			&alert.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, &alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// GetAll returns absence alerts filtered by status, which is one of "open", "resolved" or "all".
+func (m AbsenceAlertModel) GetAll(status string, classID int64, filters Filters) ([]*AbsenceAlert, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), a.alert_id, a.student_id, s.first_name, s.last_name, a.class_id, c.class_name,
+		COALESCE(`+currentLead+`, 0), a.absences, a.sessions, a.created_at, a.resolved_at
+		FROM absence_alerts a
+		INNER JOIN students s ON a.student_id = s.student_id
+		INNER JOIN classes c ON a.class_id = c.class_id
+		WHERE ($1 = 'all' OR ($1 = 'open' AND a.resolved_at IS NULL) OR ($1 = 'resolved' AND a.resolved_at IS NOT NULL))
+		AND (a.class_id = $2 OR $2 = 0)
+		ORDER BY %s %s, a.alert_id ASC
+		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, status, classID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	alerts := []*AbsenceAlert{}
+	totalRecords := 0
+
+	for rows.Next() {
+		var alert AbsenceAlert
+		err := rows.Scan(
+			&totalRecords,
+			&alert.AlertID,
+			&alert.StudentID,
+			&alert.FirstName,
+			&alert.LastName,
+			&alert.ClassID,
+			&alert.ClassName,
+			&alert.FacultyID,
+			&alert.Absences,
+			&alert.Sessions,
+			&alert.CreatedAt,
+			&alert.ResolvedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		alerts = append(alerts, &alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return alerts, metadata, nil
+}
+
+func (m AbsenceAlertModel) Resolve(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		UPDATE absence_alerts
+		SET resolved_at = NOW()
+		WHERE alert_id = $1 AND resolved_at IS NULL
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type AccountAdjustmentModel struct {
+	DB *sql.DB
+}
+
+// AccountAdjustment is a credit given to a family, which lowers what it owes, or a refund of
+// credit paid back to it.
+type AccountAdjustment struct {
+	AdjustmentID int64     `json:"adjustment_id"`
+	FamilyID     int64     `json:"family_id"`
+	Kind         string    `json:"kind"`
+	AmountCents  int64     `json:"amount_cents"`
+	Reason       string    `json:"reason"`
+	RecordedBy   *int64    `json:"recorded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Insert records the adjustment and settles the family's invoices again. A refund can only pay
+// back credit the family has on account, and ErrRefundExceedsCredit is returned otherwise.
+func (m AccountAdjustmentModel) Insert(adjustment *AccountAdjustment) error {
+	return m.insert(adjustment, nil)
+}
+
+// InsertPaidOut records a refund that payOut pays back to the family, for example to their
+// card. payOut is only called once the family is known to have the credit, and the refund is
+// only recorded if it succeeds.
+func (m AccountAdjustmentModel) InsertPaidOut(adjustment *AccountAdjustment, payOut func() error) error {
+	return m.insert(adjustment, payOut)
+}
+
+func (m AccountAdjustmentModel) insert(adjustment *AccountAdjustment, payOut func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockFamily(ctx, tx, adjustment.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	if adjustment.Kind == "refund" {
+		balance, err := familyBalance(ctx, tx, adjustment.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		if adjustment.AmountCents > -balance {
+			return ErrRefundExceedsCredit
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO account_adjustments (guardian_id, kind, amount_cents, reason, recorded_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING adjustment_id, created_at`,
+		adjustment.FamilyID,
+		adjustment.Kind,
+		adjustment.AmountCents,
+		adjustment.Reason,
+		adjustment.RecordedBy,
+	).Scan(&adjustment.AdjustmentID, &adjustment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = settleInvoices(ctx, tx, adjustment.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	if payOut != nil {
+		err = payOut()
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
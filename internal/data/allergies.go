@@ -0,0 +1,225 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type AllergyModel struct {
+	DB *sql.DB
+}
+
+// Allergy is something a student is allergic to, with how bad a reaction is and what to do
+// about one. Allergen is one of the allergens menus are tagged with; Details says what an
+// "other" allergen is, or narrows one down, like "cashews only".
+type Allergy struct {
+	AllergyID int64     `json:"allergy_id"`
+	StudentID int64     `json:"student_id"`
+	Allergen  string    `json:"allergen"`
+	Details   string    `json:"details"`
+	Severity  string    `json:"severity"`
+	Reaction  string    `json:"reaction"`
+	Treatment string    `json:"treatment"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AllergyFlag is the short form of an allergy shown next to a student's name on rosters.
+type AllergyFlag struct {
+	Allergen string `json:"allergen"`
+	Details  string `json:"details,omitempty"`
+	Severity string `json:"severity"`
+}
+
+// bySeverity orders allergies from the most to the least severe.
+const bySeverity = `
+	CASE severity WHEN 'life_threatening' THEN 0 WHEN 'severe' THEN 1 WHEN 'moderate' THEN 2 ELSE 3 END`
+
+func (m AllergyModel) Insert(allergy *Allergy) error {
+	query := `
+		INSERT INTO student_allergies (student_id, allergen, details, severity, reaction, treatment)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING allergy_id, created_at
+		`
+	args := []any{allergy.StudentID, allergy.Allergen, allergy.Details, allergy.Severity, allergy.Reaction, allergy.Treatment}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&allergy.AllergyID, &allergy.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "student_allergies_student_allergen_idx"`:
+			return ErrDuplicateAllergy
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m AllergyModel) Get(studentID, id int64) (*Allergy, error) {
+	if studentID < 1 || id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	allergies, err := m.getAll(`WHERE student_id = $1 AND allergy_id = $2`, studentID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(allergies) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return allergies[0], nil
+}
+
+// GetAllByStudentID returns the student's allergies, the most severe first.
+func (m AllergyModel) GetAllByStudentID(studentID int64) ([]*Allergy, error) {
+	return m.getAll(`WHERE student_id = $1`, studentID)
+}
+
+func (m AllergyModel) getAll(where string, args ...any) ([]*Allergy, error) {
+	query := `
+		SELECT allergy_id, student_id, allergen, details, severity, reaction, treatment, created_at
+		FROM student_allergies
+		` + where + `
+		ORDER BY ` + bySeverity + `, allergen, details
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allergies := []*Allergy{}
+	for rows.Next() {
+		var allergy Allergy
+		err := rows.Scan(
+			&allergy.AllergyID,
+			&allergy.StudentID,
+			&allergy.Allergen,
+			&allergy.Details,
+			&allergy.Severity,
+			&allergy.Reaction,
+			&allergy.Treatment,
+			&allergy.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		allergies = append(allergies, &allergy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return allergies, nil
+}
+
+// GetFlagsByClassID returns the allergies of every student in the class by student ID, the most
+// severe first.
+func (m AllergyModel) GetFlagsByClassID(classID int64) (map[int64][]*AllergyFlag, error) {
+	query := `
+		SELECT a.student_id, a.allergen, a.details, a.severity
+		FROM student_allergies a
+		INNER JOIN class_students cs ON a.student_id = cs.student_id
+		WHERE cs.class_id = $1
+		ORDER BY a.student_id, ` + bySeverity + `, a.allergen, a.details
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flags := map[int64][]*AllergyFlag{}
+	for rows.Next() {
+		var (
+			studentID int64
+			flag      AllergyFlag
+		)
+		err := rows.Scan(&studentID, &flag.Allergen, &flag.Details, &flag.Severity)
+		if err != nil {
+			return nil, err
+		}
+		flags[studentID] = append(flags[studentID], &flag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return flags, nil
+}
+
+func (m AllergyModel) Update(allergy *Allergy) error {
+	query := `
+		UPDATE student_allergies
+		SET allergen = $1, details = $2, severity = $3, reaction = $4, treatment = $5
+		WHERE allergy_id = $6 AND student_id = $7
+		`
+	args := []any{allergy.Allergen, allergy.Details, allergy.Severity, allergy.Reaction, allergy.Treatment, allergy.AllergyID, allergy.StudentID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "student_allergies_student_allergen_idx"`:
+			return ErrDuplicateAllergy
+		default:
+			return err
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m AllergyModel) Delete(studentID, id int64) error {
+	if studentID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM student_allergies WHERE student_id = $1 AND allergy_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, studentID, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// currentAssignment matches the class_faculty rows in effect today.
+const currentAssignment = `starts_on <= CURRENT_DATE AND (ends_on IS NULL OR ends_on >= CURRENT_DATE)`
+
+// currentLead selects the faculty member leading class c today, the latest to start if there are
+// several, or NULL if there is none.
+const currentLead = `(
+	SELECT faculty_id FROM class_faculty
+	WHERE class_id = c.class_id AND role = 'lead' AND ` + currentAssignment + `
+	ORDER BY starts_on DESC, assignment_id DESC
+	LIMIT 1)`
+
+type ClassFacultyModel struct {
+	DB *sql.DB
+}
+
+// ClassFaculty assigns a faculty member to a class as its lead, an assistant or a substitute
+// from StartsOn until EndsOn, or indefinitely if EndsOn is nil.
+type ClassFaculty struct {
+	AssignmentID int64  `json:"assignment_id"`
+	ClassID      int64  `json:"class_id"`
+	FacultyID    int64  `json:"faculty_id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Role         string `json:"role"`
+	StartsOn     Date   `json:"starts_on"`
+	EndsOn       *Date  `json:"ends_on,omitempty"`
+}
+
+// Insert assigns the faculty member to the class. A lead who starts today or earlier also
+// becomes the class's faculty member, later ones are promoted by SyncLeads when they start.
+func (m ClassFacultyModel) Insert(assignment *ClassFaculty) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current bool
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO class_faculty (class_id, faculty_id, role, starts_on, ends_on)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING assignment_id, `+currentAssignment,
+		assignment.ClassID, assignment.FacultyID, assignment.Role, time.Time(assignment.StartsOn), nullDate(assignment.EndsOn),
+	).Scan(&assignment.AssignmentID, &current)
+	if err != nil {
+		return err
+	}
+
+	if assignment.Role == "lead" && current {
+		_, err = tx.ExecContext(ctx, `UPDATE classes SET faculty_id = $1 WHERE class_id = $2`, assignment.FacultyID, assignment.ClassID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetAllByClassID returns the staff assigned to the class, only those in effect today if
+// currentOnly is set.
+func (m ClassFacultyModel) GetAllByClassID(classID int64, currentOnly bool) ([]*ClassFaculty, error) {
+	query := `
+		SELECT cf.assignment_id, cf.class_id, cf.faculty_id, f.first_name, f.last_name, cf.role, cf.starts_on, cf.ends_on
+		FROM class_faculty cf
+		INNER JOIN faculty f ON cf.faculty_id = f.faculty_id
+		WHERE cf.class_id = $1
+		AND (NOT $2 OR (cf.starts_on <= CURRENT_DATE AND (cf.ends_on IS NULL OR cf.ends_on >= CURRENT_DATE)))
+		ORDER BY CASE cf.role WHEN 'lead' THEN 0 WHEN 'assistant' THEN 1 ELSE 2 END, cf.starts_on, cf.assignment_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, classID, currentOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []*ClassFaculty{}
+	for rows.Next() {
+		var (
+			assignment ClassFaculty
+			startsOn   time.Time
+			endsOn     sql.NullTime
+		)
+
+		err := rows.Scan(
+			&assignment.AssignmentID,
+			&assignment.ClassID,
+			&assignment.FacultyID,
+			&assignment.FirstName,
+			&assignment.LastName,
+			&assignment.Role,
+			&startsOn,
+			&endsOn,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		assignment.StartsOn = Date(startsOn)
+		assignment.EndsOn = dateOrNil(endsOn)
+		assignments = append(assignments, &assignment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// Delete removes the assignment from the class. The class's only current lead can't be removed,
+// a new lead has to be assigned first. Removing one of several current leads hands the class
+// over to another of them.
+func (m ClassFacultyModel) Delete(classID, assignmentID int64) error {
+	if classID < 1 || assignmentID < 1 {
+		return ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the class so two leads can't be removed at the same time.
+	var facultyID int64
+	err = tx.QueryRowContext(ctx, `SELECT faculty_id FROM classes WHERE class_id = $1 FOR UPDATE`, classID).Scan(&facultyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	var role string
+	var current bool
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM class_faculty
+		WHERE class_id = $1 AND assignment_id = $2
+		RETURNING role, `+currentAssignment, classID, assignmentID).Scan(&role, &current)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	if role == "lead" && current {
+		var lead int64
+		err = tx.QueryRowContext(ctx, `
+			SELECT faculty_id
+			FROM class_faculty
+			WHERE class_id = $1 AND role = 'lead' AND `+currentAssignment+`
+			ORDER BY faculty_id = $2 DESC, starts_on DESC, assignment_id DESC
+			LIMIT 1`, classID, facultyID).Scan(&lead)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrLastLeadTeacher
+			default:
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE classes SET faculty_id = $1 WHERE class_id = $2`, lead, classID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SyncLeads points every class at its current lead, promoting leads whose assignment has started
+// and replacing those whose assignment has ended. Classes without a current lead are left as
+// they are. It returns how many classes changed.
+func (m ClassFacultyModel) SyncLeads() (int64, error) {
+	query := `
+		UPDATE classes c
+		SET faculty_id = ` + currentLead + `
+		WHERE ` + currentLead + ` IS NOT NULL
+		AND c.faculty_id IS DISTINCT FROM ` + currentLead
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// IsAssigned reports whether the faculty member is currently assigned to the class in any role.
+func (m ClassFacultyModel) IsAssigned(facultyID, classID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM class_faculty
+			WHERE faculty_id = $1 AND class_id = $2 AND ` + currentAssignment + `
+		)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var assigned bool
+	err := m.DB.QueryRowContext(ctx, query, facultyID, classID).Scan(&assigned)
+	if err != nil {
+		return false, err
+	}
+
+	return assigned, nil
+}
+
+// IsAssignedToStudent reports whether the faculty member is currently assigned to any class the
+// student is enrolled in.
+func (m ClassFacultyModel) IsAssignedToStudent(facultyID, studentID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM class_faculty
+			WHERE faculty_id = $1 AND ` + currentAssignment + `
+				AND class_id IN (SELECT class_id FROM class_students WHERE student_id = $2)
+		)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var assigned bool
+	err := m.DB.QueryRowContext(ctx, query, facultyID, studentID).Scan(&assigned)
+	if err != nil {
+		return false, err
+	}
+
+	return assigned, nil
+}
@@ -0,0 +1,209 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+)
+
+type ClassSessionModel struct {
+	DB *sql.DB
+}
+
+// ClassSession is a weekly recurring session of a class. Weekday follows time.Weekday, and the
+// start and end are "15:04" times of day in the server's time zone.
+type ClassSession struct {
+	SessionID int64  `json:"session_id"`
+	ClassID   int64  `json:"class_id"`
+	Weekday   int    `json:"weekday"`
+	StartsAt  string `json:"starts_at"`
+	EndsAt    string `json:"ends_at"`
+}
+
+// SessionOccurrence is a class session on a particular day.
+type SessionOccurrence struct {
+	SessionID int64     `json:"session_id"`
+	ClassID   int64     `json:"class_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+// SessionStaffing tells whether enough staff are scheduled throughout a session occurrence.
+type SessionStaffing struct {
+	SessionOccurrence
+	EnrolledStudents int  `json:"enrolled_students"`
+	RequiredStaff    int  `json:"required_staff"`
+	ScheduledStaff   int  `json:"scheduled_staff"`
+	Sufficient       bool `json:"sufficient"`
+}
+
+func (m ClassSessionModel) Insert(session *ClassSession) error {
+	query := `
+		INSERT INTO class_sessions (class_id, weekday, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING session_id
+		`
+	args := []any{session.ClassID, session.Weekday, session.StartsAt, session.EndsAt}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&session.SessionID)
+}
+
+func (m ClassSessionModel) GetAllByClassID(classID int64) ([]*ClassSession, error) {
+	query := `
+		SELECT session_id, class_id, weekday, to_char(starts_at, 'HH24:MI'), to_char(ends_at, 'HH24:MI')
+		FROM class_sessions
+		WHERE class_id = $1
+		ORDER BY weekday, starts_at, session_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*ClassSession{}
+	for rows.Next() {
+		var session ClassSession
+		err := rows.Scan(
+			&session.SessionID,
+			&session.ClassID,
+			&session.Weekday,
+			&session.StartsAt,
+			&session.EndsAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (m ClassSessionModel) Delete(classID, sessionID int64) error {
+	if classID < 1 || sessionID < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM class_sessions WHERE class_id = $1 AND session_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, classID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Occurrences returns the sessions taking place in the week starting at weekStart.
+func Occurrences(sessions []*ClassSession, weekStart time.Time) []*SessionOccurrence {
+	occurrences := []*SessionOccurrence{}
+
+	for i := 0; i < 7; i++ {
+		day := weekStart.AddDate(0, 0, i)
+
+		for _, session := range sessions {
+			if int(day.Weekday()) != session.Weekday {
+				continue
+			}
+
+			startsAt, err1 := time.Parse("15:04", session.StartsAt)
+			endsAt, err2 := time.Parse("15:04", session.EndsAt)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+
+			occurrences = append(occurrences, &SessionOccurrence{
+				SessionID: session.SessionID,
+				ClassID:   session.ClassID,
+				StartsAt:  time.Date(day.Year(), day.Month(), day.Day(), startsAt.Hour(), startsAt.Minute(), 0, 0, day.Location()),
+				EndsAt:    time.Date(day.Year(), day.Month(), day.Day(), endsAt.Hour(), endsAt.Minute(), 0, 0, day.Location()),
+			})
+		}
+	}
+
+	return occurrences
+}
+
+// CheckStaffing works out, for every occurrence, the smallest number of staff on shift at any
+// moment of it, and whether that is enough for the enrolled students at childrenPerStaff
+// children per member of staff.
+func CheckStaffing(occurrences []*SessionOccurrence, shifts []*Shift, enrolled, childrenPerStaff int) []*SessionStaffing {
+	required := 0
+	if enrolled > 0 {
+		required = int(math.Ceil(float64(enrolled) / float64(childrenPerStaff)))
+	}
+
+	staffing := []*SessionStaffing{}
+
+	for _, occurrence := range occurrences {
+		scheduled := minimumCoverage(occurrence.StartsAt, occurrence.EndsAt, shifts)
+
+		staffing = append(staffing, &SessionStaffing{
+			SessionOccurrence: *occurrence,
+			EnrolledStudents:  enrolled,
+			RequiredStaff:     required,
+			ScheduledStaff:    scheduled,
+			Sufficient:        scheduled >= required,
+		})
+	}
+
+	return staffing
+}
+
+// minimumCoverage returns the smallest number of shifts covering any moment between start and end.
+func minimumCoverage(start, end time.Time, shifts []*Shift) int {
+	// the coverage can only change where a shift starts or ends
+	points := []time.Time{start}
+	for _, shift := range shifts {
+		if shift.StartsAt.After(start) && shift.StartsAt.Before(end) {
+			points = append(points, shift.StartsAt)
+		}
+		if shift.EndsAt.After(start) && shift.EndsAt.Before(end) {
+			points = append(points, shift.EndsAt)
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].Before(points[j]) })
+
+	minimum := -1
+	for _, point := range points {
+		covering := 0
+		for _, shift := range shifts {
+			if !shift.StartsAt.After(point) && shift.EndsAt.After(point) {
+				covering++
+			}
+		}
+
+		if minimum == -1 || covering < minimum {
+			minimum = covering
+		}
+	}
+
+	return minimum
+}
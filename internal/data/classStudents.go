@@ -7,7 +7,8 @@ import (
 )
 
 type ClassStudentsModel struct {
-	DB *sql.DB
+	DB     *sql.DB
+	Events EventPublisher
 }
 
 type ClassStudents struct {
@@ -26,7 +27,13 @@ func (m ClassStudentsModel) Insert(classStudent *ClassStudents) error {
 	defer cancel()
 
 	_, err := m.DB.ExecContext(ctx, query, args...)
-	return err
+	if err != nil {
+		return err
+	}
+
+	publish(m.Events, classStudent.ClassID, "enrollment.added", classStudent)
+
+	return nil
 }
 
 func (m ClassStudentsModel) Delete(classID, studentID int64) error {
@@ -53,6 +60,8 @@ func (m ClassStudentsModel) Delete(classID, studentID int64) error {
 		return ErrRecordNotFound
 	}
 
+	publish(m.Events, classID, "enrollment.removed", &ClassStudents{ClassID: classID, StudentID: studentID})
+
 	return nil
 }
 
@@ -102,18 +111,19 @@ func (m ClassStudentsModel) GetStudentsByClassID(classID int64) ([]*Student, err
 }
 
 type StudentWithGuardian struct {
-	StudentID         int64  `json:"student_id"`
-	FirstName         string `json:"first_name"`
-	LastName          string `json:"last_name"`
-	Gender            string `json:"gender"`
-	DateOfBirth       Date   `json:"date_of_birth"`
-	GuardianFirstName string `json:"guardian_first_name"`
-	GuardianLastName  string `json:"guardian_last_name"`
-	GuardianContact   string `json:"guardian_contact"`
-	GuardianID        int64  `json:"guardian_id"`
-	GuardianGender    string `json:"guardian_gender"`
-	GuardianRel       string `json:"guardian_rel"`
-	GuardianOcc       string `json:"guardian_occ"`
+	StudentID         int64          `json:"student_id"`
+	FirstName         string         `json:"first_name"`
+	LastName          string         `json:"last_name"`
+	Gender            string         `json:"gender"`
+	DateOfBirth       Date           `json:"date_of_birth"`
+	GuardianFirstName string         `json:"guardian_first_name"`
+	GuardianLastName  string         `json:"guardian_last_name"`
+	GuardianContact   string         `json:"guardian_contact"`
+	GuardianID        int64          `json:"guardian_id"`
+	GuardianGender    string         `json:"guardian_gender"`
+	GuardianRel       string         `json:"guardian_rel"`
+	GuardianOcc       string         `json:"guardian_occ"`
+	Allergies         []*AllergyFlag `json:"allergies"`
 }
 
 func (m ClassStudentsModel) GetStudentsByClassIDWithGuardian(classID int64) ([]*StudentWithGuardian, error) {
@@ -167,16 +177,48 @@ func (m ClassStudentsModel) GetStudentsByClassIDWithGuardian(classID int64) ([]*
 		return nil, err
 	}
 
+	allergies, err := AllergyModel{DB: m.DB}.GetFlagsByClassID(classID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, student := range students {
+		student.Allergies = allergies[student.StudentID]
+		if student.Allergies == nil {
+			student.Allergies = []*AllergyFlag{}
+		}
+	}
+
 	return students, nil
 }
 
+func (m ClassStudentsModel) NumberOfStudentsByClassID(classID int64) (int, error) {
+	query := `
+		SELECT count(*)
+		FROM class_students
+		WHERE class_id = $1
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int
+	err := m.DB.QueryRowContext(ctx, query, classID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
 func (m ClassStudentsModel) NumberOfStudentsByFacultyID(facultyID int64) (int, error) {
 	query := `
 		SELECT count(DISTINCT s.student_id)
 		FROM students s
 		INNER JOIN class_students cs ON s.student_id = cs.student_id
-		INNER JOIN classes c ON cs.class_id = c.class_id
-		WHERE c.faculty_id = $1
+		INNER JOIN class_faculty cf ON cs.class_id = cf.class_id
+		WHERE cf.faculty_id = $1
+		AND cf.starts_on <= CURRENT_DATE AND (cf.ends_on IS NULL OR cf.ends_on >= CURRENT_DATE)
 		`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -19,6 +19,7 @@ type Class struct {
 	Schedule  string `json:"schedule"`
 }
 
+// Insert creates the class and assigns its faculty member to it as the lead.
 func (m ClassModel) Insert(class *Class) error {
 	query := `
 		INSERT INTO classes (faculty_id, class_name, term, schedule) 
@@ -30,7 +31,25 @@ func (m ClassModel) Insert(class *Class) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&class.ClassID)
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&class.ClassID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO class_faculty (class_id, faculty_id, role)
+		VALUES ($1, $2, 'lead')`, class.ClassID, class.FacultyID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (m ClassModel) Get(id int64) (*Class, error) {
@@ -159,10 +178,15 @@ func (m ClassModel) GetAll(name string, filters Filters) ([]*Class, Metadata, er
 	return classes, metaData, nil
 }
 
+// GetAllByFacultyID returns the classes the faculty member is currently assigned to, in any role.
 func (m ClassModel) GetAllByFacultyID(faculty_id int64) ([]*Class, error) {
 	query := `SELECT class_id, class_name, term, schedule
 			FROM classes
-			WHERE faculty_id = $1
+			WHERE class_id IN (
+				SELECT class_id
+				FROM class_faculty
+				WHERE faculty_id = $1 AND ` + currentAssignment + `
+			)
 			ORDER BY class_id ASC`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -197,9 +221,9 @@ func (m ClassModel) GetAllByFacultyID(faculty_id int64) ([]*Class, error) {
 }
 
 func (m ClassModel) NumberOfFacultyClasses(faculty_id int64) (int, error) {
-	query := `SELECT count(*)
-			FROM classes
-			WHERE faculty_id = $1`
+	query := `SELECT count(DISTINCT class_id)
+			FROM class_faculty
+			WHERE faculty_id = $1 AND ` + currentAssignment
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -0,0 +1,240 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type CoverageGrantModel struct {
+	DB *sql.DB
+}
+
+// CoverageGrant gives a substitute temporary access to a class they aren't assigned to, between
+// StartsAt and EndsAt, once an admin has approved it.
+type CoverageGrant struct {
+	GrantID     int64      `json:"grant_id"`
+	ClassID     int64      `json:"class_id"`
+	FacultyID   int64      `json:"faculty_id"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      time.Time  `json:"ends_at"`
+	Reason      string     `json:"reason"`
+	Status      string     `json:"status"`
+	RequestedBy *int64     `json:"requested_by"`
+	DecidedBy   *int64     `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CoverageAudit is a request made by a substitute under a coverage grant.
+type CoverageAudit struct {
+	AuditID   int64     `json:"audit_id"`
+	GrantID   int64     `json:"grant_id"`
+	FacultyID *int64    `json:"faculty_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (m CoverageGrantModel) Insert(grant *CoverageGrant) error {
+	query := `
+		INSERT INTO coverage_grants (class_id, faculty_id, starts_at, ends_at, reason, requested_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING grant_id, status, created_at
+		`
+	args := []any{grant.ClassID, grant.FacultyID, grant.StartsAt, grant.EndsAt, grant.Reason, grant.RequestedBy}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&grant.GrantID, &grant.Status, &grant.CreatedAt)
+}
+
+func (m CoverageGrantModel) Get(id int64) (*CoverageGrant, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	grants, err := m.getAll(`WHERE grant_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(grants) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return grants[0], nil
+}
+
+// GetAll returns the grants with the given status, or every status if it is empty. A facultyID
+// other than 0 only returns the grants the faculty member requested or is the substitute on.
+func (m CoverageGrantModel) GetAll(status string, classID, facultyID int64) ([]*CoverageGrant, error) {
+	return m.getAll(`
+		WHERE (status = $1 OR $1 = '')
+		AND (class_id = $2 OR $2 = 0)
+		AND (faculty_id = $3 OR requested_by = $3 OR $3 = 0)`, status, classID, facultyID)
+}
+
+// GetActive returns the approved grant giving the faculty member access to the class at the given time.
+func (m CoverageGrantModel) GetActive(facultyID, classID int64, at time.Time) (*CoverageGrant, error) {
+	grants, err := m.getAll(`
+		WHERE faculty_id = $1 AND class_id = $2 AND status = 'approved'
+		AND starts_at <= $3 AND ends_at > $3`, facultyID, classID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(grants) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return grants[0], nil
+}
+
+// GetActiveForStudent returns the approved grant giving the faculty member access to a class the
+// student is enrolled in at the given time.
+func (m CoverageGrantModel) GetActiveForStudent(facultyID, studentID int64, at time.Time) (*CoverageGrant, error) {
+	grants, err := m.getAll(`
+		WHERE faculty_id = $1 AND class_id IN (SELECT class_id FROM class_students WHERE student_id = $2)
+		AND status = 'approved' AND starts_at <= $3 AND ends_at > $3`, facultyID, studentID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(grants) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return grants[0], nil
+}
+
+func (m CoverageGrantModel) getAll(where string, args ...any) ([]*CoverageGrant, error) {
+	query := `
+		SELECT grant_id, class_id, faculty_id, starts_at, ends_at, reason, status, requested_by, decided_by, decided_at, created_at
+		FROM coverage_grants
+		` + where + `
+		ORDER BY starts_at DESC, grant_id DESC
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []*CoverageGrant{}
+	for rows.Next() {
+		var grant CoverageGrant
+		err := rows.Scan(
+			&grant.GrantID,
+			&grant.ClassID,
+			&grant.FacultyID,
+			&grant.StartsAt,
+			&grant.EndsAt,
+			&grant.Reason,
+			&grant.Status,
+			&grant.RequestedBy,
+			&grant.DecidedBy,
+			&grant.DecidedAt,
+			&grant.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, &grant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
+
+// Decide approves or rejects a pending grant. Grants that were already decided return
+// ErrGrantNotPending.
+func (m CoverageGrantModel) Decide(grant *CoverageGrant, status string, decidedBy int64) error {
+	query := `
+		UPDATE coverage_grants
+		SET status = $1, decided_by = $2, decided_at = NOW()
+		WHERE grant_id = $3 AND status = 'pending'
+		RETURNING status, decided_by, decided_at
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, status, decidedBy, grant.GrantID).Scan(&grant.Status, &grant.DecidedBy, &grant.DecidedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrGrantNotPending
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m CoverageGrantModel) InsertAudit(audit *CoverageAudit) error {
+	query := `
+		INSERT INTO coverage_audit (grant_id, faculty_id, method, path, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING audit_id, created_at
+		`
+	args := []any{audit.GrantID, audit.FacultyID, audit.Method, audit.Path, audit.Status}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&audit.AuditID, &audit.CreatedAt)
+}
+
+func (m CoverageGrantModel) GetAudit(grantID int64) ([]*CoverageAudit, error) {
+	query := `
+		SELECT audit_id, grant_id, faculty_id, method, path, status, created_at
+		FROM coverage_audit
+		WHERE grant_id = $1
+		ORDER BY created_at, audit_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, grantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*CoverageAudit{}
+	for rows.Next() {
+		var audit CoverageAudit
+		err := rows.Scan(
+			&audit.AuditID,
+			&audit.GrantID,
+			&audit.FacultyID,
+			&audit.Method,
+			&audit.Path,
+			&audit.Status,
+			&audit.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &audit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,218 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type DailyLogModel struct {
+	DB *sql.DB
+}
+
+// DailyLogEntry is something recorded on a student's daily sheet. Meals have the amount eaten,
+// naps end at EndedAt once the student wakes up, and Details holds what was eaten, what the
+// diaper or toilet visit was or the student's mood.
+type DailyLogEntry struct {
+	EntryID    int64      `json:"entry_id"`
+	StudentID  int64      `json:"student_id"`
+	Kind       string     `json:"kind"`
+	OccurredAt time.Time  `json:"occurred_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	Amount     *string    `json:"amount,omitempty"`
+	Details    string     `json:"details"`
+	Notes      string     `json:"notes"`
+	RecordedBy *int64     `json:"recorded_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// insertDailyLogEntry saves an entry, taking the args returned by dailyLogEntryArgs.
+const insertDailyLogEntry = `
+	INSERT INTO daily_log_entries (student_id, kind, occurred_at, ended_at, amount, details, notes, recorded_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING entry_id, created_at`
+
+func dailyLogEntryArgs(entry *DailyLogEntry) []any {
+	return []any{
+		entry.StudentID,
+		entry.Kind,
+		entry.OccurredAt,
+		entry.EndedAt,
+		entry.Amount,
+		entry.Details,
+		entry.Notes,
+		entry.RecordedBy,
+	}
+}
+
+func (m DailyLogModel) Insert(entry *DailyLogEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, insertDailyLogEntry, dailyLogEntryArgs(entry)...).Scan(&entry.EntryID, &entry.CreatedAt)
+}
+
+// InsertMany saves entries for several students, such as a whole class eating lunch, in one
+// transaction.
+func (m DailyLogModel) InsertMany(entries []*DailyLogEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		err = tx.QueryRowContext(ctx, insertDailyLogEntry, dailyLogEntryArgs(entry)...).Scan(&entry.EntryID, &entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m DailyLogModel) Get(studentID, id int64) (*DailyLogEntry, error) {
+	if studentID < 1 || id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	entries, err := m.getAll(`WHERE student_id = $1 AND entry_id = $2`, studentID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return entries[0], nil
+}
+
+// GetAllByStudentID returns the student's entries on the day.
+func (m DailyLogModel) GetAllByStudentID(studentID int64, day time.Time) ([]*DailyLogEntry, error) {
+	day = dateOf(day)
+	return m.getAll(`WHERE student_id = $1 AND occurred_at >= $2 AND occurred_at < $3`,
+		studentID, day, day.AddDate(0, 0, 1))
+}
+
+// GetAllByClassID returns the entries of the students in the class on the day.
+func (m DailyLogModel) GetAllByClassID(classID int64, day time.Time) ([]*DailyLogEntry, error) {
+	day = dateOf(day)
+	return m.getAll(`
+		WHERE student_id IN (SELECT student_id FROM class_students WHERE class_id = $1)
+		AND occurred_at >= $2 AND occurred_at < $3`, classID, day, day.AddDate(0, 0, 1))
+}
+
+func (m DailyLogModel) getAll(where string, args ...any) ([]*DailyLogEntry, error) {
+	query := `
+		SELECT entry_id, student_id, kind, occurred_at, ended_at, amount, details, notes, recorded_by, created_at
+		FROM daily_log_entries
+		` + where + `
+		ORDER BY occurred_at, entry_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*DailyLogEntry{}
+	for rows.Next() {
+		var (
+			entry   DailyLogEntry
+			endedAt sql.NullTime
+		)
+		err := rows.Scan(
+			&entry.EntryID,
+			&entry.StudentID,
+			&entry.Kind,
+			&entry.OccurredAt,
+			&endedAt,
+			&entry.Amount,
+			&entry.Details,
+			&entry.Notes,
+			&entry.RecordedBy,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if endedAt.Valid {
+			entry.EndedAt = &endedAt.Time
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (m DailyLogModel) Update(entry *DailyLogEntry) error {
+	query := `
+		UPDATE daily_log_entries
+		SET occurred_at = $1, ended_at = $2, amount = $3, details = $4, notes = $5
+		WHERE entry_id = $6
+		RETURNING entry_id
+		`
+	args := []any{
+		entry.OccurredAt,
+		entry.EndedAt,
+		entry.Amount,
+		entry.Details,
+		entry.Notes,
+		entry.EntryID,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&entry.EntryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m DailyLogModel) Delete(studentID, id int64) error {
+	if studentID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM daily_log_entries WHERE student_id = $1 AND entry_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, studentID, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
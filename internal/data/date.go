@@ -1,6 +1,7 @@
 package data
 
 import (
+	"database/sql"
 	"errors"
 	"strconv"
 	"time"
@@ -39,3 +40,25 @@ func (d *Date) UnmarshalJSON(jsonValue []byte) error {
 
 	return nil
 }
+
+// dateOf returns midnight at the start of t's day, in t's location.
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// nullDate converts an optional date into a value that can be passed as a query argument.
+func nullDate(d *Date) sql.NullTime {
+	if d == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: time.Time(*d), Valid: true}
+}
+
+func dateOrNil(t sql.NullTime) *Date {
+	if !t.Valid {
+		return nil
+	}
+	d := Date(t.Time)
+	return &d
+}
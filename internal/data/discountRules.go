@@ -0,0 +1,137 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type DiscountRuleModel struct {
+	DB *sql.DB
+}
+
+// DiscountRule takes Value percent, or Value cents, off a student's tuition for each billing
+// run. Sibling rules apply to the FromChild-th child of a family and every child after that,
+// staff rules to children of guardians who work at the daycare. Stackable rules add up, a rule
+// that isn't stackable is never combined with another one.
+type DiscountRule struct {
+	RuleID      int64     `json:"rule_id"`
+	Name        string    `json:"name"`
+	AppliesTo   string    `json:"applies_to"`
+	Calculation string    `json:"calculation"`
+	Value       int64     `json:"value"`
+	FromChild   int       `json:"from_child"`
+	Stackable   bool      `json:"stackable"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (m DiscountRuleModel) Insert(rule *DiscountRule) error {
+	query := `
+		INSERT INTO discount_rules (name, applies_to, calculation, value, from_child, stackable, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING rule_id, created_at
+		`
+	args := []any{rule.Name, rule.AppliesTo, rule.Calculation, rule.Value, rule.FromChild, rule.Stackable, rule.Active}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&rule.RuleID, &rule.CreatedAt)
+}
+
+func (m DiscountRuleModel) Get(id int64) (*DiscountRule, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	rules, err := m.getAll(`WHERE rule_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return rules[0], nil
+}
+
+// GetAll returns the discount rules, only the ones still given if activeOnly is set.
+func (m DiscountRuleModel) GetAll(activeOnly bool) ([]*DiscountRule, error) {
+	return m.getAll(`WHERE active OR NOT $1`, activeOnly)
+}
+
+func (m DiscountRuleModel) getAll(where string, args ...any) ([]*DiscountRule, error) {
+	query := `
+		SELECT rule_id, name, applies_to, calculation, value, from_child, stackable, active, created_at
+		FROM discount_rules
+		` + where + `
+		ORDER BY rule_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*DiscountRule{}
+	for rows.Next() {
+		var rule DiscountRule
+		err := rows.Scan(
+			&rule.RuleID,
+			&rule.Name,
+			&rule.AppliesTo,
+			&rule.Calculation,
+			&rule.Value,
+			&rule.FromChild,
+			&rule.Stackable,
+			&rule.Active,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, &rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Update saves the changes to the rule. They apply from the next billing run, invoices that
+// were already issued keep the discounts they were issued with.
+func (m DiscountRuleModel) Update(rule *DiscountRule) error {
+	query := `
+		UPDATE discount_rules
+		SET name = $1, applies_to = $2, calculation = $3, value = $4, from_child = $5, stackable = $6, active = $7
+		WHERE rule_id = $8
+		`
+	args := []any{rule.Name, rule.AppliesTo, rule.Calculation, rule.Value, rule.FromChild, rule.Stackable, rule.Active, rule.RuleID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type EmergencyContactModel struct {
+	DB *sql.DB
+}
+
+// EmergencyContact is someone to call about a student when none of their guardians can be
+// reached. Contacts are called in priority order, 1 first. Unlike guardians they have no PIN
+// and can't use the kiosk.
+type EmergencyContact struct {
+	ContactID    int64     `json:"contact_id"`
+	StudentID    int64     `json:"student_id"`
+	Priority     int       `json:"priority"`
+	Name         string    `json:"name"`
+	Relationship string    `json:"relationship"`
+	Phone        string    `json:"phone"`
+	AltPhone     string    `json:"alt_phone"`
+	Notes        string    `json:"notes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Insert adds the contact to the student's list. A contact without a priority is added after
+// the student's other contacts.
+func (m EmergencyContactModel) Insert(contact *EmergencyContact) error {
+	query := `
+		INSERT INTO student_emergency_contacts (student_id, priority, name, relationship, phone, alt_phone, notes)
+		SELECT $1, COALESCE(NULLIF($2, 0), MAX(priority) + 1, 1), $3, $4, $5, $6, $7
+		FROM student_emergency_contacts
+		WHERE student_id = $1
+		RETURNING contact_id, priority, created_at
+		`
+	args := []any{contact.StudentID, contact.Priority, contact.Name, contact.Relationship, contact.Phone, contact.AltPhone, contact.Notes}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&contact.ContactID, &contact.Priority, &contact.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "student_emergency_contacts_student_priority_idx"`:
+			return ErrDuplicateContactPriority
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m EmergencyContactModel) Get(studentID, id int64) (*EmergencyContact, error) {
+	if studentID < 1 || id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	contacts, err := m.getAll(`WHERE student_id = $1 AND contact_id = $2`, studentID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(contacts) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return contacts[0], nil
+}
+
+// GetAllByStudentID returns the student's contacts in priority order.
+func (m EmergencyContactModel) GetAllByStudentID(studentID int64) ([]*EmergencyContact, error) {
+	return m.getAll(`WHERE student_id = $1`, studentID)
+}
+
+// GetAllByClassID returns the contacts of every student in the class by student ID, in priority
+// order.
+func (m EmergencyContactModel) GetAllByClassID(classID int64) (map[int64][]*EmergencyContact, error) {
+	contacts, err := m.getAll(`WHERE student_id IN (SELECT student_id FROM class_students WHERE class_id = $1)`, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	byStudent := map[int64][]*EmergencyContact{}
+	for _, contact := range contacts {
+		byStudent[contact.StudentID] = append(byStudent[contact.StudentID], contact)
+	}
+
+	return byStudent, nil
+}
+
+func (m EmergencyContactModel) getAll(where string, args ...any) ([]*EmergencyContact, error) {
+	query := `
+		SELECT contact_id, student_id, priority, name, relationship, phone, alt_phone, notes, created_at
+		FROM student_emergency_contacts
+		` + where + `
+		ORDER BY student_id, priority
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []*EmergencyContact{}
+	for rows.Next() {
+		var contact EmergencyContact
+		err := rows.Scan(
+			&contact.ContactID,
+			&contact.StudentID,
+			&contact.Priority,
+			&contact.Name,
+			&contact.Relationship,
+			&contact.Phone,
+			&contact.AltPhone,
+			&contact.Notes,
+			&contact.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, &contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+func (m EmergencyContactModel) Update(contact *EmergencyContact) error {
+	query := `
+		UPDATE student_emergency_contacts
+		SET priority = $1, name = $2, relationship = $3, phone = $4, alt_phone = $5, notes = $6
+		WHERE contact_id = $7 AND student_id = $8
+		`
+	args := []any{contact.Priority, contact.Name, contact.Relationship, contact.Phone, contact.AltPhone, contact.Notes, contact.ContactID, contact.StudentID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "student_emergency_contacts_student_priority_idx"`:
+			return ErrDuplicateContactPriority
+		default:
+			return err
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m EmergencyContactModel) Delete(studentID, id int64) error {
+	if studentID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM student_emergency_contacts WHERE student_id = $1 AND contact_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, studentID, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
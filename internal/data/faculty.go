@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -19,20 +20,24 @@ type Faculty struct {
 	Password  []byte `json:"-"`
 	Contact   string `json:"contact"`
 	Position  string `json:"position"`
+	Active    bool   `json:"active"`
+	IsAdmin   bool   `json:"is_admin"`
 }
 
+// Insert signs up a new faculty member. They can't sign in until an admin activates them; the
+// first admin of a new center is made with the make-admin command.
 func (m FacultyModel) Insert(faculty *Faculty) error {
 	query := `
-		INSERT INTO faculty (first_name, last_name, email, contact, password_hash, position) 
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING faculty_id
+		INSERT INTO faculty (first_name, last_name, email, contact, password_hash, position, active) 
+		VALUES ($1, $2, $3, $4, $5, $6, false)
+		RETURNING faculty_id, active, is_admin
 		`
 	args := []any{faculty.FirstName, faculty.LastName, faculty.Email, faculty.Contact, faculty.Password, faculty.Position}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&faculty.FacultyID)
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&faculty.FacultyID, &faculty.Active, &faculty.IsAdmin)
 	if err != nil {
 		switch {
 		case err.Error() == `pq: duplicate key value violates unique constraint "faculty_email_key"`:
@@ -75,7 +80,7 @@ func (m FacultyModel) Update(faculty *Faculty) error {
 
 func (m FacultyModel) GetByEmail(email string) (*Faculty, error) {
 	query := `
-		SELECT faculty_id, first_name, last_name, email, password_hash, contact, position
+		SELECT faculty_id, first_name, last_name, email, password_hash, contact, position, active, is_admin
 		FROM faculty
 		WHERE email = $1
 		`
@@ -93,6 +98,8 @@ func (m FacultyModel) GetByEmail(email string) (*Faculty, error) {
 		&faculty.Password,
 		&faculty.Contact,
 		&faculty.Position,
+		&faculty.Active,
+		&faculty.IsAdmin,
 	)
 	if err != nil {
 		switch {
@@ -112,7 +119,7 @@ func (m FacultyModel) Get(id int64) (*Faculty, error) {
 	}
 
 	query := `
-		SELECT faculty_id, first_name, last_name, email, contact, position
+		SELECT faculty_id, first_name, last_name, email, contact, position, active, is_admin
 		FROM faculty
 		WHERE faculty_id = $1
 		`
@@ -130,6 +137,8 @@ func (m FacultyModel) Get(id int64) (*Faculty, error) {
 		&faculty.Email,
 		&faculty.Contact,
 		&faculty.Position,
+		&faculty.Active,
+		&faculty.IsAdmin,
 	)
 
 	if err != nil {
@@ -170,3 +179,234 @@ func (m FacultyModel) Delete(id int64) error {
 
 	return nil
 }
+
+// GetAll searches the faculty by name. An empty position matches every position and a nil
+// active matches both active and deactivated faculty.
+func (m FacultyModel) GetAll(name, position string, active *bool, filters Filters) ([]*Faculty, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), faculty_id, first_name, last_name, email, contact, position, active, is_admin
+		FROM faculty
+		WHERE ((to_tsvector('simple', first_name || ' ' || last_name) @@ plainto_tsquery('simple', $1)) OR $1 = '')
+		AND (lower(position) = lower($2) OR $2 = '')
+		AND ($3::bool IS NULL OR active = $3)
+		ORDER BY %s %s, faculty_id ASC
+		LIMIT $4 OFFSET $5`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, name, position, active, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	faculty := []*Faculty{}
+	totalRecords := 0
+
+	for rows.Next() {
+		var member Faculty
+		err := rows.Scan(
+			&totalRecords,
+			&member.FacultyID,
+			&member.FirstName,
+			&member.LastName,
+			&member.Email,
+			&member.Contact,
+			&member.Position,
+			&member.Active,
+			&member.IsAdmin,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		faculty = append(faculty, &member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return faculty, metadata, nil
+}
+
+// GetAdminIDs returns the IDs of the active admins, who are notified of things that need a
+// manager's attention.
+func (m FacultyModel) GetAdminIDs() ([]int64, error) {
+	query := `
+		SELECT faculty_id
+		FROM faculty
+		WHERE is_admin AND active
+		ORDER BY faculty_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (m FacultyModel) SetActive(id int64, active bool) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `UPDATE faculty SET active = $1 WHERE faculty_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, active, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m FacultyModel) SetAdmin(id int64, isAdmin bool) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `UPDATE faculty SET is_admin = $1 WHERE faculty_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, isAdmin, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteAndReassign hands the classes of a faculty member over to reassignTo and then deletes
+// them, all in one transaction. A reassignTo of 0 only deletes faculty without any classes, and
+// ErrFacultyHasClasses is returned otherwise. Faculty who have clocked in are kept for payroll and
+// return ErrFacultyHasTimeEntries.
+func (m FacultyModel) DeleteAndReassign(id, reassignTo int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if reassignTo > 0 {
+		var active bool
+		err = tx.QueryRowContext(ctx, `SELECT active FROM faculty WHERE faculty_id = $1`, reassignTo).Scan(&active)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrInvalidReassignment
+			default:
+				return err
+			}
+		}
+
+		if !active {
+			return ErrInvalidReassignment
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE classes SET faculty_id = $1 WHERE faculty_id = $2`, reassignTo, id)
+		if err != nil {
+			return err
+		}
+
+		// Past assignments go with the faculty member, current and upcoming ones are handed over.
+		_, err = tx.ExecContext(ctx, `
+			UPDATE class_faculty
+			SET faculty_id = $1
+			WHERE faculty_id = $2 AND (ends_on IS NULL OR ends_on >= CURRENT_DATE)`, reassignTo, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	var classes int
+	err = tx.QueryRowContext(ctx, `
+		SELECT (SELECT count(*) FROM classes WHERE faculty_id = $1)
+			+ (SELECT count(*) FROM class_faculty WHERE faculty_id = $1 AND (ends_on IS NULL OR ends_on >= CURRENT_DATE))`, id).Scan(&classes)
+	if err != nil {
+		return err
+	}
+
+	if classes > 0 {
+		return ErrFacultyHasClasses
+	}
+
+	var entries int
+	err = tx.QueryRowContext(ctx, `SELECT count(*) FROM time_entries WHERE faculty_id = $1`, id).Scan(&entries)
+	if err != nil {
+		return err
+	}
+
+	if entries > 0 {
+		return ErrFacultyHasTimeEntries
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM faculty WHERE faculty_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,339 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type FacultyCredentialModel struct {
+	DB *sql.DB
+}
+
+// FacultyCredential is a certificate or check a faculty member needs for licensing, such as a
+// CPR certificate or a background check. Credentials without an expiry date never expire.
+type FacultyCredential struct {
+	CredentialID int64      `json:"credential_id"`
+	FacultyID    int64      `json:"faculty_id"`
+	Type         string     `json:"type"`
+	IssuedOn     Date       `json:"issued_on"`
+	ExpiresOn    *Date      `json:"expires_on,omitempty"`
+	DocumentRef  string     `json:"document_ref"`
+	FlaggedAt    *time.Time `json:"flagged_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ExpiringCredential is a credential flagged as expiring, with the name of its faculty member.
+type ExpiringCredential struct {
+	FacultyCredential
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// ComplianceIssue is a required credential type that a faculty member has no current credential for.
+type ComplianceIssue struct {
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+	ExpiredOn *Date  `json:"expired_on,omitempty"`
+}
+
+// NonCompliantFaculty is an active faculty member missing one or more required credentials.
+type NonCompliantFaculty struct {
+	FacultyID int64              `json:"faculty_id"`
+	FirstName string             `json:"first_name"`
+	LastName  string             `json:"last_name"`
+	Position  string             `json:"position"`
+	Issues    []*ComplianceIssue `json:"issues"`
+}
+
+func (m FacultyCredentialModel) Insert(credential *FacultyCredential) error {
+	query := `
+		INSERT INTO faculty_credentials (faculty_id, type, issued_on, expires_on, document_ref)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING credential_id, created_at
+		`
+	args := []any{credential.FacultyID, credential.Type, time.Time(credential.IssuedOn), nullDate(credential.ExpiresOn), credential.DocumentRef}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&credential.CredentialID, &credential.CreatedAt)
+}
+
+func (m FacultyCredentialModel) Get(facultyID, credentialID int64) (*FacultyCredential, error) {
+	if facultyID < 1 || credentialID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	credentials, err := m.getAll(`WHERE faculty_id = $1 AND credential_id = $2`, facultyID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(credentials) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return credentials[0], nil
+}
+
+func (m FacultyCredentialModel) GetAllByFacultyID(facultyID int64) ([]*FacultyCredential, error) {
+	return m.getAll(`WHERE faculty_id = $1`, facultyID)
+}
+
+func (m FacultyCredentialModel) getAll(where string, args ...any) ([]*FacultyCredential, error) {
+	query := `
+		SELECT credential_id, faculty_id, type, issued_on, expires_on, document_ref, flagged_at, created_at
+		FROM faculty_credentials
+		` + where + `
+		ORDER BY type, issued_on DESC, credential_id DESC
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []*FacultyCredential{}
+	for rows.Next() {
+		credential, err := scanCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// Update saves the changes to the credential. Changing the expiry date clears the flag, so a
+// renewed credential that is about to expire again is flagged again.
+func (m FacultyCredentialModel) Update(credential *FacultyCredential) error {
+	query := `
+		UPDATE faculty_credentials
+		SET type = $1, issued_on = $2, expires_on = $3, document_ref = $4,
+			flagged_at = CASE WHEN expires_on IS DISTINCT FROM $3 THEN NULL ELSE flagged_at END
+		WHERE faculty_id = $5 AND credential_id = $6
+		RETURNING flagged_at
+		`
+	args := []any{
+		credential.Type,
+		time.Time(credential.IssuedOn),
+		nullDate(credential.ExpiresOn),
+		credential.DocumentRef,
+		credential.FacultyID,
+		credential.CredentialID,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&credential.FlaggedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m FacultyCredentialModel) Delete(facultyID, credentialID int64) error {
+	if facultyID < 1 || credentialID < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM faculty_credentials WHERE faculty_id = $1 AND credential_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, facultyID, credentialID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// FlagExpiring flags the credentials of active faculty that expire on or before the given date
+// and returns the ones that weren't flagged before. Credentials that have already been renewed
+// by a newer credential of the same type are left alone.
+func (m FacultyCredentialModel) FlagExpiring(before time.Time) ([]*ExpiringCredential, error) {
+	query := `
+		UPDATE faculty_credentials c
+		SET flagged_at = NOW()
+		FROM faculty f
+		WHERE c.faculty_id = f.faculty_id
+		AND f.active
+		AND c.flagged_at IS NULL
+		AND c.expires_on <= $1
+		AND NOT EXISTS (
+			SELECT 1
+			FROM faculty_credentials renewed
+			WHERE renewed.faculty_id = c.faculty_id
+			AND renewed.type = c.type
+			AND (renewed.expires_on IS NULL OR renewed.expires_on > $1)
+		)
+		RETURNING c.credential_id, c.faculty_id, c.type, c.issued_on, c.expires_on, c.document_ref, c.flagged_at, c.created_at,
+			f.first_name, f.last_name
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, dateOf(before))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []*ExpiringCredential{}
+	for rows.Next() {
+		var (
+			credential ExpiringCredential
+			issuedOn   time.Time
+			expiresOn  sql.NullTime
+		)
+
+		err := rows.Scan(
+			&credential.CredentialID,
+			&credential.FacultyID,
+			&credential.Type,
+			&issuedOn,
+			&expiresOn,
+			&credential.DocumentRef,
+			&credential.FlaggedAt,
+			&credential.CreatedAt,
+			&credential.FirstName,
+			&credential.LastName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		credential.IssuedOn = Date(issuedOn)
+		credential.ExpiresOn = dateOrNil(expiresOn)
+		credentials = append(credentials, &credential)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// GetNonCompliant returns the active faculty who, on the given date, have no current credential
+// of one or more of the required types. Each missing type is reported as "missing" if the faculty
+// member never had such a credential and as "expired" otherwise.
+func (m FacultyCredentialModel) GetNonCompliant(required []string, on time.Time) ([]*NonCompliantFaculty, error) {
+	query := `
+		SELECT f.faculty_id, f.first_name, f.last_name, f.position, t.type, c.credential_id IS NOT NULL, c.expires_on
+		FROM faculty f
+		CROSS JOIN unnest(string_to_array($1, ',')) AS t(type)
+		LEFT JOIN LATERAL (
+			SELECT credential_id, expires_on
+			FROM faculty_credentials
+			WHERE faculty_id = f.faculty_id AND type = t.type
+			ORDER BY expires_on DESC NULLS FIRST
+			LIMIT 1
+		) c ON true
+		WHERE f.active AND (c.credential_id IS NULL OR c.expires_on < $2)
+		ORDER BY f.last_name, f.first_name, f.faculty_id, t.type
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, strings.Join(required, ","), dateOf(on))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	faculty := []*NonCompliantFaculty{}
+	var member *NonCompliantFaculty
+
+	for rows.Next() {
+		var (
+			f         NonCompliantFaculty
+			issue     ComplianceIssue
+			found     bool
+			expiredOn sql.NullTime
+		)
+
+		err := rows.Scan(&f.FacultyID, &f.FirstName, &f.LastName, &f.Position, &issue.Type, &found, &expiredOn)
+		if err != nil {
+			return nil, err
+		}
+
+		if member == nil || member.FacultyID != f.FacultyID {
+			f.Issues = []*ComplianceIssue{}
+			member = &f
+			faculty = append(faculty, member)
+		}
+
+		issue.Status = "missing"
+		if found {
+			issue.Status = "expired"
+			issue.ExpiredOn = dateOrNil(expiredOn)
+		}
+		member.Issues = append(member.Issues, &issue)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return faculty, nil
+}
+
+func scanCredential(rows *sql.Rows) (*FacultyCredential, error) {
+	var (
+		credential FacultyCredential
+		issuedOn   time.Time
+		expiresOn  sql.NullTime
+	)
+
+	err := rows.Scan(
+		&credential.CredentialID,
+		&credential.FacultyID,
+		&credential.Type,
+		&issuedOn,
+		&expiresOn,
+		&credential.DocumentRef,
+		&credential.FlaggedAt,
+		&credential.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	credential.IssuedOn = Date(issuedOn)
+	credential.ExpiresOn = dateOrNil(expiresOn)
+
+	return &credential, nil
+}
@@ -0,0 +1,297 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// A family is the students billed to the same account, together with all of their guardians.
+// The account is identified by the ID of the guardian it's billed through, stored with each
+// student as their family_id. It's set when the student is registered and changed only when an
+// admin moves the student, so siblings and half-siblings share an account once they're put in
+// the same family, and invoices and payments never move between accounts.
+type FamilyModel struct {
+	DB *sql.DB
+}
+
+type Family struct {
+	FamilyID  int64       `json:"family_id"`
+	Guardians []*Guardian `json:"guardians"`
+	Students  []*Student  `json:"students"`
+}
+
+// StatementEntry is one line of a family's statement. Debits, like invoices and refunds, raise
+// what the family owes and credits, like payments, lower it.
+type StatementEntry struct {
+	Date         Date   `json:"date"`
+	Kind         string `json:"kind"`
+	ReferenceID  int64  `json:"reference_id"`
+	Description  string `json:"description"`
+	DebitCents   int64  `json:"debit_cents"`
+	CreditCents  int64  `json:"credit_cents"`
+	BalanceCents int64  `json:"balance_cents"`
+}
+
+type Statement struct {
+	FamilyID            int64             `json:"family_id"`
+	From                Date              `json:"from"`
+	To                  Date              `json:"to"`
+	OpeningBalanceCents int64             `json:"opening_balance_cents"`
+	Entries             []*StatementEntry `json:"entries"`
+	ClosingBalanceCents int64             `json:"closing_balance_cents"`
+}
+
+// TaxStatement sums up what a family was charged and paid for childcare over a calendar year,
+// for their tax return. Credits given to the family aren't payments and are left out.
+type TaxStatement struct {
+	FamilyID      int64               `json:"family_id"`
+	Year          int                 `json:"year"`
+	Guardians     []*Guardian         `json:"guardians"`
+	Children      []*ChildcareCharges `json:"children"`
+	ChargedCents  int64               `json:"charged_cents"`
+	PaidCents     int64               `json:"paid_cents"`
+	RefundedCents int64               `json:"refunded_cents"`
+	NetPaidCents  int64               `json:"net_paid_cents"`
+}
+
+// ChildcareCharges is what one child of the family was charged for over the year, net of
+// discounts.
+type ChildcareCharges struct {
+	Student
+	ChargedCents int64 `json:"charged_cents"`
+}
+
+// Get returns the family billed through the given guardian. A family whose students have all
+// been moved elsewhere is still returned while its account has any activity.
+func (m FamilyModel) Get(familyID int64) (*Family, error) {
+	if familyID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT s.student_id, s.first_name, s.last_name, s.gender, s.date_of_birth
+		FROM students s
+		WHERE s.family_id = $1
+		ORDER BY s.date_of_birth, s.student_id`, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	family := &Family{FamilyID: familyID, Guardians: []*Guardian{}, Students: []*Student{}}
+	for rows.Next() {
+		var student Student
+		err := rows.Scan(&student.StudentID, &student.FirstName, &student.LastName, &student.Gender, &student.DateOfBirth)
+		if err != nil {
+			return nil, err
+		}
+		family.Students = append(family.Students, &student)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(family.Students) == 0 {
+		var active bool
+		err := m.DB.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM invoices WHERE guardian_id = $1)
+				OR EXISTS (SELECT 1 FROM payments WHERE guardian_id = $1)
+				OR EXISTS (SELECT 1 FROM account_adjustments WHERE guardian_id = $1)`, familyID).Scan(&active)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, ErrRecordNotFound
+		}
+	}
+
+	rows, err = m.DB.QueryContext(ctx, `
+		SELECT g.guardian_id, g.first_name, g.last_name, g.gender, g.relationship, g.occupation, g.contact, g.faculty_id
+		FROM guardians g
+		WHERE g.guardian_id = $1 OR g.guardian_id IN (
+			SELECT sg.guardian_id
+			FROM student_guardian sg
+			INNER JOIN students s ON sg.student_id = s.student_id
+			WHERE s.family_id = $1
+		)
+		ORDER BY g.guardian_id`, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var guardian Guardian
+		err := rows.Scan(
+			&guardian.GuardianID,
+			&guardian.FirstName,
+			&guardian.LastName,
+			&guardian.Gender,
+			&guardian.Relationship,
+			&guardian.Occupation,
+			&guardian.Contact,
+			&guardian.FacultyID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		family.Guardians = append(family.Guardians, &guardian)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return family, nil
+}
+
+// GetStatement returns the family's account activity from one day to another, both included,
+// with the balance brought forward from before and the running balance after every entry.
+func (m FamilyModel) GetStatement(familyID int64, from, to time.Time) (*Statement, error) {
+	query := `
+		SELECT posted_on, kind, reference_id, description, debit_cents, credit_cents
+		FROM (
+			SELECT issued_at::date AS posted_on, 'invoice' AS kind, invoice_id AS reference_id,
+				'Invoice for ' || to_char(period_start, 'YYYY-MM-DD') || ' to ' || to_char(period_end, 'YYYY-MM-DD') AS description,
+				total_cents AS debit_cents, 0::bigint AS credit_cents, 0 AS sort
+			FROM invoices
+			WHERE guardian_id = $1 AND status <> 'void'
+			UNION ALL
+			SELECT received_on, 'payment', payment_id,
+				'Payment by ' || replace(method, '_', ' ') || CASE WHEN reference <> '' THEN ' (' || reference || ')' ELSE '' END,
+				0, amount_cents, 1
+			FROM payments
+			WHERE guardian_id = $1
+			UNION ALL
+			SELECT created_at::date, kind, adjustment_id, reason,
+				CASE WHEN kind = 'refund' THEN amount_cents ELSE 0 END,
+				CASE WHEN kind = 'credit' THEN amount_cents ELSE 0 END, 2
+			FROM account_adjustments
+			WHERE guardian_id = $1
+		) ledger
+		WHERE posted_on <= $2
+		ORDER BY posted_on, sort, reference_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	from, to = dateOf(from), dateOf(to)
+
+	rows, err := m.DB.QueryContext(ctx, query, familyID, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statement := &Statement{
+		FamilyID: familyID,
+		From:     Date(from),
+		To:       Date(to),
+		Entries:  []*StatementEntry{},
+	}
+
+	var balance int64
+	for rows.Next() {
+		var (
+			entry    StatementEntry
+			postedOn time.Time
+		)
+
+		err := rows.Scan(&postedOn, &entry.Kind, &entry.ReferenceID, &entry.Description, &entry.DebitCents, &entry.CreditCents)
+		if err != nil {
+			return nil, err
+		}
+
+		balance += entry.DebitCents - entry.CreditCents
+
+		// entries before the statement only count towards the opening balance, dates are compared
+		// as text because the database returns them in UTC and from is in local time
+		if postedOn.Format("2006-01-02") < from.Format("2006-01-02") {
+			statement.OpeningBalanceCents = balance
+			continue
+		}
+
+		entry.Date = Date(postedOn)
+		entry.BalanceCents = balance
+		statement.Entries = append(statement.Entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statement.ClosingBalanceCents = balance
+
+	return statement, nil
+}
+
+// GetTaxStatement returns the family's childcare charges and payments for the year. Invoices
+// count towards the year their billing period starts in, payments towards the year they were
+// received. Children are listed for what the family's invoices charged for them, including
+// children who have since moved to another family.
+func (m FamilyModel) GetTaxStatement(familyID int64, year int) (*TaxStatement, error) {
+	family, err := m.Get(familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &TaxStatement{
+		FamilyID:  familyID,
+		Year:      year,
+		Guardians: family.Guardians,
+		Children:  []*ChildcareCharges{},
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT s.student_id, s.first_name, s.last_name, s.gender, s.date_of_birth, sum(il.amount_cents)
+		FROM invoice_lines il
+		INNER JOIN invoices i ON il.invoice_id = i.invoice_id
+		INNER JOIN students s ON il.student_id = s.student_id
+		WHERE i.guardian_id = $1 AND i.status <> 'void' AND i.period_start BETWEEN $2 AND $3
+		GROUP BY s.student_id
+		ORDER BY s.date_of_birth, s.student_id`, familyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var child ChildcareCharges
+		err := rows.Scan(&child.StudentID, &child.FirstName, &child.LastName, &child.Gender, &child.DateOfBirth, &child.ChargedCents)
+		if err != nil {
+			return nil, err
+		}
+		statement.Children = append(statement.Children, &child)
+		statement.ChargedCents += child.ChargedCents
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = m.DB.QueryRowContext(ctx, `
+		SELECT
+			COALESCE((SELECT sum(amount_cents) FROM payments WHERE guardian_id = $1 AND received_on BETWEEN $2 AND $3), 0),
+			COALESCE((SELECT sum(amount_cents) FROM account_adjustments
+				WHERE guardian_id = $1 AND kind = 'refund' AND created_at::date BETWEEN $2 AND $3), 0)`,
+		familyID, from, to).Scan(&statement.PaidCents, &statement.RefundedCents)
+	if err != nil {
+		return nil, err
+	}
+
+	statement.NetPaidCents = statement.PaidCents - statement.RefundedCents
+
+	return statement, nil
+}
@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 )
 
@@ -18,6 +19,8 @@ type Guardian struct {
 	Relationship string `json:"relationship"`
 	Occupation   string `json:"occupation"`
 	Contact      string `json:"contact"`
+	FacultyID    *int64 `json:"faculty_id,omitempty"`
+	PINHash      []byte `json:"-"`
 }
 
 // CREATE TABLE IF NOT EXISTS student_guardian (
@@ -57,3 +60,142 @@ func (m *GuardianModel) GetByStudentID(studentID int64) (*Guardian, error) {
 
 	return g, nil
 }
+
+func (m *GuardianModel) Get(id int64) (*Guardian, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT guardian_id, first_name, last_name, gender, relationship, occupation, contact, faculty_id, pin_hash
+		FROM guardians
+		WHERE guardian_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	g := &Guardian{}
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&g.GuardianID,
+		&g.FirstName,
+		&g.LastName,
+		&g.Gender,
+		&g.Relationship,
+		&g.Occupation,
+		&g.Contact,
+		&g.FacultyID,
+		&g.PINHash,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// GetAllWithPINByContact returns the guardians with the given contact number who have a kiosk
+// PIN set. Several guardians may share a number, so the caller has to check the PIN of each.
+func (m *GuardianModel) GetAllWithPINByContact(contact string) ([]*Guardian, error) {
+	query := `
+		SELECT guardian_id, first_name, last_name, gender, relationship, occupation, contact, pin_hash
+		FROM guardians
+		WHERE contact = $1 AND pin_hash IS NOT NULL
+		ORDER BY guardian_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, contact)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guardians := []*Guardian{}
+	for rows.Next() {
+		g := &Guardian{}
+		err := rows.Scan(
+			&g.GuardianID,
+			&g.FirstName,
+			&g.LastName,
+			&g.Gender,
+			&g.Relationship,
+			&g.Occupation,
+			&g.Contact,
+			&g.PINHash,
+		)
+		if err != nil {
+			return nil, err
+		}
+		guardians = append(guardians, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return guardians, nil
+}
+
+func (m *GuardianModel) SetPIN(id int64, pinHash []byte) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `UPDATE guardians SET pin_hash = $1 WHERE guardian_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, pinHash, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetFaculty links the guardian to the faculty account of the staff member they are, or unlinks
+// them if facultyID is nil.
+func (m *GuardianModel) SetFaculty(id int64, facultyID *int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `UPDATE guardians SET faculty_id = $1 WHERE guardian_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, facultyID, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
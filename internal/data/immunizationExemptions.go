@@ -0,0 +1,129 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ImmunizationExemptionModel struct {
+	DB *sql.DB
+}
+
+// ImmunizationExemption excuses a student from a vaccine, or from every vaccine if Vaccine is
+// empty, until it expires. Exemptions without an expiry date never expire.
+type ImmunizationExemption struct {
+	ExemptionID int64     `json:"exemption_id"`
+	StudentID   int64     `json:"student_id"`
+	Vaccine     string    `json:"vaccine"`
+	Kind        string    `json:"kind"`
+	DocumentRef string    `json:"document_ref"`
+	ExpiresOn   *Date     `json:"expires_on,omitempty"`
+	RecordedBy  *int64    `json:"recorded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (m ImmunizationExemptionModel) Insert(exemption *ImmunizationExemption) error {
+	query := `
+		INSERT INTO immunization_exemptions (student_id, vaccine, kind, document_ref, expires_on, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING exemption_id, created_at
+		`
+	args := []any{
+		exemption.StudentID,
+		exemption.Vaccine,
+		exemption.Kind,
+		exemption.DocumentRef,
+		nullDate(exemption.ExpiresOn),
+		exemption.RecordedBy,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&exemption.ExemptionID, &exemption.CreatedAt)
+}
+
+func (m ImmunizationExemptionModel) GetAllByStudentID(studentID int64) ([]*ImmunizationExemption, error) {
+	return m.getAll(`WHERE student_id = $1`, studentID)
+}
+
+// GetAllByClassID returns the exemptions of the students in the class, or of every enrolled
+// student if classID is 0.
+func (m ImmunizationExemptionModel) GetAllByClassID(classID int64) ([]*ImmunizationExemption, error) {
+	return m.getAll(`WHERE student_id IN (`+enrolledIn+`)`, classID)
+}
+
+func (m ImmunizationExemptionModel) getAll(where string, args ...any) ([]*ImmunizationExemption, error) {
+	query := `
+		SELECT exemption_id, student_id, vaccine, kind, document_ref, expires_on, recorded_by, created_at
+		FROM immunization_exemptions
+		` + where + `
+		ORDER BY student_id, exemption_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exemptions := []*ImmunizationExemption{}
+	for rows.Next() {
+		var (
+			exemption ImmunizationExemption
+			expiresOn sql.NullTime
+		)
+		err := rows.Scan(
+			&exemption.ExemptionID,
+			&exemption.StudentID,
+			&exemption.Vaccine,
+			&exemption.Kind,
+			&exemption.DocumentRef,
+			&expiresOn,
+			&exemption.RecordedBy,
+			&exemption.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		exemption.ExpiresOn = dateOrNil(expiresOn)
+		exemptions = append(exemptions, &exemption)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return exemptions, nil
+}
+
+func (m ImmunizationExemptionModel) Delete(studentID, id int64) error {
+	if studentID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM immunization_exemptions WHERE student_id = $1 AND exemption_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, studentID, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ImmunizationScheduleModel struct {
+	DB *sql.DB
+}
+
+// ScheduledDose is a dose of a vaccine every child needs, due at DueAgeMonths months old and
+// overdue GraceMonths months after that.
+type ScheduledDose struct {
+	ScheduleID   int64  `json:"schedule_id"`
+	Vaccine      string `json:"vaccine"`
+	Dose         int    `json:"dose"`
+	DueAgeMonths int    `json:"due_age_months"`
+	GraceMonths  int    `json:"grace_months"`
+}
+
+func (m ImmunizationScheduleModel) Insert(dose *ScheduledDose) error {
+	query := `
+		INSERT INTO immunization_schedule (vaccine, dose, due_age_months, grace_months)
+		VALUES ($1, $2, $3, $4)
+		RETURNING schedule_id
+		`
+	args := []any{dose.Vaccine, dose.Dose, dose.DueAgeMonths, dose.GraceMonths}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&dose.ScheduleID)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "immunization_schedule_vaccine_dose_key"`:
+			return ErrDuplicateScheduledDose
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m ImmunizationScheduleModel) Get(id int64) (*ScheduledDose, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	schedule, err := m.getAll(`WHERE schedule_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(schedule) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return schedule[0], nil
+}
+
+// GetAll returns the whole schedule in the order the doses fall due.
+func (m ImmunizationScheduleModel) GetAll() ([]*ScheduledDose, error) {
+	return m.getAll("")
+}
+
+func (m ImmunizationScheduleModel) getAll(where string, args ...any) ([]*ScheduledDose, error) {
+	query := `
+		SELECT schedule_id, vaccine, dose, due_age_months, grace_months
+		FROM immunization_schedule
+		` + where + `
+		ORDER BY due_age_months, vaccine, dose
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedule := []*ScheduledDose{}
+	for rows.Next() {
+		var dose ScheduledDose
+		err := rows.Scan(&dose.ScheduleID, &dose.Vaccine, &dose.Dose, &dose.DueAgeMonths, &dose.GraceMonths)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, &dose)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (m ImmunizationScheduleModel) Update(dose *ScheduledDose) error {
+	query := `
+		UPDATE immunization_schedule
+		SET vaccine = $1, dose = $2, due_age_months = $3, grace_months = $4
+		WHERE schedule_id = $5
+		`
+	args := []any{dose.Vaccine, dose.Dose, dose.DueAgeMonths, dose.GraceMonths, dose.ScheduleID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "immunization_schedule_vaccine_dose_key"`:
+			return ErrDuplicateScheduledDose
+		default:
+			return err
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m ImmunizationScheduleModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM immunization_schedule WHERE schedule_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
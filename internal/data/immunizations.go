@@ -0,0 +1,196 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ImmunizationModel struct {
+	DB *sql.DB
+}
+
+// Immunization is a dose of a vaccine a student was given.
+type Immunization struct {
+	ImmunizationID int64     `json:"immunization_id"`
+	StudentID      int64     `json:"student_id"`
+	Vaccine        string    `json:"vaccine"`
+	Dose           int       `json:"dose"`
+	AdministeredOn Date      `json:"administered_on"`
+	AdministeredBy string    `json:"administered_by"`
+	RecordedBy     *int64    `json:"recorded_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// EnrolledStudent is a student in a class, for reports that go through the classes one by one.
+type EnrolledStudent struct {
+	ClassID   int64  `json:"class_id"`
+	ClassName string `json:"class_name"`
+	Student
+}
+
+// enrolledIn selects the students in the class given as the first argument, or in any class if
+// it is 0.
+const enrolledIn = `
+	SELECT student_id FROM class_students WHERE class_id = $1 OR $1 = 0`
+
+func (m ImmunizationModel) Insert(immunization *Immunization) error {
+	query := `
+		INSERT INTO immunizations (student_id, vaccine, dose, administered_on, administered_by, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING immunization_id, created_at
+		`
+	args := []any{
+		immunization.StudentID,
+		immunization.Vaccine,
+		immunization.Dose,
+		time.Time(immunization.AdministeredOn),
+		immunization.AdministeredBy,
+		immunization.RecordedBy,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&immunization.ImmunizationID, &immunization.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "immunizations_student_vaccine_dose_idx"`:
+			return ErrDuplicateImmunization
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m ImmunizationModel) GetAllByStudentID(studentID int64) ([]*Immunization, error) {
+	return m.getAll(`WHERE student_id = $1`, studentID)
+}
+
+// GetAllByClassID returns the immunizations of the students in the class, or of every enrolled
+// student if classID is 0.
+func (m ImmunizationModel) GetAllByClassID(classID int64) ([]*Immunization, error) {
+	return m.getAll(`WHERE student_id IN (`+enrolledIn+`)`, classID)
+}
+
+func (m ImmunizationModel) getAll(where string, args ...any) ([]*Immunization, error) {
+	query := `
+		SELECT immunization_id, student_id, vaccine, dose, administered_on, administered_by, recorded_by, created_at
+		FROM immunizations
+		` + where + `
+		ORDER BY student_id, vaccine, dose
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	immunizations := []*Immunization{}
+	for rows.Next() {
+		var (
+			immunization   Immunization
+			administeredOn time.Time
+		)
+		err := rows.Scan(
+			&immunization.ImmunizationID,
+			&immunization.StudentID,
+			&immunization.Vaccine,
+			&immunization.Dose,
+			&administeredOn,
+			&immunization.AdministeredBy,
+			&immunization.RecordedBy,
+			&immunization.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		immunization.AdministeredOn = Date(administeredOn)
+		immunizations = append(immunizations, &immunization)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return immunizations, nil
+}
+
+func (m ImmunizationModel) Delete(studentID, id int64) error {
+	if studentID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM immunizations WHERE student_id = $1 AND immunization_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, studentID, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetEnrolled returns the students in the class, or in every class if classID is 0, ordered by
+// class and name. A student in two classes is returned for each of them.
+func (m ImmunizationModel) GetEnrolled(classID int64) ([]*EnrolledStudent, error) {
+	query := `
+		SELECT c.class_id, c.class_name, s.student_id, s.first_name, s.last_name, s.gender, s.date_of_birth
+		FROM class_students cs
+		INNER JOIN classes c ON cs.class_id = c.class_id
+		INNER JOIN students s ON cs.student_id = s.student_id
+		WHERE cs.class_id = $1 OR $1 = 0
+		ORDER BY c.class_name, c.class_id, s.last_name, s.first_name
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	students := []*EnrolledStudent{}
+	for rows.Next() {
+		var student EnrolledStudent
+		err := rows.Scan(
+			&student.ClassID,
+			&student.ClassName,
+			&student.StudentID,
+			&student.FirstName,
+			&student.LastName,
+			&student.Gender,
+			&student.DateOfBirth,
+		)
+		if err != nil {
+			return nil, err
+		}
+		students = append(students, &student)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return students, nil
+}
@@ -0,0 +1,502 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type IncidentModel struct {
+	DB *sql.DB
+}
+
+// Incident is a report of an injury or other incident involving one or more students. It is
+// written up as a draft by the faculty member who saw it, submitted once complete, and counts
+// as acknowledged when a guardian of every student involved has acknowledged it.
+type Incident struct {
+	IncidentID  int64              `json:"incident_id"`
+	ClassID     *int64             `json:"class_id"`
+	Kind        string             `json:"kind"`
+	OccurredAt  time.Time          `json:"occurred_at"`
+	Location    string             `json:"location"`
+	Description string             `json:"description"`
+	FirstAid    string             `json:"first_aid"`
+	Witnesses   string             `json:"witnesses"`
+	Status      string             `json:"status"`
+	ReportedBy  *int64             `json:"reported_by"`
+	SubmittedAt *time.Time         `json:"submitted_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Students    []*IncidentStudent `json:"students"`
+}
+
+// IncidentStudent is a student involved in an incident, and the guardian who acknowledged it
+// for them.
+type IncidentStudent struct {
+	StudentID      int64      `json:"student_id"`
+	FirstName      string     `json:"first_name"`
+	LastName       string     `json:"last_name"`
+	AcknowledgedBy *int64     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
+
+// IncidentFilter narrows down the incidents returned by GetAll. Zero values match everything.
+// FacultyID limits the incidents to those the faculty member may see, as IsAccessible does.
+type IncidentFilter struct {
+	FacultyID int64
+	ClassID   int64
+	StudentID int64
+	Status    string
+	Kind      string
+	From      time.Time
+	To        time.Time
+}
+
+// IncidentSummary counts the incidents of a kind in a class, ClassID being nil for incidents
+// not tied to a class.
+type IncidentSummary struct {
+	ClassID        *int64 `json:"class_id"`
+	ClassName      string `json:"class_name"`
+	Kind           string `json:"kind"`
+	Incidents      int    `json:"incidents"`
+	Unacknowledged int    `json:"unacknowledged"`
+}
+
+// accessibleIncidents selects the incidents faculty member $1 reported, or that happened in or
+// involve a student of a class they are assigned to or covering under an approved grant today.
+const accessibleIncidents = `
+	WITH classes AS (
+		SELECT class_id FROM class_faculty WHERE faculty_id = $1 AND ` + currentAssignment + `
+		UNION
+		SELECT class_id FROM coverage_grants
+		WHERE faculty_id = $1 AND status = 'approved' AND starts_at <= NOW() AND ends_at > NOW()
+	)
+	SELECT incident_id FROM incidents
+	WHERE reported_by = $1 OR class_id IN (SELECT class_id FROM classes)
+	UNION
+	SELECT incident_id FROM incident_students
+	WHERE student_id IN (SELECT student_id FROM class_students WHERE class_id IN (SELECT class_id FROM classes))`
+
+// Insert saves the draft incident with its students in one transaction.
+func (m IncidentModel) Insert(incident *Incident) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO incidents (class_id, kind, occurred_at, location, description, first_aid, witnesses, reported_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING incident_id, status, created_at, updated_at`,
+		incident.ClassID,
+		incident.Kind,
+		incident.OccurredAt,
+		incident.Location,
+		incident.Description,
+		incident.FirstAid,
+		incident.Witnesses,
+		incident.ReportedBy,
+	).Scan(&incident.IncidentID, &incident.Status, &incident.CreatedAt, &incident.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = insertIncidentStudents(ctx, tx, incident)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertIncidentStudents(ctx context.Context, tx *sql.Tx, incident *Incident) error {
+	for _, student := range incident.Students {
+		err := tx.QueryRowContext(ctx, `
+			WITH student AS (
+				SELECT student_id, first_name, last_name FROM students WHERE student_id = $2
+			), inserted AS (
+				INSERT INTO incident_students (incident_id, student_id)
+				SELECT $1, student_id FROM student
+				RETURNING student_id
+			)
+			SELECT s.first_name, s.last_name FROM student s INNER JOIN inserted USING (student_id)`,
+			incident.IncidentID, student.StudentID).Scan(&student.FirstName, &student.LastName)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrRecordNotFound
+			default:
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m IncidentModel) Get(id int64) (*Incident, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	incidents, err := m.getAll(`WHERE i.incident_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(incidents) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return incidents[0], nil
+}
+
+// GetAll returns the incidents matching the filter, most recent first.
+func (m IncidentModel) GetAll(filter IncidentFilter) ([]*Incident, error) {
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+
+	return m.getAll(`
+		WHERE (i.incident_id IN (`+accessibleIncidents+`) OR $1 = 0)
+		AND (i.class_id = $2 OR $2 = 0)
+		AND (i.incident_id IN (SELECT incident_id FROM incident_students WHERE student_id = $3) OR $3 = 0)
+		AND (i.status = $4 OR $4 = '')
+		AND (i.kind = $5 OR $5 = '')
+		AND (i.occurred_at >= $6 OR $6 IS NULL)
+		AND (i.occurred_at < $7 OR $7 IS NULL)`,
+		filter.FacultyID, filter.ClassID, filter.StudentID, filter.Status, filter.Kind, from, to)
+}
+
+// IsAccessible reports whether the faculty member reported the incident, or is assigned to or
+// covering the class it happened in or a class of a student involved in it.
+func (m IncidentModel) IsAccessible(id, facultyID int64) (bool, error) {
+	query := `SELECT $2 IN (` + accessibleIncidents + `)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var accessible bool
+	err := m.DB.QueryRowContext(ctx, query, facultyID, id).Scan(&accessible)
+	if err != nil {
+		return false, err
+	}
+
+	return accessible, nil
+}
+
+// GetPendingByGuardianID returns the submitted incidents involving the guardian's children that
+// haven't been acknowledged for them yet.
+func (m IncidentModel) GetPendingByGuardianID(guardianID int64) ([]*Incident, error) {
+	return m.getAll(`
+		WHERE i.status = 'submitted'
+		AND i.incident_id IN (
+			SELECT incident_id FROM incident_students
+			WHERE acknowledged_at IS NULL
+			AND student_id IN (SELECT student_id FROM student_guardian WHERE guardian_id = $1)
+		)`, guardianID)
+}
+
+func (m IncidentModel) getAll(where string, args ...any) ([]*Incident, error) {
+	query := `
+		SELECT i.incident_id, i.class_id, i.kind, i.occurred_at, i.location, i.description, i.first_aid,
+			i.witnesses, i.status, i.reported_by, i.submitted_at, i.created_at, i.updated_at,
+			s.student_id, s.first_name, s.last_name, ist.acknowledged_by, ist.acknowledged_at
+		FROM incidents i
+		LEFT JOIN incident_students ist ON i.incident_id = ist.incident_id
+		LEFT JOIN students s ON ist.student_id = s.student_id
+		` + where + `
+		ORDER BY i.occurred_at DESC, i.incident_id DESC, s.last_name, s.first_name
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	incidents := []*Incident{}
+	var current *Incident
+	for rows.Next() {
+		var (
+			incident       Incident
+			submittedAt    sql.NullTime
+			studentID      sql.NullInt64
+			firstName      sql.NullString
+			lastName       sql.NullString
+			acknowledgedBy *int64
+			acknowledgedAt sql.NullTime
+		)
+		err := rows.Scan(
+			&incident.IncidentID,
+			&incident.ClassID,
+			&incident.Kind,
+			&incident.OccurredAt,
+			&incident.Location,
+			&incident.Description,
+			&incident.FirstAid,
+			&incident.Witnesses,
+			&incident.Status,
+			&incident.ReportedBy,
+			&submittedAt,
+			&incident.CreatedAt,
+			&incident.UpdatedAt,
+			&studentID,
+			&firstName,
+			&lastName,
+			&acknowledgedBy,
+			&acknowledgedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if current == nil || current.IncidentID != incident.IncidentID {
+			if submittedAt.Valid {
+				incident.SubmittedAt = &submittedAt.Time
+			}
+			incident.Students = []*IncidentStudent{}
+			current = &incident
+			incidents = append(incidents, current)
+		}
+
+		if studentID.Valid {
+			student := &IncidentStudent{
+				StudentID:      studentID.Int64,
+				FirstName:      firstName.String,
+				LastName:       lastName.String,
+				AcknowledgedBy: acknowledgedBy,
+			}
+			if acknowledgedAt.Valid {
+				student.AcknowledgedAt = &acknowledgedAt.Time
+			}
+			current.Students = append(current.Students, student)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+// Update saves changes to a draft incident, replacing its students. Incidents that have been
+// submitted can no longer be changed and return ErrIncidentNotDraft.
+func (m IncidentModel) Update(incident *Incident) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE incidents
+		SET class_id = $1, kind = $2, occurred_at = $3, location = $4, description = $5, first_aid = $6,
+			witnesses = $7, updated_at = NOW()
+		WHERE incident_id = $8 AND status = 'draft'
+		RETURNING updated_at`,
+		incident.ClassID,
+		incident.Kind,
+		incident.OccurredAt,
+		incident.Location,
+		incident.Description,
+		incident.FirstAid,
+		incident.Witnesses,
+		incident.IncidentID,
+	).Scan(&incident.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrIncidentNotDraft
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM incident_students WHERE incident_id = $1`, incident.IncidentID)
+	if err != nil {
+		return err
+	}
+
+	err = insertIncidentStudents(ctx, tx, incident)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Submit sends a draft incident to the guardians of the students involved to acknowledge.
+func (m IncidentModel) Submit(incident *Incident) error {
+	query := `
+		UPDATE incidents
+		SET status = 'submitted', submitted_at = NOW(), updated_at = NOW()
+		WHERE incident_id = $1 AND status = 'draft'
+		RETURNING status, submitted_at, updated_at
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var submittedAt time.Time
+	err := m.DB.QueryRowContext(ctx, query, incident.IncidentID).Scan(&incident.Status, &submittedAt, &incident.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrIncidentNotDraft
+		default:
+			return err
+		}
+	}
+
+	incident.SubmittedAt = &submittedAt
+
+	return nil
+}
+
+// Acknowledge records the guardian's acknowledgement of the incident for their children involved
+// in it. Once every student involved has been acknowledged for, the incident is acknowledged.
+// Only submitted incidents can be acknowledged, ErrIncidentNotSubmitted is returned otherwise.
+func (m IncidentModel) Acknowledge(incident *Incident, guardianID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the incident so two guardians acknowledging at once both see the other's.
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM incidents WHERE incident_id = $1 FOR UPDATE`, incident.IncidentID).Scan(&status)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	if status != "submitted" {
+		return ErrIncidentNotSubmitted
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE incident_students
+		SET acknowledged_by = $1, acknowledged_at = NOW()
+		WHERE incident_id = $2 AND acknowledged_at IS NULL
+		AND student_id IN (SELECT student_id FROM student_guardian WHERE guardian_id = $1)`,
+		guardianID, incident.IncidentID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE incidents
+		SET status = 'acknowledged', updated_at = NOW()
+		WHERE incident_id = $1
+		AND NOT EXISTS (SELECT 1 FROM incident_students WHERE incident_id = $1 AND acknowledged_at IS NULL)`,
+		incident.IncidentID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	acknowledged, err := m.Get(incident.IncidentID)
+	if err != nil {
+		return err
+	}
+	*incident = *acknowledged
+
+	return nil
+}
+
+// Delete removes a draft incident. Incidents that have been submitted are kept for the licensing
+// record and return ErrIncidentNotDraft.
+func (m IncidentModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM incidents WHERE incident_id = $1 AND status = 'draft'`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrIncidentNotDraft
+	}
+
+	return nil
+}
+
+// GetSummaries counts the submitted incidents between from and to by class and kind. Drafts are
+// left out as they haven't been reported yet.
+func (m IncidentModel) GetSummaries(from, to time.Time) ([]*IncidentSummary, error) {
+	query := `
+		SELECT i.class_id, COALESCE(c.class_name, ''), i.kind, COUNT(*),
+			COUNT(*) FILTER (WHERE i.status <> 'acknowledged')
+		FROM incidents i
+		LEFT JOIN classes c ON i.class_id = c.class_id
+		WHERE i.status <> 'draft' AND i.occurred_at >= $1 AND i.occurred_at < $2
+		GROUP BY i.class_id, c.class_name, i.kind
+		ORDER BY c.class_name NULLS LAST, i.kind
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []*IncidentSummary{}
+	for rows.Next() {
+		var summary IncidentSummary
+		err := rows.Scan(&summary.ClassID, &summary.ClassName, &summary.Kind, &summary.Incidents, &summary.Unacknowledged)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, &summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,378 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type InvoiceModel struct {
+	DB *sql.DB
+}
+
+// Invoice bills a family, through its billing guardian, for one billing period.
+type Invoice struct {
+	InvoiceID   int64          `json:"invoice_id"`
+	GuardianID  int64          `json:"guardian_id"`
+	PeriodStart Date           `json:"period_start"`
+	PeriodEnd   Date           `json:"period_end"`
+	TotalCents  int64          `json:"total_cents"`
+	Status      string         `json:"status"`
+	DueOn       Date           `json:"due_on"`
+	IssuedAt    time.Time      `json:"issued_at"`
+	Lines       []*InvoiceLine `json:"lines,omitempty"`
+}
+
+type InvoiceLine struct {
+	LineID        int64  `json:"line_id"`
+	InvoiceID     int64  `json:"invoice_id"`
+	StudentID     *int64 `json:"student_id,omitempty"`
+	StudentPlanID *int64 `json:"student_plan_id,omitempty"`
+	LateFeeID     *int64 `json:"late_fee_id,omitempty"`
+	Kind          string `json:"kind"`
+	Description   string `json:"description"`
+	Quantity      int    `json:"quantity"`
+	UnitCents     int64  `json:"unit_cents"`
+	AmountCents   int64  `json:"amount_cents"`
+}
+
+// Insert saves the invoice and its lines in one transaction. A family that was already invoiced
+// for any day of the period, on an invoice that hasn't been voided, returns ErrDuplicateInvoice.
+// Credit the family already has on account goes towards paying the invoice.
+func (m InvoiceModel) Insert(invoice *Invoice) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockFamily(ctx, tx, invoice.GuardianID)
+	if err != nil {
+		return err
+	}
+
+	var invoiced bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM invoices
+			WHERE guardian_id = $1 AND status <> 'void' AND period_start <= $3 AND period_end >= $2
+		)`, invoice.GuardianID, time.Time(invoice.PeriodStart), time.Time(invoice.PeriodEnd)).Scan(&invoiced)
+	if err != nil {
+		return err
+	}
+
+	if invoiced {
+		return ErrDuplicateInvoice
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO invoices (guardian_id, period_start, period_end, total_cents, due_on)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING invoice_id, status, issued_at`,
+		invoice.GuardianID,
+		time.Time(invoice.PeriodStart),
+		time.Time(invoice.PeriodEnd),
+		invoice.TotalCents,
+		time.Time(invoice.DueOn),
+	).Scan(&invoice.InvoiceID, &invoice.Status, &invoice.IssuedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "invoices_guardian_period_idx"`:
+			return ErrDuplicateInvoice
+		default:
+			return err
+		}
+	}
+
+	for _, line := range invoice.Lines {
+		line.InvoiceID = invoice.InvoiceID
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO invoice_lines (invoice_id, student_id, student_plan_id, late_fee_id, kind, description, quantity, unit_cents, amount_cents)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING line_id`,
+			line.InvoiceID,
+			line.StudentID,
+			line.StudentPlanID,
+			line.LateFeeID,
+			line.Kind,
+			line.Description,
+			line.Quantity,
+			line.UnitCents,
+			line.AmountCents,
+		).Scan(&line.LineID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = settleInvoices(ctx, tx, invoice.GuardianID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT status FROM invoices WHERE invoice_id = $1`, invoice.InvoiceID).Scan(&invoice.Status)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Void cancels an open invoice, which then no longer counts towards the family's balance. Its
+// registration and late pickup fees are unlinked from their plans and fees, so the next billing
+// run bills them again.
+func (m InvoiceModel) Void(invoice *Invoice) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockFamily(ctx, tx, invoice.GuardianID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE invoices
+		SET status = 'void'
+		WHERE invoice_id = $1 AND status = 'open'
+		RETURNING status`, invoice.InvoiceID).Scan(&invoice.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrInvoiceNotOpen
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE invoice_lines
+		SET student_plan_id = CASE WHEN kind = 'registration' THEN NULL ELSE student_plan_id END,
+			late_fee_id = CASE WHEN kind = 'late_pickup' THEN NULL ELSE late_fee_id END
+		WHERE invoice_id = $1 AND kind IN ('registration', 'late_pickup')`, invoice.InvoiceID)
+	if err != nil {
+		return err
+	}
+
+	err = settleInvoices(ctx, tx, invoice.GuardianID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// familyBalance returns what the family owes, which is negative when it has credit on account.
+func familyBalance(ctx context.Context, tx *sql.Tx, familyID int64) (int64, error) {
+	query := `
+		SELECT
+			COALESCE((SELECT sum(total_cents) FROM invoices WHERE guardian_id = $1 AND status <> 'void'), 0)
+			- COALESCE((SELECT sum(amount_cents) FROM payments WHERE guardian_id = $1), 0)
+			- COALESCE((SELECT sum(amount_cents) FROM account_adjustments WHERE guardian_id = $1 AND kind = 'credit'), 0)
+			+ COALESCE((SELECT sum(amount_cents) FROM account_adjustments WHERE guardian_id = $1 AND kind = 'refund'), 0)
+		`
+
+	var balance int64
+	err := tx.QueryRowContext(ctx, query, familyID).Scan(&balance)
+	return balance, err
+}
+
+// lockFamily locks the family's guardian row so that changes to the family's account are made
+// one after the other.
+func lockFamily(ctx context.Context, tx *sql.Tx, familyID int64) error {
+	_, err := tx.ExecContext(ctx, `SELECT 1 FROM guardians WHERE guardian_id = $1 FOR UPDATE`, familyID)
+	return err
+}
+
+// settlement works out what family $1 has paid and been credited in total, as covered, and the
+// running total of its invoices that haven't been voided, oldest first, as running. Payments go
+// towards the oldest invoices first.
+const settlement = `
+	WITH covered AS (
+		SELECT COALESCE((SELECT sum(amount_cents) FROM payments WHERE guardian_id = $1), 0)
+			+ COALESCE((SELECT sum(amount_cents) FROM account_adjustments WHERE guardian_id = $1 AND kind = 'credit'), 0)
+			- COALESCE((SELECT sum(amount_cents) FROM account_adjustments WHERE guardian_id = $1 AND kind = 'refund'), 0) AS cents
+	), running AS (
+		SELECT invoice_id, sum(total_cents) OVER (ORDER BY period_start, invoice_id) AS cents
+		FROM invoices
+		WHERE guardian_id = $1 AND status <> 'void'
+	)`
+
+// settleInvoices marks the family's invoices as paid, oldest first, for as long as the money
+// paid and credited to the family covers them, and the rest as open.
+func settleInvoices(ctx context.Context, tx *sql.Tx, familyID int64) error {
+	query := settlement + `
+		UPDATE invoices i
+		SET status = CASE WHEN running.cents <= covered.cents THEN 'paid' ELSE 'open' END
+		FROM running, covered
+		WHERE i.invoice_id = running.invoice_id
+		`
+
+	_, err := tx.ExecContext(ctx, query, familyID)
+	return err
+}
+
+// GetUnpaidCents returns what is left to pay of the invoice, once the money paid and credited to
+// the family has gone towards it and the family's older invoices. Voided invoices return 0.
+func (m InvoiceModel) GetUnpaidCents(invoice *Invoice) (int64, error) {
+	query := settlement + `
+		SELECT LEAST(i.total_cents, GREATEST(running.cents - covered.cents, 0))
+		FROM invoices i
+		INNER JOIN running ON i.invoice_id = running.invoice_id
+		CROSS JOIN covered
+		WHERE i.invoice_id = $2
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var unpaid int64
+	err := m.DB.QueryRowContext(ctx, query, invoice.GuardianID, invoice.InvoiceID).Scan(&unpaid)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, nil
+		default:
+			return 0, err
+		}
+	}
+
+	return unpaid, nil
+}
+
+// Get returns the invoice together with its lines.
+func (m InvoiceModel) Get(id int64) (*Invoice, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	invoices, err := m.getAll(`WHERE invoice_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(invoices) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	invoice := invoices[0]
+
+	invoice.Lines, err = m.getLines(invoice.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// GetAll returns the invoices of a guardian, or of every guardian if guardianID is 0, with the
+// given status, or every status if it is empty. Lines are left out.
+func (m InvoiceModel) GetAll(guardianID int64, status string) ([]*Invoice, error) {
+	return m.getAll(`WHERE (guardian_id = $1 OR $1 = 0) AND (status = $2 OR $2 = '')`, guardianID, status)
+}
+
+func (m InvoiceModel) getAll(where string, args ...any) ([]*Invoice, error) {
+	query := `
+		SELECT invoice_id, guardian_id, period_start, period_end, total_cents, status, due_on, issued_at
+		FROM invoices
+		` + where + `
+		ORDER BY period_start DESC, invoice_id DESC
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []*Invoice{}
+	for rows.Next() {
+		var (
+			invoice                       Invoice
+			periodStart, periodEnd, dueOn time.Time
+		)
+
+		err := rows.Scan(
+			&invoice.InvoiceID,
+			&invoice.GuardianID,
+			&periodStart,
+			&periodEnd,
+			&invoice.TotalCents,
+			&invoice.Status,
+			&dueOn,
+			&invoice.IssuedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		invoice.PeriodStart = Date(periodStart)
+		invoice.PeriodEnd = Date(periodEnd)
+		invoice.DueOn = Date(dueOn)
+		invoices = append(invoices, &invoice)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+func (m InvoiceModel) getLines(invoiceID int64) ([]*InvoiceLine, error) {
+	query := `
+		SELECT line_id, invoice_id, student_id, student_plan_id, late_fee_id, kind, description, quantity, unit_cents, amount_cents
+		FROM invoice_lines
+		WHERE invoice_id = $1
+		ORDER BY line_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []*InvoiceLine{}
+	for rows.Next() {
+		var line InvoiceLine
+		err := rows.Scan(
+			&line.LineID,
+			&line.InvoiceID,
+			&line.StudentID,
+			&line.StudentPlanID,
+			&line.LateFeeID,
+			&line.Kind,
+			&line.Description,
+			&line.Quantity,
+			&line.UnitCents,
+			&line.AmountCents,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, &line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
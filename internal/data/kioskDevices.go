@@ -0,0 +1,146 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"time"
+)
+
+type KioskDeviceModel struct {
+	DB *sql.DB
+}
+
+type KioskDevice struct {
+	DeviceID  int64      `json:"device_id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// New registers a kiosk device and returns it together with its plaintext token. Only a
+// SHA-256 hash of the token is stored, so the plaintext can't be recovered later.
+func (m KioskDeviceModel) New(name string) (*KioskDevice, string, error) {
+	randomBytes := make([]byte, 16)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	hash := sha256.Sum256([]byte(token))
+
+	query := `
+		INSERT INTO kiosk_devices (name, token_hash)
+		VALUES ($1, $2)
+		RETURNING device_id, created_at
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	device := &KioskDevice{Name: name}
+
+	err = m.DB.QueryRowContext(ctx, query, name, hash[:]).Scan(&device.DeviceID, &device.CreatedAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return device, token, nil
+}
+
+// GetByToken returns the device the plaintext token belongs to, as long as it hasn't been revoked.
+func (m KioskDeviceModel) GetByToken(token string) (*KioskDevice, error) {
+	hash := sha256.Sum256([]byte(token))
+
+	query := `
+		SELECT device_id, name, created_at
+		FROM kiosk_devices
+		WHERE token_hash = $1 AND revoked_at IS NULL
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var device KioskDevice
+
+	err := m.DB.QueryRowContext(ctx, query, hash[:]).Scan(&device.DeviceID, &device.Name, &device.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &device, nil
+}
+
+func (m KioskDeviceModel) GetAll() ([]*KioskDevice, error) {
+	query := `
+		SELECT device_id, name, created_at, revoked_at
+		FROM kiosk_devices
+		ORDER BY device_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []*KioskDevice{}
+	for rows.Next() {
+		var device KioskDevice
+		err := rows.Scan(&device.DeviceID, &device.Name, &device.CreatedAt, &device.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, &device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (m KioskDeviceModel) Revoke(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		UPDATE kiosk_devices
+		SET revoked_at = NOW()
+		WHERE device_id = $1 AND revoked_at IS NULL
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
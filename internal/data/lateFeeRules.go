@@ -0,0 +1,178 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type LateFeeRuleModel struct {
+	DB *sql.DB
+}
+
+// LateFeeRule says how families are charged for picking up after closing. ClosesAt is a "15:04"
+// time of day in the center's time zone. Pickups within GraceMinutes of closing are free, later
+// ones are charged RateCents for every minute, or for every started block of BlockMinutes,
+// since closing. A rule applies from EffectiveFrom until the next rule takes effect. Each server
+// runs a single center, like the classes and faculty it stores, so there is one rule in effect
+// at a time rather than one per center.
+type LateFeeRule struct {
+	RuleID        int64     `json:"rule_id"`
+	EffectiveFrom Date      `json:"effective_from"`
+	ClosesAt      string    `json:"closes_at"`
+	GraceMinutes  int       `json:"grace_minutes"`
+	Charge        string    `json:"charge"`
+	BlockMinutes  int       `json:"block_minutes"`
+	RateCents     int64     `json:"rate_cents"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Insert saves the rule. Another rule taking effect the same day returns
+// ErrDuplicateLateFeeRule.
+func (m LateFeeRuleModel) Insert(rule *LateFeeRule) error {
+	query := `
+		INSERT INTO late_fee_rules (effective_from, closes_at, grace_minutes, charge, block_minutes, rate_cents)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING rule_id, created_at
+		`
+	args := []any{time.Time(rule.EffectiveFrom), rule.ClosesAt, rule.GraceMinutes, rule.Charge, rule.BlockMinutes, rule.RateCents}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&rule.RuleID, &rule.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "late_fee_rules_effective_from_key"`:
+			return ErrDuplicateLateFeeRule
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m LateFeeRuleModel) Get(id int64) (*LateFeeRule, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	rules, err := m.getAll(`WHERE rule_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return rules[0], nil
+}
+
+// GetInEffect returns the rule that applies on the day of t, or ErrRecordNotFound if no rule has
+// taken effect yet.
+func (m LateFeeRuleModel) GetInEffect(t time.Time) (*LateFeeRule, error) {
+	rules, err := m.getAll(`
+		WHERE effective_from = (SELECT max(effective_from) FROM late_fee_rules WHERE effective_from <= $1)`,
+		dateOf(t))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return rules[0], nil
+}
+
+// GetAll returns every rule, the latest to take effect first.
+func (m LateFeeRuleModel) GetAll() ([]*LateFeeRule, error) {
+	return m.getAll(``)
+}
+
+func (m LateFeeRuleModel) getAll(where string, args ...any) ([]*LateFeeRule, error) {
+	query := `
+		SELECT rule_id, effective_from, to_char(closes_at, 'HH24:MI'), grace_minutes, charge, block_minutes, rate_cents, created_at
+		FROM late_fee_rules
+		` + where + `
+		ORDER BY effective_from DESC
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*LateFeeRule{}
+	for rows.Next() {
+		var (
+			rule          LateFeeRule
+			effectiveFrom time.Time
+		)
+
+		err := rows.Scan(
+			&rule.RuleID,
+			&effectiveFrom,
+			&rule.ClosesAt,
+			&rule.GraceMinutes,
+			&rule.Charge,
+			&rule.BlockMinutes,
+			&rule.RateCents,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rule.EffectiveFrom = Date(effectiveFrom)
+		rules = append(rules, &rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Delete removes a rule that hasn't taken effect yet. Rules that already apply are kept so that
+// the fees charged under them can be explained, and return ErrLateFeeRuleInEffect.
+func (m LateFeeRuleModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM late_fee_rules
+		WHERE rule_id = $1 AND effective_from > $2
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, dateOf(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		_, err := m.Get(id)
+		if err != nil {
+			return err
+		}
+		return ErrLateFeeRuleInEffect
+	}
+
+	return nil
+}
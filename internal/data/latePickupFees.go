@@ -0,0 +1,288 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type LatePickupFeeModel struct {
+	DB *sql.DB
+}
+
+// LatePickupFee is charged once per student and day when they are checked out after closing.
+// It is billed to the family by the next billing run, unless it is waived first.
+type LatePickupFee struct {
+	FeeID        int64     `json:"fee_id"`
+	StudentID    int64     `json:"student_id"`
+	FirstName    string    `json:"first_name,omitempty"`
+	LastName     string    `json:"last_name,omitempty"`
+	ClassDate    Date      `json:"class_date"`
+	RuleID       int64     `json:"rule_id"`
+	CheckedOutAt time.Time `json:"checked_out_at"`
+	MinutesLate  int       `json:"minutes_late"`
+	AmountCents  int64     `json:"amount_cents"`
+	Waived       bool      `json:"waived"`
+	Invoiced     bool      `json:"invoiced"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// BillableLateFee is a late pickup fee as seen by a billing run, together with the guardian who
+// is billed for it.
+type BillableLateFee struct {
+	LatePickupFee
+	GuardianID int64
+}
+
+// LatePickupSummary totals a student's late pickups over a range of days.
+type LatePickupSummary struct {
+	StudentID   int64  `json:"student_id"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	LatePickups int    `json:"late_pickups"`
+	MinutesLate int    `json:"minutes_late"`
+	AmountCents int64  `json:"amount_cents"`
+	WaivedCents int64  `json:"waived_cents"`
+}
+
+// lateFeeBilled selects the line billing fee f on an invoice that hasn't been voided. Lines on
+// voided invoices don't count, so the fee is billed again.
+const lateFeeBilled = `
+	SELECT 1
+	FROM invoice_lines il
+	INNER JOIN invoices i ON il.invoice_id = i.invoice_id
+	WHERE il.late_fee_id = f.fee_id AND il.kind = 'late_pickup' AND i.status <> 'void'`
+
+// Insert saves the fee. A student is only charged once a day, so a fee for a day the student
+// was already charged for is ignored and reported as not inserted.
+func (m LatePickupFeeModel) Insert(fee *LatePickupFee) (bool, error) {
+	query := `
+		INSERT INTO late_pickup_fees (student_id, class_date, rule_id, checked_out_at, minutes_late, amount_cents)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (student_id, class_date) DO NOTHING
+		RETURNING fee_id, created_at
+		`
+	args := []any{fee.StudentID, time.Time(fee.ClassDate), fee.RuleID, fee.CheckedOutAt, fee.MinutesLate, fee.AmountCents}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&fee.FeeID, &fee.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// GetAll returns the late pickups between from and to, both included, in the order they
+// happened.
+func (m LatePickupFeeModel) GetAll(from, to time.Time) ([]*LatePickupFee, error) {
+	query := `
+		SELECT f.fee_id, f.student_id, s.first_name, s.last_name, f.class_date, f.rule_id, f.checked_out_at,
+			f.minutes_late, f.amount_cents, f.waived,
+			EXISTS (` + lateFeeBilled + `),
+			f.created_at
+		FROM late_pickup_fees f
+		INNER JOIN students s ON f.student_id = s.student_id
+		WHERE f.class_date BETWEEN $1 AND $2
+		ORDER BY f.checked_out_at, f.fee_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, dateOf(from), dateOf(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fees := []*LatePickupFee{}
+	for rows.Next() {
+		fee, err := scanLatePickupFee(rows)
+		if err != nil {
+			return nil, err
+		}
+		fees = append(fees, fee)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fees, nil
+}
+
+// GetSummaries totals the late pickups of every student who was picked up late between from and
+// to, both included, the most often late first.
+func (m LatePickupFeeModel) GetSummaries(from, to time.Time) ([]*LatePickupSummary, error) {
+	query := `
+		SELECT s.student_id, s.first_name, s.last_name, count(*), sum(f.minutes_late),
+			COALESCE(sum(f.amount_cents) FILTER (WHERE NOT f.waived), 0),
+			COALESCE(sum(f.amount_cents) FILTER (WHERE f.waived), 0)
+		FROM late_pickup_fees f
+		INNER JOIN students s ON f.student_id = s.student_id
+		WHERE f.class_date BETWEEN $1 AND $2
+		GROUP BY s.student_id
+		ORDER BY count(*) DESC, s.last_name, s.first_name, s.student_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, dateOf(from), dateOf(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []*LatePickupSummary{}
+	for rows.Next() {
+		var summary LatePickupSummary
+		err := rows.Scan(
+			&summary.StudentID,
+			&summary.FirstName,
+			&summary.LastName,
+			&summary.LatePickups,
+			&summary.MinutesLate,
+			&summary.AmountCents,
+			&summary.WaivedCents,
+		)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, &summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+// GetBillable returns the fees up to and including the day to that have been neither waived nor
+// billed on an invoice that is still in effect, ordered by the family billed for them. Students
+// without a family have a GuardianID of 0.
+func (m LatePickupFeeModel) GetBillable(to time.Time) ([]*BillableLateFee, error) {
+	query := `
+		SELECT f.fee_id, f.student_id, s.first_name, s.last_name, f.class_date, f.rule_id, f.checked_out_at,
+			f.minutes_late, f.amount_cents, f.waived, false, f.created_at,
+			COALESCE(s.family_id, 0) AS guardian_id
+		FROM late_pickup_fees f
+		INNER JOIN students s ON f.student_id = s.student_id
+		WHERE f.class_date <= $1 AND NOT f.waived AND f.amount_cents > 0
+			AND NOT EXISTS (` + lateFeeBilled + `)
+		ORDER BY guardian_id, f.class_date, f.fee_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, dateOf(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fees := []*BillableLateFee{}
+	for rows.Next() {
+		var guardianID int64
+		fee, err := scanLatePickupFee(rows, &guardianID)
+		if err != nil {
+			return nil, err
+		}
+		fees = append(fees, &BillableLateFee{LatePickupFee: *fee, GuardianID: guardianID})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fees, nil
+}
+
+// Waive lets the family off the fee. Fees that were already billed return
+// ErrLateFeeInvoiced, they have to be credited on the family's account instead.
+func (m LatePickupFeeModel) Waive(id int64) (*LatePickupFee, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		UPDATE late_pickup_fees f
+		SET waived = true
+		FROM students s
+		WHERE f.fee_id = $1 AND f.student_id = s.student_id
+			AND NOT EXISTS (` + lateFeeBilled + `)
+		RETURNING f.fee_id, f.student_id, s.first_name, s.last_name, f.class_date, f.rule_id, f.checked_out_at,
+			f.minutes_late, f.amount_cents, f.waived, false, f.created_at
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		var exists bool
+		err := m.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM late_pickup_fees WHERE fee_id = $1)`, id).Scan(&exists)
+		switch {
+		case err != nil:
+			return nil, err
+		case !exists:
+			return nil, ErrRecordNotFound
+		default:
+			return nil, ErrLateFeeInvoiced
+		}
+	}
+
+	return scanLatePickupFee(rows)
+}
+
+// scanLatePickupFee reads a fee from the row, followed by any extra columns into extra.
+func scanLatePickupFee(rows *sql.Rows, extra ...any) (*LatePickupFee, error) {
+	var (
+		fee       LatePickupFee
+		classDate time.Time
+	)
+
+	dest := []any{
+		&fee.FeeID,
+		&fee.StudentID,
+		&fee.FirstName,
+		&fee.LastName,
+		&classDate,
+		&fee.RuleID,
+		&fee.CheckedOutAt,
+		&fee.MinutesLate,
+		&fee.AmountCents,
+		&fee.Waived,
+		&fee.Invoiced,
+		&fee.CreatedAt,
+	}
+
+	err := rows.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	fee.ClassDate = Date(classDate)
+
+	return &fee, nil
+}
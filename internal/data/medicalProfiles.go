@@ -0,0 +1,149 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type MedicalProfileModel struct {
+	DB *sql.DB
+}
+
+// MedicalProfile is what staff need to know about a student's health. Allergies are kept
+// separately, one row each, so that rosters and menus can be checked against them.
+type MedicalProfile struct {
+	StudentID             int64      `json:"student_id"`
+	Allergies             []*Allergy `json:"allergies"`
+	Conditions            string     `json:"conditions"`
+	Medications           string     `json:"medications"`
+	DietaryRestrictions   string     `json:"dietary_restrictions"`
+	PhysicianName         string     `json:"physician_name"`
+	PhysicianPhone        string     `json:"physician_phone"`
+	InsuranceProvider     string     `json:"insurance_provider"`
+	InsurancePolicyNumber string     `json:"insurance_policy_number"`
+	UpdatedBy             *int64     `json:"updated_by"`
+	UpdatedAt             *time.Time `json:"updated_at"`
+}
+
+// Get returns the student's medical profile, which is empty if nothing has been recorded yet.
+func (m MedicalProfileModel) Get(studentID int64) (*MedicalProfile, error) {
+	query := `
+		SELECT conditions, medications, dietary_restrictions, physician_name, physician_phone,
+			insurance_provider, insurance_policy_number, updated_by, updated_at
+		FROM student_medical_profiles
+		WHERE student_id = $1
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	profile := MedicalProfile{StudentID: studentID}
+	var updatedAt time.Time
+
+	err := m.DB.QueryRowContext(ctx, query, studentID).Scan(
+		&profile.Conditions,
+		&profile.Medications,
+		&profile.DietaryRestrictions,
+		&profile.PhysicianName,
+		&profile.PhysicianPhone,
+		&profile.InsuranceProvider,
+		&profile.InsurancePolicyNumber,
+		&profile.UpdatedBy,
+		&updatedAt,
+	)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, err
+	default:
+		profile.UpdatedAt = &updatedAt
+	}
+
+	profile.Allergies, err = AllergyModel{DB: m.DB}.GetAllByStudentID(studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// GetDietaryRestrictionsByClassID returns the dietary restrictions of the students in the class
+// who have any, by student ID.
+func (m MedicalProfileModel) GetDietaryRestrictionsByClassID(classID int64) (map[int64]string, error) {
+	query := `
+		SELECT student_id, dietary_restrictions
+		FROM student_medical_profiles
+		WHERE student_id IN (SELECT student_id FROM class_students WHERE class_id = $1)
+			AND dietary_restrictions <> ''
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	restrictions := map[int64]string{}
+	for rows.Next() {
+		var (
+			studentID   int64
+			restriction string
+		)
+		err := rows.Scan(&studentID, &restriction)
+		if err != nil {
+			return nil, err
+		}
+		restrictions[studentID] = restriction
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return restrictions, nil
+}
+
+// Upsert saves the profile, creating it the first time.
+func (m MedicalProfileModel) Upsert(profile *MedicalProfile) error {
+	query := `
+		INSERT INTO student_medical_profiles (student_id, conditions, medications, dietary_restrictions, physician_name,
+			physician_phone, insurance_provider, insurance_policy_number, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (student_id) DO UPDATE
+		SET conditions = EXCLUDED.conditions, medications = EXCLUDED.medications,
+			dietary_restrictions = EXCLUDED.dietary_restrictions, physician_name = EXCLUDED.physician_name,
+			physician_phone = EXCLUDED.physician_phone, insurance_provider = EXCLUDED.insurance_provider,
+			insurance_policy_number = EXCLUDED.insurance_policy_number, updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+		RETURNING updated_at
+		`
+	args := []any{
+		profile.StudentID,
+		profile.Conditions,
+		profile.Medications,
+		profile.DietaryRestrictions,
+		profile.PhysicianName,
+		profile.PhysicianPhone,
+		profile.InsuranceProvider,
+		profile.InsurancePolicyNumber,
+		profile.UpdatedBy,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var updatedAt time.Time
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&updatedAt)
+	if err != nil {
+		return err
+	}
+
+	profile.UpdatedAt = &updatedAt
+
+	return nil
+}
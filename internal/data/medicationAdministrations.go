@@ -0,0 +1,174 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type MedicationAdministrationModel struct {
+	DB *sql.DB
+}
+
+// MedicationAdministration is a dose of a medication given to a student under an authorization.
+type MedicationAdministration struct {
+	AdministrationID int64     `json:"administration_id"`
+	AuthorizationID  int64     `json:"authorization_id"`
+	StudentID        int64     `json:"student_id"`
+	GivenAt          time.Time `json:"given_at"`
+	GivenBy          *int64    `json:"given_by"`
+	DoseGiven        string    `json:"dose_given"`
+	Notes            string    `json:"notes"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// sameMedication selects the authorizations of student $1 for medication $2, however it was
+// capitalized, so that doses are checked against every authorization for the medication.
+const sameMedication = `
+	SELECT authorization_id FROM medication_authorizations
+	WHERE student_id = $1 AND lower(trim(medication)) = lower(trim($2))`
+
+// Insert records a dose. The student is locked while the dose is checked so two staff members
+// giving the same dose at once can't both record it: the authorization has to be signed, not
+// revoked and cover the day, no other dose of the medication may be within the minimum interval
+// of this one, and the daily maximum mustn't have been reached. Doses given under any other
+// authorization of the student for the same medication count too.
+func (m MedicationAdministrationModel) Insert(administration *MedicationAdministration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `SELECT 1 FROM students WHERE student_id = $1 FOR UPDATE`, administration.StudentID)
+	if err != nil {
+		return err
+	}
+
+	day := dateOf(administration.GivenAt)
+
+	var medication string
+	var minInterval, maxDaily int
+	var active bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT medication, min_interval_minutes, max_daily_doses,
+			signed_at IS NOT NULL AND revoked_at IS NULL AND $3 BETWEEN starts_on AND ends_on
+		FROM medication_authorizations
+		WHERE authorization_id = $1 AND student_id = $2`,
+		administration.AuthorizationID, administration.StudentID, day).Scan(&medication, &minInterval, &maxDaily, &active)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	if !active {
+		return ErrAuthorizationNotActive
+	}
+
+	interval := time.Duration(minInterval) * time.Minute
+	var tooSoon bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM medication_administrations
+			WHERE authorization_id IN (`+sameMedication+`) AND given_at > $3 AND given_at < $4
+		)`, administration.StudentID, medication, administration.GivenAt.Add(-interval), administration.GivenAt.Add(interval)).Scan(&tooSoon)
+	if err != nil {
+		return err
+	}
+
+	if tooSoon {
+		return ErrDoseTooSoon
+	}
+
+	var given int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM medication_administrations
+		WHERE authorization_id IN (`+sameMedication+`) AND given_at >= $3 AND given_at < $4`,
+		administration.StudentID, medication, day, day.AddDate(0, 0, 1)).Scan(&given)
+	if err != nil {
+		return err
+	}
+
+	if given >= maxDaily {
+		return ErrDailyDosesGiven
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO medication_administrations (authorization_id, student_id, given_at, given_by, dose_given, notes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING administration_id, created_at`,
+		administration.AuthorizationID,
+		administration.StudentID,
+		administration.GivenAt,
+		administration.GivenBy,
+		administration.DoseGiven,
+		administration.Notes,
+	).Scan(&administration.AdministrationID, &administration.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m MedicationAdministrationModel) GetAllByAuthorizationID(authorizationID int64) ([]*MedicationAdministration, error) {
+	return m.getAll(`WHERE authorization_id = $1`, authorizationID)
+}
+
+// GetAllByClassID returns the doses given to students in the class between from and to.
+func (m MedicationAdministrationModel) GetAllByClassID(classID int64, from, to time.Time) ([]*MedicationAdministration, error) {
+	return m.getAll(`
+		WHERE student_id IN (SELECT student_id FROM class_students WHERE class_id = $1)
+			AND given_at >= $2 AND given_at < $3`, classID, from, to)
+}
+
+func (m MedicationAdministrationModel) getAll(where string, args ...any) ([]*MedicationAdministration, error) {
+	query := `
+		SELECT administration_id, authorization_id, student_id, given_at, given_by, dose_given, notes, created_at
+		FROM medication_administrations
+		` + where + `
+		ORDER BY given_at, administration_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	administrations := []*MedicationAdministration{}
+	for rows.Next() {
+		var administration MedicationAdministration
+		err := rows.Scan(
+			&administration.AdministrationID,
+			&administration.AuthorizationID,
+			&administration.StudentID,
+			&administration.GivenAt,
+			&administration.GivenBy,
+			&administration.DoseGiven,
+			&administration.Notes,
+			&administration.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		administrations = append(administrations, &administration)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return administrations, nil
+}
@@ -0,0 +1,259 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type MedicationAuthorizationModel struct {
+	DB *sql.DB
+}
+
+// MedicationAuthorization is a guardian's permission for staff to give a student a medication
+// between two days. It is entered by staff and has to be signed by a guardian of the student
+// before any dose can be given. Times are the times of day, as HH:MM, doses are due at. A
+// medication given only when needed has none.
+type MedicationAuthorization struct {
+	AuthorizationID    int64      `json:"authorization_id"`
+	StudentID          int64      `json:"student_id"`
+	Medication         string     `json:"medication"`
+	Dosage             string     `json:"dosage"`
+	Route              string     `json:"route"`
+	Instructions       string     `json:"instructions"`
+	Times              []string   `json:"times"`
+	MinIntervalMinutes int        `json:"min_interval_minutes"`
+	MaxDailyDoses      int        `json:"max_daily_doses"`
+	StartsOn           Date       `json:"starts_on"`
+	EndsOn             Date       `json:"ends_on"`
+	SignedBy           *int64     `json:"signed_by"`
+	SignedAt           *time.Time `json:"signed_at"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy          *int64     `json:"created_by"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// ClassMedication is an authorization of a student in a class, with the student's name.
+type ClassMedication struct {
+	MedicationAuthorization
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// Active reports whether doses may be given under the authorization on the day of t.
+func (a *MedicationAuthorization) Active(t time.Time) bool {
+	day := t.Format("2006-01-02")
+	return a.SignedAt != nil && a.RevokedAt == nil &&
+		day >= time.Time(a.StartsOn).Format("2006-01-02") && day <= time.Time(a.EndsOn).Format("2006-01-02")
+}
+
+func (m MedicationAuthorizationModel) Insert(authorization *MedicationAuthorization) error {
+	query := `
+		INSERT INTO medication_authorizations (student_id, medication, dosage, route, instructions, times,
+			min_interval_minutes, max_daily_doses, starts_on, ends_on, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING authorization_id, created_at
+		`
+	args := []any{
+		authorization.StudentID,
+		authorization.Medication,
+		authorization.Dosage,
+		authorization.Route,
+		authorization.Instructions,
+		strings.Join(authorization.Times, ","),
+		authorization.MinIntervalMinutes,
+		authorization.MaxDailyDoses,
+		time.Time(authorization.StartsOn),
+		time.Time(authorization.EndsOn),
+		authorization.CreatedBy,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&authorization.AuthorizationID, &authorization.CreatedAt)
+}
+
+func (m MedicationAuthorizationModel) Get(studentID, id int64) (*MedicationAuthorization, error) {
+	if studentID < 1 || id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	authorizations, err := m.getAll(`WHERE ma.student_id = $1 AND ma.authorization_id = $2`, studentID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(authorizations) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return &authorizations[0].MedicationAuthorization, nil
+}
+
+func (m MedicationAuthorizationModel) GetAllByStudentID(studentID int64) ([]*MedicationAuthorization, error) {
+	medications, err := m.getAll(`WHERE ma.student_id = $1`, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizations := make([]*MedicationAuthorization, len(medications))
+	for i, medication := range medications {
+		authorizations[i] = &medication.MedicationAuthorization
+	}
+
+	return authorizations, nil
+}
+
+// GetPendingByGuardianID returns the authorizations for the guardian's children that are waiting
+// for a guardian's signature and haven't ended yet.
+func (m MedicationAuthorizationModel) GetPendingByGuardianID(guardianID int64) ([]*ClassMedication, error) {
+	return m.getAll(`
+		WHERE ma.signed_at IS NULL AND ma.revoked_at IS NULL AND ma.ends_on >= CURRENT_DATE
+			AND ma.student_id IN (SELECT student_id FROM student_guardian WHERE guardian_id = $1)`, guardianID)
+}
+
+// GetActiveByClassID returns the signed authorizations of students in the class that cover the
+// day.
+func (m MedicationAuthorizationModel) GetActiveByClassID(classID int64, day time.Time) ([]*ClassMedication, error) {
+	return m.getAll(`
+		WHERE ma.signed_at IS NOT NULL AND ma.revoked_at IS NULL AND $2 BETWEEN ma.starts_on AND ma.ends_on
+			AND ma.student_id IN (SELECT student_id FROM class_students WHERE class_id = $1)`, classID, dateOf(day))
+}
+
+func (m MedicationAuthorizationModel) getAll(where string, args ...any) ([]*ClassMedication, error) {
+	query := `
+		SELECT ma.authorization_id, ma.student_id, ma.medication, ma.dosage, ma.route, ma.instructions, ma.times,
+			ma.min_interval_minutes, ma.max_daily_doses, ma.starts_on, ma.ends_on, ma.signed_by, ma.signed_at,
+			ma.revoked_at, ma.created_by, ma.created_at, s.first_name, s.last_name
+		FROM medication_authorizations ma
+		INNER JOIN students s ON ma.student_id = s.student_id
+		` + where + `
+		ORDER BY s.last_name, s.first_name, ma.authorization_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	medications := []*ClassMedication{}
+	for rows.Next() {
+		var (
+			medication ClassMedication
+			times      string
+			startsOn   time.Time
+			endsOn     time.Time
+			signedAt   sql.NullTime
+			revokedAt  sql.NullTime
+		)
+		err := rows.Scan(
+			&medication.AuthorizationID,
+			&medication.StudentID,
+			&medication.Medication,
+			&medication.Dosage,
+			&medication.Route,
+			&medication.Instructions,
+			&times,
+			&medication.MinIntervalMinutes,
+			&medication.MaxDailyDoses,
+			&startsOn,
+			&endsOn,
+			&medication.SignedBy,
+			&signedAt,
+			&revokedAt,
+			&medication.CreatedBy,
+			&medication.CreatedAt,
+			&medication.FirstName,
+			&medication.LastName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		medication.Times = []string{}
+		if times != "" {
+			medication.Times = strings.Split(times, ",")
+		}
+		medication.StartsOn = Date(startsOn)
+		medication.EndsOn = Date(endsOn)
+		if signedAt.Valid {
+			medication.SignedAt = &signedAt.Time
+		}
+		if revokedAt.Valid {
+			medication.RevokedAt = &revokedAt.Time
+		}
+
+		medications = append(medications, &medication)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return medications, nil
+}
+
+// Sign records the guardian's signature on the authorization. Only authorizations that are
+// neither signed nor revoked can be signed, ErrAuthorizationNotPending is returned otherwise.
+func (m MedicationAuthorizationModel) Sign(authorization *MedicationAuthorization, guardianID int64) error {
+	query := `
+		UPDATE medication_authorizations
+		SET signed_by = $1, signed_at = NOW()
+		WHERE authorization_id = $2 AND signed_at IS NULL AND revoked_at IS NULL
+		RETURNING signed_at
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var signedAt time.Time
+	err := m.DB.QueryRowContext(ctx, query, guardianID, authorization.AuthorizationID).Scan(&signedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrAuthorizationNotPending
+		default:
+			return err
+		}
+	}
+
+	authorization.SignedBy = &guardianID
+	authorization.SignedAt = &signedAt
+
+	return nil
+}
+
+// Revoke stops any more doses being given under the authorization.
+func (m MedicationAuthorizationModel) Revoke(authorization *MedicationAuthorization) error {
+	query := `
+		UPDATE medication_authorizations
+		SET revoked_at = NOW()
+		WHERE authorization_id = $1 AND revoked_at IS NULL
+		RETURNING revoked_at
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var revokedAt time.Time
+	err := m.DB.QueryRowContext(ctx, query, authorization.AuthorizationID).Scan(&revokedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrAuthorizationRevoked
+		default:
+			return err
+		}
+	}
+
+	authorization.RevokedAt = &revokedAt
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type MenuItemModel struct {
+	DB *sql.DB
+}
+
+// MenuItem is something served at a meal on a day. Allergens are tags from the allergen
+// vocabulary, such as "milk" or "tree_nut", that are matched against students' allergies.
+type MenuItem struct {
+	ItemID    int64     `json:"item_id"`
+	ServedOn  Date      `json:"served_on"`
+	Meal      string    `json:"meal"`
+	Name      string    `json:"name"`
+	Allergens []string  `json:"allergens"`
+	CreatedBy *int64    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// byMeal orders menu items by when in the day they are served.
+const byMeal = `
+	CASE meal WHEN 'breakfast' THEN 0 WHEN 'am_snack' THEN 1 WHEN 'lunch' THEN 2 WHEN 'pm_snack' THEN 3 ELSE 4 END`
+
+func (m MenuItemModel) Insert(item *MenuItem) error {
+	query := `
+		INSERT INTO menu_items (served_on, meal, name, allergens, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING item_id, created_at
+		`
+	args := []any{time.Time(item.ServedOn), item.Meal, item.Name, strings.Join(item.Allergens, ","), item.CreatedBy}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&item.ItemID, &item.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "menu_items_served_on_meal_name_idx"`:
+			return ErrDuplicateMenuItem
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m MenuItemModel) Get(id int64) (*MenuItem, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	items, err := m.getAll(`WHERE item_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return items[0], nil
+}
+
+// GetAll returns the items served from the day of from up to and including the day of to, in
+// the order they are served.
+func (m MenuItemModel) GetAll(from, to time.Time) ([]*MenuItem, error) {
+	return m.getAll(`WHERE served_on BETWEEN $1 AND $2`, dateOf(from), dateOf(to))
+}
+
+func (m MenuItemModel) getAll(where string, args ...any) ([]*MenuItem, error) {
+	query := `
+		SELECT item_id, served_on, meal, name, allergens, created_by, created_at
+		FROM menu_items
+		` + where + `
+		ORDER BY served_on, ` + byMeal + `, name
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*MenuItem{}
+	for rows.Next() {
+		var (
+			item      MenuItem
+			allergens string
+		)
+		err := rows.Scan(
+			&item.ItemID,
+			&item.ServedOn,
+			&item.Meal,
+			&item.Name,
+			&allergens,
+			&item.CreatedBy,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Allergens = []string{}
+		if allergens != "" {
+			item.Allergens = strings.Split(allergens, ",")
+		}
+
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (m MenuItemModel) Update(item *MenuItem) error {
+	query := `
+		UPDATE menu_items
+		SET served_on = $1, meal = $2, name = $3, allergens = $4
+		WHERE item_id = $5
+		RETURNING item_id
+		`
+	args := []any{time.Time(item.ServedOn), item.Meal, item.Name, strings.Join(item.Allergens, ","), item.ItemID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&item.ItemID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		case err.Error() == `pq: duplicate key value violates unique constraint "menu_items_served_on_meal_name_idx"`:
+			return ErrDuplicateMenuItem
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m MenuItemModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM menu_items WHERE item_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type MenuSubstitutionModel struct {
+	DB *sql.DB
+}
+
+// MenuSubstitution is what a student is served instead of a menu item, usually because of an
+// allergy.
+type MenuSubstitution struct {
+	SubstitutionID int64     `json:"substitution_id"`
+	ItemID         int64     `json:"item_id"`
+	StudentID      int64     `json:"student_id"`
+	Substitute     string    `json:"substitute"`
+	Notes          string    `json:"notes"`
+	RecordedBy     *int64    `json:"recorded_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Upsert records the substitution, replacing the one the student already had for the item.
+func (m MenuSubstitutionModel) Upsert(substitution *MenuSubstitution) error {
+	query := `
+		INSERT INTO menu_substitutions (item_id, student_id, substitute, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (item_id, student_id) DO UPDATE
+		SET substitute = EXCLUDED.substitute, notes = EXCLUDED.notes, recorded_by = EXCLUDED.recorded_by, created_at = NOW()
+		RETURNING substitution_id, created_at
+		`
+	args := []any{substitution.ItemID, substitution.StudentID, substitution.Substitute, substitution.Notes, substitution.RecordedBy}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&substitution.SubstitutionID, &substitution.CreatedAt)
+}
+
+func (m MenuSubstitutionModel) GetAllByItemID(itemID int64) ([]*MenuSubstitution, error) {
+	return m.getAll(`WHERE item_id = $1`, itemID)
+}
+
+// GetAllByClassID returns the substitutions of students in the class for items served on the
+// day.
+func (m MenuSubstitutionModel) GetAllByClassID(classID int64, day time.Time) ([]*MenuSubstitution, error) {
+	return m.getAll(`
+		WHERE student_id IN (SELECT student_id FROM class_students WHERE class_id = $1)
+		AND item_id IN (SELECT item_id FROM menu_items WHERE served_on = $2)`, classID, dateOf(day))
+}
+
+func (m MenuSubstitutionModel) getAll(where string, args ...any) ([]*MenuSubstitution, error) {
+	query := `
+		SELECT substitution_id, item_id, student_id, substitute, notes, recorded_by, created_at
+		FROM menu_substitutions
+		` + where + `
+		ORDER BY item_id, student_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	substitutions := []*MenuSubstitution{}
+	for rows.Next() {
+		var substitution MenuSubstitution
+		err := rows.Scan(
+			&substitution.SubstitutionID,
+			&substitution.ItemID,
+			&substitution.StudentID,
+			&substitution.Substitute,
+			&substitution.Notes,
+			&substitution.RecordedBy,
+			&substitution.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		substitutions = append(substitutions, &substitution)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return substitutions, nil
+}
+
+func (m MenuSubstitutionModel) Delete(itemID, id int64) error {
+	if itemID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM menu_substitutions WHERE item_id = $1 AND substitution_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, itemID, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -9,8 +9,66 @@ var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrEditConflict   = errors.New("edit conflict")
 	ErrDuplicateEmail = errors.New("duplicate email")
+
+	ErrFacultyHasClasses   = errors.New("faculty member still has classes")
+	ErrInvalidReassignment = errors.New("classes can only be reassigned to another active faculty member")
+	ErrLastLeadTeacher     = errors.New("the class must keep a lead teacher")
+	ErrGrantNotPending     = errors.New("the coverage request has already been decided")
+
+	ErrDuplicateInvoice    = errors.New("the family has already been invoiced for the period or part of it")
+	ErrInvoiceNotOpen      = errors.New("only open invoices can be voided")
+	ErrRefundExceedsCredit = errors.New("the refund is more than the family's credit")
+	ErrDuplicatePayment    = errors.New("the payment has already been recorded")
+
+	ErrDuplicateAgencyCode      = errors.New("another agency already uses this code")
+	ErrOverlappingAuthorization = errors.New("the student already has an authorization with the agency for some of these days")
+
+	ErrDuplicateLateFeeRule = errors.New("another late fee rule already takes effect on this day")
+	ErrLateFeeRuleInEffect  = errors.New("late fee rules that have taken effect cannot be deleted")
+	ErrLateFeeInvoiced      = errors.New("the fee has already been invoiced, credit the family's account instead")
+
+	ErrAlreadyClockedIn = errors.New("already clocked in")
+	ErrNotClockedIn     = errors.New("not clocked in")
+	ErrAlreadyOnBreak   = errors.New("already on a break")
+	ErrNotOnBreak       = errors.New("not on a break")
+
+	ErrDuplicateAllergy       = errors.New("the student already has an allergy to this allergen with these details")
+	ErrDuplicateScheduledDose = errors.New("the schedule already has this dose of the vaccine")
+	ErrDuplicateImmunization  = errors.New("this dose of the vaccine has already been recorded for the student")
+
+	ErrAuthorizationNotPending = errors.New("the authorization has already been signed or revoked")
+	ErrAuthorizationRevoked    = errors.New("the authorization has already been revoked")
+	ErrAuthorizationNotActive  = errors.New("the authorization isn't signed, has been revoked or doesn't cover the day")
+	ErrDoseTooSoon             = errors.New("another dose was given too close to this one")
+	ErrDailyDosesGiven         = errors.New("every dose allowed for the day has already been given")
+
+	ErrIncidentNotDraft     = errors.New("the incident has already been submitted")
+	ErrIncidentNotSubmitted = errors.New("the incident hasn't been submitted or has already been acknowledged")
+
+	ErrDuplicateMenuItem = errors.New("the meal already has an item with this name on this day")
+
+	ErrAlreadyAsleep = errors.New("the student is already asleep")
+	ErrNotAsleep     = errors.New("the student has already woken up")
+
+	ErrDuplicateContactPriority = errors.New("the student already has an emergency contact with this priority")
+
+	ErrFacultyHasTimeEntries = errors.New("faculty member has recorded time entries")
+
+	ErrUnknownFamily = errors.New("the family must be billed through one of the student's guardians or be an existing family")
 )
 
+// EventPublisher is told about attendance, enrollment and sleep changes so they can be pushed
+// to connected clients. Models work without one, a nil publisher is simply skipped.
+type EventPublisher interface {
+	Publish(classID int64, eventType string, data any)
+}
+
+func publish(events EventPublisher, classID int64, eventType string, data any) {
+	if events != nil {
+		events.Publish(classID, eventType, data)
+	}
+}
+
 type Models struct {
 	Students          StudentModel
 	Guardians         GuardianModel
@@ -19,16 +77,86 @@ type Models struct {
 	Classes           ClassModel
 	ClassStudents     ClassStudentsModel
 	StudentAttendance StudentAttendanceModel
+	AbsenceAlerts     AbsenceAlertModel
+	Notifications     NotificationModel
+	KioskDevices      KioskDeviceModel
+	TimeEntries       TimeEntryModel
+	Shifts            ShiftModel
+	TimeOff           TimeOffModel
+	ClassSessions     ClassSessionModel
+	Credentials       FacultyCredentialModel
+	ClassFaculty      ClassFacultyModel
+	Coverage          CoverageGrantModel
+	TuitionPlans      TuitionPlanModel
+	StudentPlans      StudentPlanModel
+	Invoices          InvoiceModel
+	Payments          PaymentModel
+	Adjustments       AccountAdjustmentModel
+	PaymentIntents    PaymentIntentModel
+	Families          FamilyModel
+	SubsidyAgencies   SubsidyAgencyModel
+	Subsidies         SubsidyAuthorizationModel
+	LateFeeRules      LateFeeRuleModel
+	LateFees          LatePickupFeeModel
+	DiscountRules     DiscountRuleModel
+	MedicalProfiles   MedicalProfileModel
+	Allergies         AllergyModel
+	Immunizations     ImmunizationModel
+	Exemptions        ImmunizationExemptionModel
+	DoseSchedule      ImmunizationScheduleModel
+	Medications       MedicationAuthorizationModel
+	MedicationDoses   MedicationAdministrationModel
+	Incidents         IncidentModel
+	DailyLogs         DailyLogModel
+	MenuItems         MenuItemModel
+	Substitutions     MenuSubstitutionModel
+	Sleep             SleepSessionModel
+	EmergencyContacts EmergencyContactModel
 }
 
-func NewModels(db *sql.DB) Models {
+func NewModels(db *sql.DB, events EventPublisher) Models {
 	return Models{
 		Students:          StudentModel{DB: db},
 		Guardians:         GuardianModel{DB: db},
 		StudentGuardian:   StudentGuardianModel{DB: db},
 		Faculty:           FacultyModel{DB: db},
 		Classes:           ClassModel{DB: db},
-		ClassStudents:     ClassStudentsModel{DB: db},
-		StudentAttendance: StudentAttendanceModel{DB: db},
+		ClassStudents:     ClassStudentsModel{DB: db, Events: events},
+		StudentAttendance: StudentAttendanceModel{DB: db, Events: events},
+		AbsenceAlerts:     AbsenceAlertModel{DB: db},
+		Notifications:     NotificationModel{DB: db},
+		KioskDevices:      KioskDeviceModel{DB: db},
+		TimeEntries:       TimeEntryModel{DB: db},
+		Shifts:            ShiftModel{DB: db},
+		TimeOff:           TimeOffModel{DB: db},
+		ClassSessions:     ClassSessionModel{DB: db},
+		Credentials:       FacultyCredentialModel{DB: db},
+		ClassFaculty:      ClassFacultyModel{DB: db},
+		Coverage:          CoverageGrantModel{DB: db},
+		TuitionPlans:      TuitionPlanModel{DB: db},
+		StudentPlans:      StudentPlanModel{DB: db},
+		Invoices:          InvoiceModel{DB: db},
+		Payments:          PaymentModel{DB: db},
+		Adjustments:       AccountAdjustmentModel{DB: db},
+		PaymentIntents:    PaymentIntentModel{DB: db},
+		Families:          FamilyModel{DB: db},
+		SubsidyAgencies:   SubsidyAgencyModel{DB: db},
+		Subsidies:         SubsidyAuthorizationModel{DB: db},
+		LateFeeRules:      LateFeeRuleModel{DB: db},
+		LateFees:          LatePickupFeeModel{DB: db},
+		DiscountRules:     DiscountRuleModel{DB: db},
+		MedicalProfiles:   MedicalProfileModel{DB: db},
+		Allergies:         AllergyModel{DB: db},
+		Immunizations:     ImmunizationModel{DB: db},
+		Exemptions:        ImmunizationExemptionModel{DB: db},
+		DoseSchedule:      ImmunizationScheduleModel{DB: db},
+		Medications:       MedicationAuthorizationModel{DB: db},
+		MedicationDoses:   MedicationAdministrationModel{DB: db},
+		Incidents:         IncidentModel{DB: db},
+		DailyLogs:         DailyLogModel{DB: db},
+		MenuItems:         MenuItemModel{DB: db},
+		Substitutions:     MenuSubstitutionModel{DB: db},
+		Sleep:             SleepSessionModel{DB: db, Events: events},
+		EmergencyContacts: EmergencyContactModel{DB: db},
 	}
 }
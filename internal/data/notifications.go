@@ -0,0 +1,107 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type NotificationModel struct {
+	DB *sql.DB
+}
+
+type Notification struct {
+	NotificationID int64      `json:"notification_id"`
+	FacultyID      int64      `json:"faculty_id"`
+	Subject        string     `json:"subject"`
+	Message        string     `json:"message"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+}
+
+func (m NotificationModel) Insert(notification *Notification) error {
+	query := `
+		INSERT INTO notifications (faculty_id, subject, message)
+		VALUES ($1, $2, $3)
+		RETURNING notification_id, created_at
+		`
+	args := []any{notification.FacultyID, notification.Subject, notification.Message}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&notification.NotificationID, &notification.CreatedAt)
+}
+
+func (m NotificationModel) GetAllByFacultyID(facultyID int64, unreadOnly bool) ([]*Notification, error) {
+	query := `
+		SELECT notification_id, faculty_id, subject, message, created_at, read_at
+		FROM notifications
+		WHERE faculty_id = $1 AND (read_at IS NULL OR NOT $2)
+		ORDER BY created_at DESC, notification_id DESC
+		LIMIT 100
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, facultyID, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+	for rows.Next() {
+		var notification Notification
+		err := rows.Scan(
+			&notification.NotificationID,
+			&notification.FacultyID,
+			&notification.Subject,
+			&notification.Message,
+			&notification.CreatedAt,
+			&notification.ReadAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (m NotificationModel) MarkRead(id, facultyID int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE notification_id = $1 AND faculty_id = $2
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, facultyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type PaymentIntentModel struct {
+	DB *sql.DB
+}
+
+// PaymentIntent is a card payment of an invoice started with the payment provider. The provider
+// knows it by ProviderRef, which the payment is recorded with once it succeeds.
+type PaymentIntent struct {
+	IntentID    int64     `json:"intent_id"`
+	Provider    string    `json:"provider"`
+	ProviderRef string    `json:"provider_ref"`
+	InvoiceID   int64     `json:"invoice_id"`
+	FamilyID    int64     `json:"family_id"`
+	AmountCents int64     `json:"amount_cents"`
+	Status      string    `json:"status"`
+	CreatedBy   *int64    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (m PaymentIntentModel) Insert(intent *PaymentIntent) error {
+	query := `
+		INSERT INTO payment_intents (provider, provider_ref, invoice_id, guardian_id, amount_cents, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING intent_id, created_at, updated_at
+		`
+	args := []any{intent.Provider, intent.ProviderRef, intent.InvoiceID, intent.FamilyID, intent.AmountCents, intent.Status, intent.CreatedBy}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&intent.IntentID, &intent.CreatedAt, &intent.UpdatedAt)
+}
+
+func (m PaymentIntentModel) Get(id int64) (*PaymentIntent, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	return m.get(`WHERE intent_id = $1`, id)
+}
+
+// GetByProviderRef returns the intent the provider knows by ref.
+func (m PaymentIntentModel) GetByProviderRef(provider, ref string) (*PaymentIntent, error) {
+	return m.get(`WHERE provider = $1 AND provider_ref = $2`, provider, ref)
+}
+
+func (m PaymentIntentModel) get(where string, args ...any) (*PaymentIntent, error) {
+	query := `
+		SELECT intent_id, provider, provider_ref, invoice_id, guardian_id, amount_cents, status, created_by, created_at, updated_at
+		FROM payment_intents
+		` + where
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var intent PaymentIntent
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(
+		&intent.IntentID,
+		&intent.Provider,
+		&intent.ProviderRef,
+		&intent.InvoiceID,
+		&intent.FamilyID,
+		&intent.AmountCents,
+		&intent.Status,
+		&intent.CreatedBy,
+		&intent.CreatedAt,
+		&intent.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &intent, nil
+}
+
+// SetStatus saves the status the provider last reported for the intent.
+func (m PaymentIntentModel) SetStatus(intent *PaymentIntent, status string) error {
+	query := `
+		UPDATE payment_intents
+		SET status = $1, updated_at = NOW()
+		WHERE intent_id = $2
+		RETURNING updated_at
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, status, intent.IntentID).Scan(&intent.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	intent.Status = status
+
+	return nil
+}
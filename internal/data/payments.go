@@ -0,0 +1,118 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type PaymentModel struct {
+	DB *sql.DB
+}
+
+// Payment is money a family paid towards its account by cash, cheque or bank transfer, or by
+// card through the payment provider, in which case ProviderRef is the provider's reference.
+type Payment struct {
+	PaymentID   int64     `json:"payment_id"`
+	FamilyID    int64     `json:"family_id"`
+	AmountCents int64     `json:"amount_cents"`
+	Method      string    `json:"method"`
+	Reference   string    `json:"reference"`
+	ReceivedOn  Date      `json:"received_on"`
+	ProviderRef *string   `json:"provider_ref,omitempty"`
+	RecordedBy  *int64    `json:"recorded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Insert records the payment and settles the family's invoices it pays for. A payment with a
+// provider reference is only ever recorded once, and ErrDuplicatePayment is returned when it
+// already has been.
+func (m PaymentModel) Insert(payment *Payment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockFamily(ctx, tx, payment.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payments (guardian_id, amount_cents, method, reference, received_on, provider_ref, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (provider_ref) DO NOTHING
+		RETURNING payment_id, created_at`,
+		payment.FamilyID,
+		payment.AmountCents,
+		payment.Method,
+		payment.Reference,
+		time.Time(payment.ReceivedOn),
+		payment.ProviderRef,
+		payment.RecordedBy,
+	).Scan(&payment.PaymentID, &payment.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrDuplicatePayment
+		default:
+			return err
+		}
+	}
+
+	err = settleInvoices(ctx, tx, payment.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m PaymentModel) Get(familyID, paymentID int64) (*Payment, error) {
+	if familyID < 1 || paymentID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT payment_id, guardian_id, amount_cents, method, reference, received_on, provider_ref, recorded_by, created_at
+		FROM payments
+		WHERE guardian_id = $1 AND payment_id = $2
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var (
+		payment    Payment
+		receivedOn time.Time
+	)
+
+	err := m.DB.QueryRowContext(ctx, query, familyID, paymentID).Scan(
+		&payment.PaymentID,
+		&payment.FamilyID,
+		&payment.AmountCents,
+		&payment.Method,
+		&payment.Reference,
+		&receivedOn,
+		&payment.ProviderRef,
+		&payment.RecordedBy,
+		&payment.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	payment.ReceivedOn = Date(receivedOn)
+
+	return &payment, nil
+}
@@ -0,0 +1,205 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type ShiftModel struct {
+	DB *sql.DB
+}
+
+type Shift struct {
+	ShiftID   int64     `json:"shift_id"`
+	FacultyID int64     `json:"faculty_id"`
+	ClassID   int64     `json:"class_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Notes     string    `json:"notes"`
+}
+
+// ShiftConflict is a shift or a time off of the same faculty member that overlaps a shift.
+type ShiftConflict struct {
+	Kind     string    `json:"kind"`
+	ID       int64     `json:"id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// Insert saves the shift unless it overlaps another shift or time off of the faculty member, in
+// which case nothing is saved and the conflicts are returned instead.
+func (m ShiftModel) Insert(shift *Shift) ([]*ShiftConflict, error) {
+	return m.save(shift, `
+		INSERT INTO shifts (faculty_id, class_id, starts_at, ends_at, notes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING shift_id`,
+		shift.FacultyID, shift.ClassID, shift.StartsAt, shift.EndsAt, shift.Notes)
+}
+
+// Update saves the changes to the shift, with the same conflict checks as Insert.
+func (m ShiftModel) Update(shift *Shift) ([]*ShiftConflict, error) {
+	return m.save(shift, `
+		UPDATE shifts
+		SET faculty_id = $1, class_id = $2, starts_at = $3, ends_at = $4, notes = $5
+		WHERE shift_id = $6
+		RETURNING shift_id`,
+		shift.FacultyID, shift.ClassID, shift.StartsAt, shift.EndsAt, shift.Notes, shift.ShiftID)
+}
+
+func (m ShiftModel) save(shift *Shift, query string, args ...any) ([]*ShiftConflict, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the faculty member so two overlapping shifts can't be saved at the same time.
+	_, err = tx.ExecContext(ctx, `SELECT 1 FROM faculty WHERE faculty_id = $1 FOR UPDATE`, shift.FacultyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT 'shift', shift_id, starts_at, ends_at
+		FROM shifts
+		WHERE faculty_id = $1 AND shift_id <> $2 AND starts_at < $4 AND ends_at > $3
+		UNION ALL
+		SELECT 'time_off', time_off_id, starts_at, ends_at
+		FROM time_off
+		WHERE faculty_id = $1 AND starts_at < $4 AND ends_at > $3
+		ORDER BY 3`, shift.FacultyID, shift.ShiftID, shift.StartsAt, shift.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conflicts := []*ShiftConflict{}
+	for rows.Next() {
+		var conflict ShiftConflict
+		err := rows.Scan(&conflict.Kind, &conflict.ID, &conflict.StartsAt, &conflict.EndsAt)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, &conflict)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(conflicts) > 0 {
+		return conflicts, nil
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&shift.ShiftID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return nil, tx.Commit()
+}
+
+func (m ShiftModel) Get(id int64) (*Shift, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	shifts, err := m.getAll(`WHERE shift_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shifts) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return shifts[0], nil
+}
+
+func (m ShiftModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM shifts WHERE shift_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAllByFacultyID returns the faculty member's shifts overlapping from and to.
+func (m ShiftModel) GetAllByFacultyID(facultyID int64, from, to time.Time) ([]*Shift, error) {
+	return m.getAll(`WHERE faculty_id = $1 AND starts_at < $3 AND ends_at > $2`, facultyID, from, to)
+}
+
+// GetAllByClassID returns the class's shifts overlapping from and to.
+func (m ShiftModel) GetAllByClassID(classID int64, from, to time.Time) ([]*Shift, error) {
+	return m.getAll(`WHERE class_id = $1 AND starts_at < $3 AND ends_at > $2`, classID, from, to)
+}
+
+func (m ShiftModel) getAll(where string, args ...any) ([]*Shift, error) {
+	query := `
+		SELECT shift_id, faculty_id, class_id, starts_at, ends_at, notes
+		FROM shifts
+		` + where + `
+		ORDER BY starts_at, shift_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := []*Shift{}
+	for rows.Next() {
+		var shift Shift
+		err := rows.Scan(
+			&shift.ShiftID,
+			&shift.FacultyID,
+			&shift.ClassID,
+			&shift.StartsAt,
+			&shift.EndsAt,
+			&shift.Notes,
+		)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, &shift)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return shifts, nil
+}
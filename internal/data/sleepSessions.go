@@ -0,0 +1,332 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type SleepSessionModel struct {
+	DB     *sql.DB
+	Events EventPublisher
+}
+
+// SleepSession is a nap a student takes in a class, from when they were put down until they
+// were picked up again. Staff check on sleeping students regularly, LastCheckAt being the latest
+// check.
+type SleepSession struct {
+	SessionID   int64         `json:"session_id"`
+	StudentID   int64         `json:"student_id"`
+	ClassID     int64         `json:"class_id"`
+	FirstName   string        `json:"first_name"`
+	LastName    string        `json:"last_name"`
+	StartedAt   time.Time     `json:"started_at"`
+	EndedAt     *time.Time    `json:"ended_at,omitempty"`
+	StartedBy   *int64        `json:"started_by"`
+	EndedBy     *int64        `json:"ended_by,omitempty"`
+	LastCheckAt *time.Time    `json:"last_check_at"`
+	Checks      []*SleepCheck `json:"checks,omitempty"`
+}
+
+// SleepCheck is a member of staff checking on a sleeping student.
+type SleepCheck struct {
+	CheckID   int64     `json:"check_id"`
+	SessionID int64     `json:"session_id"`
+	CheckedAt time.Time `json:"checked_at"`
+	Position  string    `json:"position"`
+	Breathing string    `json:"breathing"`
+	CheckedBy *int64    `json:"checked_by"`
+	Notes     string    `json:"notes"`
+}
+
+// CheckDueAt returns when the next check on the session is due if checks are needed every
+// interval.
+func (s *SleepSession) CheckDueAt(every time.Duration) time.Time {
+	if s.LastCheckAt != nil {
+		return s.LastCheckAt.Add(every)
+	}
+	return s.StartedAt.Add(every)
+}
+
+// Start records a student falling asleep. A student who is already asleep returns
+// ErrAlreadyAsleep.
+func (m SleepSessionModel) Start(session *SleepSession) error {
+	query := `
+		INSERT INTO sleep_sessions (student_id, class_id, started_at, started_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING session_id
+		`
+	args := []any{session.StudentID, session.ClassID, session.StartedAt, session.StartedBy}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&session.SessionID)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "sleep_sessions_open_student_idx"`:
+			return ErrAlreadyAsleep
+		default:
+			return err
+		}
+	}
+
+	publish(m.Events, session.ClassID, "sleep.started", session)
+
+	return nil
+}
+
+func (m SleepSessionModel) Get(classID, id int64) (*SleepSession, error) {
+	if classID < 1 || id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	sessions, err := m.getAll(`WHERE ss.class_id = $1 AND ss.session_id = $2`, classID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return sessions[0], nil
+}
+
+// GetAsleepByClassID returns the sessions of the students in the class who are asleep.
+func (m SleepSessionModel) GetAsleepByClassID(classID int64) ([]*SleepSession, error) {
+	return m.getAll(`WHERE ss.class_id = $1 AND ss.ended_at IS NULL`, classID)
+}
+
+// GetAllByStudentID returns the student's sessions that started on the day, with their checks.
+func (m SleepSessionModel) GetAllByStudentID(studentID int64, day time.Time) ([]*SleepSession, error) {
+	day = dateOf(day)
+	sessions, err := m.getAll(`WHERE ss.student_id = $1 AND ss.started_at >= $2 AND ss.started_at < $3`,
+		studentID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Checks, err = m.GetChecks(session.SessionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sessions, nil
+}
+
+func (m SleepSessionModel) getAll(where string, args ...any) ([]*SleepSession, error) {
+	query := `
+		SELECT ss.session_id, ss.student_id, ss.class_id, s.first_name, s.last_name, ss.started_at, ss.ended_at,
+			ss.started_by, ss.ended_by, (SELECT MAX(checked_at) FROM sleep_checks WHERE session_id = ss.session_id)
+		FROM sleep_sessions ss
+		INNER JOIN students s ON ss.student_id = s.student_id
+		` + where + `
+		ORDER BY ss.started_at, ss.session_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*SleepSession{}
+	for rows.Next() {
+		var (
+			session     SleepSession
+			endedAt     sql.NullTime
+			lastCheckAt sql.NullTime
+		)
+		err := rows.Scan(
+			&session.SessionID,
+			&session.StudentID,
+			&session.ClassID,
+			&session.FirstName,
+			&session.LastName,
+			&session.StartedAt,
+			&endedAt,
+			&session.StartedBy,
+			&session.EndedBy,
+			&lastCheckAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if endedAt.Valid {
+			session.EndedAt = &endedAt.Time
+		}
+		if lastCheckAt.Valid {
+			session.LastCheckAt = &lastCheckAt.Time
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// End records the student waking up. A session that has already ended returns ErrNotAsleep.
+func (m SleepSessionModel) End(session *SleepSession, at time.Time, endedBy int64) error {
+	query := `
+		UPDATE sleep_sessions
+		SET ended_at = $1, ended_by = $2
+		WHERE session_id = $3 AND ended_at IS NULL
+		RETURNING ended_at
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var endedAt time.Time
+	err := m.DB.QueryRowContext(ctx, query, at, endedBy, session.SessionID).Scan(&endedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNotAsleep
+		default:
+			return err
+		}
+	}
+
+	session.EndedAt = &endedAt
+	session.EndedBy = &endedBy
+
+	publish(m.Events, session.ClassID, "sleep.ended", session)
+
+	return nil
+}
+
+// InsertCheck records a check on a sleeping student. Checks on sessions that have ended return
+// ErrNotAsleep.
+func (m SleepSessionModel) InsertCheck(session *SleepSession, check *SleepCheck) error {
+	query := `
+		INSERT INTO sleep_checks (session_id, checked_at, position, breathing, checked_by, notes)
+		SELECT session_id, $2, $3, $4, $5, $6
+		FROM sleep_sessions
+		WHERE session_id = $1 AND ended_at IS NULL
+		RETURNING check_id
+		`
+	args := []any{session.SessionID, check.CheckedAt, check.Position, check.Breathing, check.CheckedBy, check.Notes}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&check.CheckID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNotAsleep
+		default:
+			return err
+		}
+	}
+
+	check.SessionID = session.SessionID
+	if session.LastCheckAt == nil || check.CheckedAt.After(*session.LastCheckAt) {
+		session.LastCheckAt = &check.CheckedAt
+	}
+
+	publish(m.Events, session.ClassID, "sleep.checked", check)
+
+	return nil
+}
+
+func (m SleepSessionModel) GetChecks(sessionID int64) ([]*SleepCheck, error) {
+	query := `
+		SELECT check_id, session_id, checked_at, position, breathing, checked_by, notes
+		FROM sleep_checks
+		WHERE session_id = $1
+		ORDER BY checked_at, check_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := []*SleepCheck{}
+	for rows.Next() {
+		var check SleepCheck
+		err := rows.Scan(
+			&check.CheckID,
+			&check.SessionID,
+			&check.CheckedAt,
+			&check.Position,
+			&check.Breathing,
+			&check.CheckedBy,
+			&check.Notes,
+		)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, &check)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return checks, nil
+}
+
+// FlagOverdue returns the sessions of sleeping students who haven't been checked on for longer
+// than every, and marks them as alerted. A session is returned again every interval for as long
+// as it stays overdue.
+func (m SleepSessionModel) FlagOverdue(every time.Duration) ([]*SleepSession, error) {
+	query := `
+		UPDATE sleep_sessions ss
+		SET overdue_alerted_at = NOW()
+		WHERE ss.ended_at IS NULL
+		AND COALESCE((SELECT MAX(checked_at) FROM sleep_checks WHERE session_id = ss.session_id), ss.started_at) < $1
+		AND (ss.overdue_alerted_at IS NULL OR ss.overdue_alerted_at < $1)
+		RETURNING ss.session_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, time.Now().Add(-every))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	overdue := []*SleepSession{}
+	for _, id := range ids {
+		sessions, err := m.getAll(`WHERE ss.session_id = $1`, id)
+		if err != nil {
+			return nil, err
+		}
+		overdue = append(overdue, sessions...)
+	}
+
+	return overdue, nil
+}
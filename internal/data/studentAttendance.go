@@ -7,38 +7,49 @@ import (
 )
 
 type StudentAttendanceModel struct {
-	DB *sql.DB
+	DB     *sql.DB
+	Events EventPublisher
 }
 
 type StudentAttendance struct {
-	StudentID int64 `json:"student_id"`
-	ClassID   int64 `json:"class_id"`
-	ClassDate Date  `json:"class_date"`
-	Present   bool  `json:"present"`
+	StudentID  int64      `json:"student_id"`
+	ClassID    int64      `json:"class_id"`
+	ClassDate  Date       `json:"class_date"`
+	Present    bool       `json:"present"`
+	Excused    bool       `json:"excused"`
+	CheckInAt  *time.Time `json:"check_in_at,omitempty"`
+	CheckOutAt *time.Time `json:"check_out_at,omitempty"`
 }
 
+// Insert records whether the student was present or excused on the day. Attendance that was
+// already recorded for the day, by a kiosk check-in for example, is updated and keeps its
+// check-in and check-out times.
 func (m StudentAttendanceModel) Insert(studentAttendance *StudentAttendance) error {
 	query := `
-		INSERT INTO student_attendance (student_id, class_id, class_date, present) 
-		VALUES ($1, $2, $3, $4)
-		RETURNING student_id
+		INSERT INTO student_attendance (student_id, class_id, class_date, present, excused) 
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (student_id, class_id, class_date) DO UPDATE
+		SET present = EXCLUDED.present, excused = EXCLUDED.excused
+		RETURNING check_in_at, check_out_at
 		`
-	args := []any{studentAttendance.StudentID, studentAttendance.ClassID, time.Time(studentAttendance.ClassDate), studentAttendance.Present}
+	args := []any{studentAttendance.StudentID, studentAttendance.ClassID, time.Time(studentAttendance.ClassDate), studentAttendance.Present, studentAttendance.Excused}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&studentAttendance.StudentID)
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&studentAttendance.CheckInAt, &studentAttendance.CheckOutAt)
 	if err != nil {
 		return err
 	}
 
+	publish(m.Events, studentAttendance.ClassID, "attendance.recorded", studentAttendance)
+
 	return nil
 }
 
 func (m StudentAttendanceModel) GetAttendance(date time.Time, classID int64) ([]*StudentAttendance, error) {
 	query := `
-		SELECT student_id, class_id, class_date, present
+		SELECT student_id, class_id, class_date, present, excused, check_in_at, check_out_at
 		FROM student_attendance
 		WHERE class_date = $1 AND class_id = $2
 		`
@@ -54,7 +65,8 @@ func (m StudentAttendanceModel) GetAttendance(date time.Time, classID int64) ([]
 	studentAttendances := []*StudentAttendance{}
 	for rows.Next() {
 		studentAttendance := &StudentAttendance{}
-		err := rows.Scan(&studentAttendance.StudentID, &studentAttendance.ClassID, &studentAttendance.ClassDate, &studentAttendance.Present)
+		err := rows.Scan(&studentAttendance.StudentID, &studentAttendance.ClassID, &studentAttendance.ClassDate, &studentAttendance.Present, &studentAttendance.Excused,
+			&studentAttendance.CheckInAt, &studentAttendance.CheckOutAt)
 		if err != nil {
 			return nil, err
 		}
@@ -64,14 +76,90 @@ func (m StudentAttendanceModel) GetAttendance(date time.Time, classID int64) ([]
 	return studentAttendances, nil
 }
 
+// CheckIn marks the student present, as of at, in every class they are enrolled in. Checking in
+// twice on the same day keeps the first check-in time.
+func (m StudentAttendanceModel) CheckIn(studentID int64, at time.Time) ([]*StudentAttendance, error) {
+	query := `
+		INSERT INTO student_attendance (student_id, class_id, class_date, present, check_in_at)
+		SELECT student_id, class_id, $2::date, true, $3::timestamptz
+		FROM class_students
+		WHERE student_id = $1
+		ON CONFLICT (student_id, class_id, class_date) DO UPDATE
+		SET present = true, excused = false, check_in_at = COALESCE(student_attendance.check_in_at, EXCLUDED.check_in_at)
+		RETURNING student_id, class_id, class_date, present, excused, check_in_at, check_out_at
+		`
+
+	studentAttendances, err := m.queryAttendance(query, studentID, dateOf(at), at)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, studentAttendance := range studentAttendances {
+		publish(m.Events, studentAttendance.ClassID, "attendance.checked_in", studentAttendance)
+	}
+
+	return studentAttendances, nil
+}
+
+// CheckOut records at as the check-out time of the student's classes for that day. Only
+// attendance that was checked in and not yet checked out is changed, and the changed records
+// are returned, so an empty result means the student wasn't checked in.
+func (m StudentAttendanceModel) CheckOut(studentID int64, at time.Time) ([]*StudentAttendance, error) {
+	query := `
+		UPDATE student_attendance
+		SET check_out_at = $3
+		WHERE student_id = $1 AND class_date = $2 AND check_in_at IS NOT NULL AND check_out_at IS NULL
+		RETURNING student_id, class_id, class_date, present, excused, check_in_at, check_out_at
+		`
+
+	studentAttendances, err := m.queryAttendance(query, studentID, dateOf(at), at)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, studentAttendance := range studentAttendances {
+		publish(m.Events, studentAttendance.ClassID, "attendance.checked_out", studentAttendance)
+	}
+
+	return studentAttendances, nil
+}
+
+func (m StudentAttendanceModel) queryAttendance(query string, args ...any) ([]*StudentAttendance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	studentAttendances := []*StudentAttendance{}
+	for rows.Next() {
+		studentAttendance := &StudentAttendance{}
+		err := rows.Scan(&studentAttendance.StudentID, &studentAttendance.ClassID, &studentAttendance.ClassDate, &studentAttendance.Present, &studentAttendance.Excused,
+			&studentAttendance.CheckInAt, &studentAttendance.CheckOutAt)
+		if err != nil {
+			return nil, err
+		}
+		studentAttendances = append(studentAttendances, studentAttendance)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return studentAttendances, nil
+}
+
 func (m StudentAttendanceModel) NumberOfAttendanceTakenByFaculty(facultyID int64) (int, error) {
 	query := `
 		SELECT COUNT(DISTINCT class_id)
 		FROM student_attendance
 		WHERE class_id IN (
 			SELECT class_id
-			FROM classes
-			WHERE faculty_id = $1
+			FROM class_faculty
+			WHERE faculty_id = $1 AND ` + currentAssignment + `
 		)
 	`
 
@@ -88,3 +176,73 @@ func (m StudentAttendanceModel) NumberOfAttendanceTakenByFaculty(facultyID int64
 
 	return count, nil
 }
+
+type AttendanceSummary struct {
+	ClassID        int64   `json:"class_id"`
+	ClassName      string  `json:"class_name"`
+	StudentID      int64   `json:"student_id"`
+	FirstName      string  `json:"first_name"`
+	LastName       string  `json:"last_name"`
+	DaysPresent    int     `json:"days_present"`
+	DaysAbsent     int     `json:"days_absent"`
+	DaysExcused    int     `json:"days_excused"`
+	AttendanceRate float64 `json:"attendance_rate"`
+}
+
+// GetAttendanceSummaries totals the attendance of every student between from and to (inclusive)
+// and calls fn once per class and student, so large ranges can be streamed to the client.
+// A classID or studentID of 0 matches every class or student. Excused days are left out of
+// the attendance rate.
+func (m StudentAttendanceModel) GetAttendanceSummaries(classID, studentID int64, from, to time.Time, fn func(*AttendanceSummary) error) error {
+	query := `
+		SELECT c.class_id, c.class_name, s.student_id, s.first_name, s.last_name,
+		count(*) FILTER (WHERE sa.present IS TRUE),
+		count(*) FILTER (WHERE sa.present IS NOT TRUE AND NOT sa.excused),
+		count(*) FILTER (WHERE sa.present IS NOT TRUE AND sa.excused)
+		FROM student_attendance sa
+		INNER JOIN students s ON sa.student_id = s.student_id
+		INNER JOIN classes c ON sa.class_id = c.class_id
+		WHERE sa.class_date BETWEEN $1 AND $2
+		AND (sa.class_id = $3 OR $3 = 0)
+		AND (sa.student_id = $4 OR $4 = 0)
+		GROUP BY c.class_id, c.class_name, s.student_id, s.first_name, s.last_name
+		ORDER BY c.class_name, c.class_id, s.last_name, s.first_name, s.student_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, from, to, classID, studentID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var summary AttendanceSummary
+		err := rows.Scan(
+			&summary.ClassID,
+			&summary.ClassName,
+			&summary.StudentID,
+			&summary.FirstName,
+			&summary.LastName,
+			&summary.DaysPresent,
+			&summary.DaysAbsent,
+			&summary.DaysExcused,
+		)
+		if err != nil {
+			return err
+		}
+
+		if counted := summary.DaysPresent + summary.DaysAbsent; counted > 0 {
+			summary.AttendanceRate = float64(summary.DaysPresent) / float64(counted)
+		}
+
+		err = fn(&summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -1,6 +1,10 @@
 package data
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"time"
+)
 
 type StudentGuardianModel struct {
 	DB *sql.DB
@@ -10,3 +14,75 @@ type StudentGuardian struct {
 	StudentID  int64 `json:"student_id"`
 	GuardianID int64 `json:"guardian_id"`
 }
+
+func (m StudentGuardianModel) GetStudentsByGuardianID(guardianID int64) ([]*Student, error) {
+	if guardianID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT s.student_id, s.first_name, s.last_name, s.gender, s.date_of_birth
+		FROM students s
+		INNER JOIN student_guardian sg ON s.student_id = sg.student_id
+		WHERE sg.guardian_id = $1
+		ORDER BY s.first_name, s.student_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, guardianID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	students := []*Student{}
+	for rows.Next() {
+		var student Student
+		err := rows.Scan(
+			&student.StudentID,
+			&student.FirstName,
+			&student.LastName,
+			&student.Gender,
+			&student.DateOfBirth,
+		)
+		if err != nil {
+			return nil, err
+		}
+		students = append(students, &student)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return students, nil
+}
+
+// Insert links an existing guardian to a student, so that siblings can share their guardians.
+// Linking a guardian who is already linked does nothing. The link doesn't change the family the
+// student is billed to, unless they had none, in which case they join the guardian's family.
+func (m StudentGuardianModel) Insert(link *StudentGuardian) error {
+	query := `
+		WITH link AS (
+			INSERT INTO student_guardian (student_id, guardian_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		)
+		UPDATE students
+		SET family_id = COALESCE((
+			SELECT min(s.family_id)
+			FROM students s
+			INNER JOIN student_guardian sg ON s.student_id = sg.student_id
+			WHERE sg.guardian_id = $2 AND s.student_id <> $1
+		), $2)
+		WHERE student_id = $1 AND family_id IS NULL
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, link.StudentID, link.GuardianID)
+	return err
+}
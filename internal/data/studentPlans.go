@@ -0,0 +1,219 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type StudentPlanModel struct {
+	DB *sql.DB
+}
+
+// StudentPlan puts a student on a tuition plan from StartsOn until EndsOn, or indefinitely if
+// EndsOn is nil.
+type StudentPlan struct {
+	StudentPlanID int64  `json:"student_plan_id"`
+	StudentID     int64  `json:"student_id"`
+	PlanID        int64  `json:"plan_id"`
+	PlanName      string `json:"plan_name"`
+	StartsOn      Date   `json:"starts_on"`
+	EndsOn        *Date  `json:"ends_on,omitempty"`
+}
+
+// BillablePlan is a student's plan as seen by a billing run, together with the guardian who is
+// billed for it.
+type BillablePlan struct {
+	StudentPlan
+	FirstName            string
+	LastName             string
+	Frequency            string
+	RateCents            int64
+	RegistrationFeeCents int64
+	RegistrationBilled   bool
+	GuardianID           int64
+	// StaffChild is set when one of the student's guardians works at the daycare.
+	StaffChild bool
+}
+
+func (m StudentPlanModel) Insert(plan *StudentPlan) error {
+	query := `
+		INSERT INTO student_plans (student_id, plan_id, starts_on, ends_on)
+		VALUES ($1, $2, $3, $4)
+		RETURNING student_plan_id
+		`
+	args := []any{plan.StudentID, plan.PlanID, time.Time(plan.StartsOn), nullDate(plan.EndsOn)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&plan.StudentPlanID)
+}
+
+func (m StudentPlanModel) Get(studentID, studentPlanID int64) (*StudentPlan, error) {
+	if studentID < 1 || studentPlanID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	plans, err := m.getAll(`WHERE sp.student_id = $1 AND sp.student_plan_id = $2`, studentID, studentPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plans) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return plans[0], nil
+}
+
+func (m StudentPlanModel) GetAllByStudentID(studentID int64) ([]*StudentPlan, error) {
+	return m.getAll(`WHERE sp.student_id = $1`, studentID)
+}
+
+func (m StudentPlanModel) getAll(where string, args ...any) ([]*StudentPlan, error) {
+	query := `
+		SELECT sp.student_plan_id, sp.student_id, sp.plan_id, p.name, sp.starts_on, sp.ends_on
+		FROM student_plans sp
+		INNER JOIN tuition_plans p ON sp.plan_id = p.plan_id
+		` + where + `
+		ORDER BY sp.starts_on DESC, sp.student_plan_id DESC
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []*StudentPlan{}
+	for rows.Next() {
+		var (
+			plan     StudentPlan
+			startsOn time.Time
+			endsOn   sql.NullTime
+		)
+
+		err := rows.Scan(&plan.StudentPlanID, &plan.StudentID, &plan.PlanID, &plan.PlanName, &startsOn, &endsOn)
+		if err != nil {
+			return nil, err
+		}
+
+		plan.StartsOn = Date(startsOn)
+		plan.EndsOn = dateOrNil(endsOn)
+		plans = append(plans, &plan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// Update saves a change to the dates of the student's plan, usually to end it.
+func (m StudentPlanModel) Update(plan *StudentPlan) error {
+	query := `
+		UPDATE student_plans
+		SET starts_on = $1, ends_on = $2
+		WHERE student_id = $3 AND student_plan_id = $4
+		`
+	args := []any{time.Time(plan.StartsOn), nullDate(plan.EndsOn), plan.StudentID, plan.StudentPlanID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetBillable returns the student plans in effect at any time between from and to, ordered by
+// the family billed for them. Students without a family have a GuardianID of 0.
+func (m StudentPlanModel) GetBillable(from, to time.Time) ([]*BillablePlan, error) {
+	query := `
+		SELECT sp.student_plan_id, sp.student_id, sp.plan_id, p.name, sp.starts_on, sp.ends_on,
+			s.first_name, s.last_name, p.frequency, p.rate_cents, p.registration_fee_cents,
+			EXISTS (
+				SELECT 1
+				FROM invoice_lines il
+				WHERE il.student_plan_id = sp.student_plan_id AND il.kind = 'registration'
+			),
+			COALESCE(s.family_id, 0) AS guardian_id,
+			EXISTS (
+				SELECT 1
+				FROM student_guardian sg
+				INNER JOIN guardians g ON sg.guardian_id = g.guardian_id
+				INNER JOIN faculty f ON g.faculty_id = f.faculty_id
+				WHERE sg.student_id = sp.student_id AND f.active
+			)
+		FROM student_plans sp
+		INNER JOIN tuition_plans p ON sp.plan_id = p.plan_id
+		INNER JOIN students s ON sp.student_id = s.student_id
+		WHERE sp.starts_on <= $2 AND (sp.ends_on IS NULL OR sp.ends_on >= $1)
+		ORDER BY guardian_id, s.last_name, s.first_name, sp.student_id, sp.starts_on
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, dateOf(from), dateOf(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []*BillablePlan{}
+	for rows.Next() {
+		var (
+			plan     BillablePlan
+			startsOn time.Time
+			endsOn   sql.NullTime
+		)
+
+		err := rows.Scan(
+			&plan.StudentPlanID,
+			&plan.StudentID,
+			&plan.PlanID,
+			&plan.PlanName,
+			&startsOn,
+			&endsOn,
+			&plan.FirstName,
+			&plan.LastName,
+			&plan.Frequency,
+			&plan.RateCents,
+			&plan.RegistrationFeeCents,
+			&plan.RegistrationBilled,
+			&plan.GuardianID,
+			&plan.StaffChild,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		plan.StartsOn = Date(startsOn)
+		plan.EndsOn = dateOrNil(endsOn)
+		plans = append(plans, &plan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
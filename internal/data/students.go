@@ -78,6 +78,13 @@ func (m StudentModel) InsertWithGuardians(student *Student, guardians []*Guardia
 		if err != nil {
 			return err
 		}
+
+		// The student is billed through their first guardian
+		query = `UPDATE students SET family_id = $2 WHERE student_id = $1 AND family_id IS NULL`
+		_, err = tx.Exec(query, studentID, guardianID)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Commit transaction
@@ -133,6 +140,13 @@ func (m StudentModel) InsertWithGuardian(student *Student, guardian *Guardian) e
 		return err
 	}
 
+	// The student is billed through their guardian
+	query = `UPDATE students SET family_id = $2 WHERE student_id = $1`
+	_, err = tx.Exec(query, studentID, guardianID)
+	if err != nil {
+		return err
+	}
+
 	// Commit transaction
 	err = tx.Commit()
 	if err != nil {
@@ -178,6 +192,39 @@ func (m StudentModel) Get(id int64) (*Student, error) {
 	return &student, nil
 }
 
+// SetFamily moves the student to the family billed through the given guardian, who must be one
+// of the student's guardians or already bill another student. Invoices and payments stay on the
+// account they were made on; only later invoices go to the new family.
+func (m StudentModel) SetFamily(id, familyID int64) error {
+	query := `
+		UPDATE students
+		SET family_id = $2
+		WHERE student_id = $1 AND (
+			EXISTS (SELECT 1 FROM student_guardian WHERE student_id = $1 AND guardian_id = $2)
+			OR EXISTS (SELECT 1 FROM students WHERE family_id = $2)
+		)
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, familyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrUnknownFamily
+	}
+
+	return nil
+}
+
 func (m StudentModel) Delete(id int64) error {
 	if id < 1 {
 		return ErrRecordNotFound
@@ -0,0 +1,134 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type SubsidyAgencyModel struct {
+	DB *sql.DB
+}
+
+// SubsidyAgency is a government agency that pays for the days subsidised students attend. Code
+// is the provider code the agency knows the daycare by and is printed on every claim.
+type SubsidyAgency struct {
+	AgencyID  int64     `json:"agency_id"`
+	Name      string    `json:"name"`
+	Code      string    `json:"code"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (m SubsidyAgencyModel) Insert(agency *SubsidyAgency) error {
+	query := `
+		INSERT INTO subsidy_agencies (name, code, active)
+		VALUES ($1, $2, $3)
+		RETURNING agency_id, created_at
+		`
+	args := []any{agency.Name, agency.Code, agency.Active}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&agency.AgencyID, &agency.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "subsidy_agencies_code_key"`:
+			return ErrDuplicateAgencyCode
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m SubsidyAgencyModel) Get(id int64) (*SubsidyAgency, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	agencies, err := m.getAll(`WHERE agency_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(agencies) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return agencies[0], nil
+}
+
+// GetAll returns the agencies, only the ones still in use if activeOnly is set.
+func (m SubsidyAgencyModel) GetAll(activeOnly bool) ([]*SubsidyAgency, error) {
+	return m.getAll(`WHERE active OR NOT $1`, activeOnly)
+}
+
+func (m SubsidyAgencyModel) getAll(where string, args ...any) ([]*SubsidyAgency, error) {
+	query := `
+		SELECT agency_id, name, code, active, created_at
+		FROM subsidy_agencies
+		` + where + `
+		ORDER BY name, agency_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agencies := []*SubsidyAgency{}
+	for rows.Next() {
+		var agency SubsidyAgency
+		err := rows.Scan(&agency.AgencyID, &agency.Name, &agency.Code, &agency.Active, &agency.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		agencies = append(agencies, &agency)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return agencies, nil
+}
+
+func (m SubsidyAgencyModel) Update(agency *SubsidyAgency) error {
+	query := `
+		UPDATE subsidy_agencies
+		SET name = $1, code = $2, active = $3
+		WHERE agency_id = $4
+		`
+	args := []any{agency.Name, agency.Code, agency.Active, agency.AgencyID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "subsidy_agencies_code_key"`:
+			return ErrDuplicateAgencyCode
+		default:
+			return err
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,304 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type SubsidyAuthorizationModel struct {
+	DB *sql.DB
+}
+
+// SubsidyAuthorization lets an agency pay DailyRateCents for up to AuthorizedDays days of
+// attendance a month, from StartsOn until EndsOn or indefinitely if EndsOn is nil. The family
+// pays CopayCents a month themselves, which the agency takes off its payment.
+type SubsidyAuthorization struct {
+	AuthorizationID int64     `json:"authorization_id"`
+	StudentID       int64     `json:"student_id"`
+	AgencyID        int64     `json:"agency_id"`
+	AgencyName      string    `json:"agency_name"`
+	CaseNumber      string    `json:"case_number"`
+	StartsOn        Date      `json:"starts_on"`
+	EndsOn          *Date     `json:"ends_on,omitempty"`
+	AuthorizedDays  int       `json:"authorized_days"`
+	DailyRateCents  int64     `json:"daily_rate_cents"`
+	CopayCents      int64     `json:"copay_cents"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ClaimableAuthorization is an authorization as seen by a claim, together with the student's
+// name.
+type ClaimableAuthorization struct {
+	SubsidyAuthorization
+	FirstName string
+	LastName  string
+}
+
+// AttendedDay is a day a student was marked present in at least one class.
+type AttendedDay struct {
+	StudentID int64
+	Date      time.Time
+	// MissingCheckOut is set when the student was checked in at the kiosk that day but never
+	// checked out, which agencies want explained before they pay for the day.
+	MissingCheckOut bool
+}
+
+// overlappingAuthorization matches another authorization of student $5 with agency $6 whose
+// dates overlap $1 to $3, where $3 is NULL for no end. $4 is the authorization being changed,
+// or 0 for a new one.
+const overlappingAuthorization = `
+	SELECT 1 FROM subsidy_authorizations o
+	WHERE o.student_id = $5 AND o.agency_id = $6 AND o.authorization_id <> $4
+		AND o.starts_on <= COALESCE($3::date, 'infinity'::date)
+		AND COALESCE(o.ends_on, 'infinity'::date) >= $1::date`
+
+// Insert saves the authorization, unless the student already has one with the agency for some
+// of the same days, which returns ErrOverlappingAuthorization.
+func (m SubsidyAuthorizationModel) Insert(auth *SubsidyAuthorization) error {
+	query := `
+		INSERT INTO subsidy_authorizations (student_id, agency_id, case_number, starts_on, ends_on, authorized_days, daily_rate_cents, copay_cents)
+		SELECT $5, $6, $2, $1, $3, $7, $8, $9
+		WHERE NOT EXISTS (` + overlappingAuthorization + `)
+		RETURNING authorization_id, created_at
+		`
+	args := []any{
+		time.Time(auth.StartsOn),
+		auth.CaseNumber,
+		nullDate(auth.EndsOn),
+		int64(0),
+		auth.StudentID,
+		auth.AgencyID,
+		auth.AuthorizedDays,
+		auth.DailyRateCents,
+		auth.CopayCents,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&auth.AuthorizationID, &auth.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrOverlappingAuthorization
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m SubsidyAuthorizationModel) Get(studentID, authorizationID int64) (*SubsidyAuthorization, error) {
+	if studentID < 1 || authorizationID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	auths, err := m.getAll(`WHERE a.student_id = $1 AND a.authorization_id = $2`, studentID, authorizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(auths) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return auths[0], nil
+}
+
+func (m SubsidyAuthorizationModel) GetAllByStudentID(studentID int64) ([]*SubsidyAuthorization, error) {
+	return m.getAll(`WHERE a.student_id = $1`, studentID)
+}
+
+func (m SubsidyAuthorizationModel) getAll(where string, args ...any) ([]*SubsidyAuthorization, error) {
+	query := `
+		SELECT a.authorization_id, a.student_id, a.agency_id, g.name, a.case_number, a.starts_on, a.ends_on,
+			a.authorized_days, a.daily_rate_cents, a.copay_cents, a.created_at
+		FROM subsidy_authorizations a
+		INNER JOIN subsidy_agencies g ON a.agency_id = g.agency_id
+		` + where + `
+		ORDER BY a.starts_on DESC, a.authorization_id DESC
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	auths := []*SubsidyAuthorization{}
+	for rows.Next() {
+		auth, err := scanAuthorization(rows)
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, auth)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return auths, nil
+}
+
+// scanAuthorization reads an authorization from the row, followed by any extra columns into
+// extra.
+func scanAuthorization(rows *sql.Rows, extra ...any) (*SubsidyAuthorization, error) {
+	var (
+		auth     SubsidyAuthorization
+		startsOn time.Time
+		endsOn   sql.NullTime
+	)
+
+	dest := []any{
+		&auth.AuthorizationID,
+		&auth.StudentID,
+		&auth.AgencyID,
+		&auth.AgencyName,
+		&auth.CaseNumber,
+		&startsOn,
+		&endsOn,
+		&auth.AuthorizedDays,
+		&auth.DailyRateCents,
+		&auth.CopayCents,
+		&auth.CreatedAt,
+	}
+
+	err := rows.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	auth.StartsOn = Date(startsOn)
+	auth.EndsOn = dateOrNil(endsOn)
+
+	return &auth, nil
+}
+
+// Update saves the changes to the authorization. Changing its dates so that they overlap
+// another authorization of the student with the agency returns ErrOverlappingAuthorization.
+func (m SubsidyAuthorizationModel) Update(auth *SubsidyAuthorization) error {
+	query := `
+		UPDATE subsidy_authorizations
+		SET starts_on = $1, case_number = $2, ends_on = $3, authorized_days = $7, daily_rate_cents = $8, copay_cents = $9
+		WHERE authorization_id = $4 AND student_id = $5 AND agency_id = $6
+			AND NOT EXISTS (` + overlappingAuthorization + `)
+		`
+	args := []any{
+		time.Time(auth.StartsOn),
+		auth.CaseNumber,
+		nullDate(auth.EndsOn),
+		auth.AuthorizationID,
+		auth.StudentID,
+		auth.AgencyID,
+		auth.AuthorizedDays,
+		auth.DailyRateCents,
+		auth.CopayCents,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// the caller has just read the authorization, so it is the overlap that stopped the update
+	if rowsAffected == 0 {
+		return ErrOverlappingAuthorization
+	}
+
+	return nil
+}
+
+// GetClaimable returns the agency's authorizations in effect at any time between from and to,
+// ordered by student.
+func (m SubsidyAuthorizationModel) GetClaimable(agencyID int64, from, to time.Time) ([]*ClaimableAuthorization, error) {
+	query := `
+		SELECT a.authorization_id, a.student_id, a.agency_id, g.name, a.case_number, a.starts_on, a.ends_on,
+			a.authorized_days, a.daily_rate_cents, a.copay_cents, a.created_at, s.first_name, s.last_name
+		FROM subsidy_authorizations a
+		INNER JOIN subsidy_agencies g ON a.agency_id = g.agency_id
+		INNER JOIN students s ON a.student_id = s.student_id
+		WHERE a.agency_id = $1 AND a.starts_on <= $3 AND (a.ends_on IS NULL OR a.ends_on >= $2)
+		ORDER BY s.last_name, s.first_name, s.student_id, a.starts_on
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, agencyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	auths := []*ClaimableAuthorization{}
+	for rows.Next() {
+		var firstName, lastName string
+		auth, err := scanAuthorization(rows, &firstName, &lastName)
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, &ClaimableAuthorization{SubsidyAuthorization: *auth, FirstName: firstName, LastName: lastName})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return auths, nil
+}
+
+// GetAttendedDays returns the days between from and to that students subsidised by the agency
+// at any time in that range were present, ordered by student and day.
+func (m SubsidyAuthorizationModel) GetAttendedDays(agencyID int64, from, to time.Time) ([]*AttendedDay, error) {
+	query := `
+		SELECT sa.student_id, sa.class_date, bool_or(sa.check_in_at IS NOT NULL AND sa.check_out_at IS NULL)
+		FROM student_attendance sa
+		WHERE sa.present AND sa.class_date BETWEEN $2 AND $3
+			AND sa.student_id IN (
+				SELECT student_id FROM subsidy_authorizations
+				WHERE agency_id = $1 AND starts_on <= $3 AND (ends_on IS NULL OR ends_on >= $2)
+			)
+		GROUP BY sa.student_id, sa.class_date
+		ORDER BY sa.student_id, sa.class_date
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, agencyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []*AttendedDay{}
+	for rows.Next() {
+		var day AttendedDay
+		err := rows.Scan(&day.StudentID, &day.Date, &day.MissingCheckOut)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, &day)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
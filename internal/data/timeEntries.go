@@ -0,0 +1,409 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type TimeEntryModel struct {
+	DB *sql.DB
+}
+
+type TimeEntry struct {
+	EntryID   int64        `json:"entry_id"`
+	FacultyID int64        `json:"faculty_id"`
+	ClockIn   time.Time    `json:"clock_in"`
+	ClockOut  *time.Time   `json:"clock_out,omitempty"`
+	Breaks    []*TimeBreak `json:"breaks"`
+}
+
+type TimeBreak struct {
+	BreakID   int64      `json:"break_id"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+}
+
+type TimeEntryEdit struct {
+	EditID      int64      `json:"edit_id"`
+	EntryID     int64      `json:"entry_id"`
+	EditedBy    *int64     `json:"edited_by"`
+	Reason      string     `json:"reason"`
+	OldClockIn  time.Time  `json:"old_clock_in"`
+	OldClockOut *time.Time `json:"old_clock_out,omitempty"`
+	NewClockIn  time.Time  `json:"new_clock_in"`
+	NewClockOut *time.Time `json:"new_clock_out,omitempty"`
+	EditedAt    time.Time  `json:"edited_at"`
+}
+
+// Worked returns the time worked during the entry, which is the time between clocking in and
+// out minus the breaks. Entries and breaks that are still open count up to now.
+func (e *TimeEntry) Worked(now time.Time) time.Duration {
+	end := now
+	if e.ClockOut != nil {
+		end = *e.ClockOut
+	}
+
+	worked := end.Sub(e.ClockIn)
+
+	for _, b := range e.Breaks {
+		breakEnd := end
+		if b.EndedAt != nil && b.EndedAt.Before(end) {
+			breakEnd = *b.EndedAt
+		}
+		if breakEnd.After(b.StartedAt) {
+			worked -= breakEnd.Sub(b.StartedAt)
+		}
+	}
+
+	if worked < 0 {
+		return 0
+	}
+
+	return worked
+}
+
+func (m TimeEntryModel) ClockIn(facultyID int64, at time.Time) (*TimeEntry, error) {
+	query := `
+		INSERT INTO time_entries (faculty_id, clock_in)
+		VALUES ($1, $2)
+		RETURNING entry_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	entry := &TimeEntry{FacultyID: facultyID, ClockIn: at, Breaks: []*TimeBreak{}}
+
+	err := m.DB.QueryRowContext(ctx, query, facultyID, at).Scan(&entry.EntryID)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "time_entries_open_idx"`:
+			return nil, ErrAlreadyClockedIn
+		default:
+			return nil, err
+		}
+	}
+
+	return entry, nil
+}
+
+// ClockOut closes the open entry of the faculty member, ending any break still in progress.
+func (m TimeEntryModel) ClockOut(facultyID int64, at time.Time) (*TimeEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var entryID int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE time_entries
+		SET clock_out = GREATEST(clock_in, $2)
+		WHERE faculty_id = $1 AND clock_out IS NULL
+		RETURNING entry_id`, facultyID, at).Scan(&entryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotClockedIn
+		default:
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE time_entry_breaks
+		SET ended_at = GREATEST(started_at, $2)
+		WHERE entry_id = $1 AND ended_at IS NULL`, entryID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Get(entryID)
+}
+
+func (m TimeEntryModel) StartBreak(facultyID int64, at time.Time) (*TimeEntry, error) {
+	query := `
+		INSERT INTO time_entry_breaks (entry_id, started_at)
+		SELECT entry_id, $2::timestamptz
+		FROM time_entries
+		WHERE faculty_id = $1 AND clock_out IS NULL
+		RETURNING entry_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var entryID int64
+	err := m.DB.QueryRowContext(ctx, query, facultyID, at).Scan(&entryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotClockedIn
+		case err.Error() == `pq: duplicate key value violates unique constraint "time_entry_breaks_open_idx"`:
+			return nil, ErrAlreadyOnBreak
+		default:
+			return nil, err
+		}
+	}
+
+	return m.Get(entryID)
+}
+
+func (m TimeEntryModel) EndBreak(facultyID int64, at time.Time) (*TimeEntry, error) {
+	query := `
+		UPDATE time_entry_breaks b
+		SET ended_at = GREATEST(b.started_at, $2)
+		FROM time_entries e
+		WHERE b.entry_id = e.entry_id AND e.faculty_id = $1 AND e.clock_out IS NULL AND b.ended_at IS NULL
+		RETURNING b.entry_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var entryID int64
+	err := m.DB.QueryRowContext(ctx, query, facultyID, at).Scan(&entryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotOnBreak
+		default:
+			return nil, err
+		}
+	}
+
+	return m.Get(entryID)
+}
+
+// GetOpen returns the entry the faculty member is currently clocked in on.
+func (m TimeEntryModel) GetOpen(facultyID int64) (*TimeEntry, error) {
+	entries, err := m.getAll(`WHERE e.faculty_id = $1 AND e.clock_out IS NULL`, facultyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return entries[0], nil
+}
+
+func (m TimeEntryModel) Get(id int64) (*TimeEntry, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	entries, err := m.getAll(`WHERE e.entry_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return entries[0], nil
+}
+
+// GetAllForPeriod returns the entries clocked in on between from (inclusive) and to (exclusive).
+// A facultyID of 0 returns the entries of every faculty member.
+func (m TimeEntryModel) GetAllForPeriod(facultyID int64, from, to time.Time) ([]*TimeEntry, error) {
+	return m.getAll(`WHERE (e.faculty_id = $1 OR $1 = 0) AND e.clock_in >= $2 AND e.clock_in < $3`, facultyID, from, to)
+}
+
+// getAll returns the entries matching the where clause together with their breaks, ordered by
+// faculty member and clock-in time.
+func (m TimeEntryModel) getAll(where string, args ...any) ([]*TimeEntry, error) {
+	query := `
+		SELECT e.entry_id, e.faculty_id, e.clock_in, e.clock_out, b.break_id, b.started_at, b.ended_at
+		FROM time_entries e
+		LEFT JOIN time_entry_breaks b ON e.entry_id = b.entry_id
+		` + where + `
+		ORDER BY e.faculty_id, e.clock_in, e.entry_id, b.started_at
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*TimeEntry{}
+	var entry *TimeEntry
+
+	for rows.Next() {
+		var (
+			e         TimeEntry
+			breakID   sql.NullInt64
+			startedAt sql.NullTime
+			endedAt   *time.Time
+		)
+
+		err := rows.Scan(&e.EntryID, &e.FacultyID, &e.ClockIn, &e.ClockOut, &breakID, &startedAt, &endedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if entry == nil || entry.EntryID != e.EntryID {
+			e.Breaks = []*TimeBreak{}
+			entry = &e
+			entries = append(entries, entry)
+		}
+
+		if breakID.Valid {
+			entry.Breaks = append(entry.Breaks, &TimeBreak{
+				BreakID:   breakID.Int64,
+				StartedAt: startedAt.Time,
+				EndedAt:   endedAt,
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Update saves a manager's correction of the clock-in and clock-out times of an entry and keeps
+// a record of the old and new times, who made the change and why. Breaks are cut to the new
+// times, and ones that fall entirely outside them are removed.
+func (m TimeEntryModel) Update(entry *TimeEntry, editedBy int64, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldClockIn time.Time
+	var oldClockOut *time.Time
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT clock_in, clock_out
+		FROM time_entries
+		WHERE entry_id = $1
+		FOR UPDATE`, entry.EntryID).Scan(&oldClockIn, &oldClockOut)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE time_entries
+		SET clock_in = $1, clock_out = $2
+		WHERE entry_id = $3`, entry.ClockIn, entry.ClockOut, entry.EntryID)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "time_entries_open_idx"`:
+			return ErrAlreadyClockedIn
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM time_entry_breaks
+		WHERE entry_id = $1 AND (ended_at <= $2 OR started_at >= $3::timestamptz)`,
+		entry.EntryID, entry.ClockIn, entry.ClockOut)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE time_entry_breaks
+		SET started_at = GREATEST(started_at, $2),
+			ended_at = CASE WHEN $3::timestamptz IS NULL THEN ended_at ELSE LEAST(COALESCE(ended_at, $3), $3) END
+		WHERE entry_id = $1`,
+		entry.EntryID, entry.ClockIn, entry.ClockOut)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO time_entry_edits (entry_id, edited_by, reason, old_clock_in, old_clock_out, new_clock_in, new_clock_out)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.EntryID, editedBy, reason, oldClockIn, oldClockOut, entry.ClockIn, entry.ClockOut)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	updated, err := m.Get(entry.EntryID)
+	if err != nil {
+		return err
+	}
+
+	entry.Breaks = updated.Breaks
+
+	return nil
+}
+
+func (m TimeEntryModel) GetEdits(entryID int64) ([]*TimeEntryEdit, error) {
+	query := `
+		SELECT edit_id, entry_id, edited_by, reason, old_clock_in, old_clock_out, new_clock_in, new_clock_out, edited_at
+		FROM time_entry_edits
+		WHERE entry_id = $1
+		ORDER BY edited_at, edit_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edits := []*TimeEntryEdit{}
+	for rows.Next() {
+		var edit TimeEntryEdit
+		err := rows.Scan(
+			&edit.EditID,
+			&edit.EntryID,
+			&edit.EditedBy,
+			&edit.Reason,
+			&edit.OldClockIn,
+			&edit.OldClockOut,
+			&edit.NewClockIn,
+			&edit.NewClockOut,
+			&edit.EditedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, &edit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return edits, nil
+}
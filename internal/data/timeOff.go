@@ -0,0 +1,148 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type TimeOffModel struct {
+	DB *sql.DB
+}
+
+type TimeOff struct {
+	TimeOffID int64     `json:"time_off_id"`
+	FacultyID int64     `json:"faculty_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason"`
+}
+
+// Insert saves the time off unless the faculty member has shifts scheduled during it, in which
+// case nothing is saved and the shifts are returned instead so they can be reassigned first.
+func (m TimeOffModel) Insert(timeOff *TimeOff) ([]*ShiftConflict, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the faculty member so a shift can't be scheduled while the time off is being saved.
+	_, err = tx.ExecContext(ctx, `SELECT 1 FROM faculty WHERE faculty_id = $1 FOR UPDATE`, timeOff.FacultyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT 'shift', shift_id, starts_at, ends_at
+		FROM shifts
+		WHERE faculty_id = $1 AND starts_at < $3 AND ends_at > $2
+		ORDER BY starts_at`, timeOff.FacultyID, timeOff.StartsAt, timeOff.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conflicts := []*ShiftConflict{}
+	for rows.Next() {
+		var conflict ShiftConflict
+		err := rows.Scan(&conflict.Kind, &conflict.ID, &conflict.StartsAt, &conflict.EndsAt)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, &conflict)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(conflicts) > 0 {
+		return conflicts, nil
+	}
+
+	query := `
+		INSERT INTO time_off (faculty_id, starts_at, ends_at, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING time_off_id
+		`
+	args := []any{timeOff.FacultyID, timeOff.StartsAt, timeOff.EndsAt, timeOff.Reason}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&timeOff.TimeOffID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, tx.Commit()
+}
+
+// GetAllByFacultyID returns the faculty member's time off overlapping from and to.
+func (m TimeOffModel) GetAllByFacultyID(facultyID int64, from, to time.Time) ([]*TimeOff, error) {
+	query := `
+		SELECT time_off_id, faculty_id, starts_at, ends_at, reason
+		FROM time_off
+		WHERE faculty_id = $1 AND starts_at < $3 AND ends_at > $2
+		ORDER BY starts_at, time_off_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, facultyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	timeOffs := []*TimeOff{}
+	for rows.Next() {
+		var timeOff TimeOff
+		err := rows.Scan(
+			&timeOff.TimeOffID,
+			&timeOff.FacultyID,
+			&timeOff.StartsAt,
+			&timeOff.EndsAt,
+			&timeOff.Reason,
+		)
+		if err != nil {
+			return nil, err
+		}
+		timeOffs = append(timeOffs, &timeOff)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return timeOffs, nil
+}
+
+func (m TimeOffModel) Delete(id, facultyID int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `DELETE FROM time_off WHERE time_off_id = $1 AND faculty_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, facultyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
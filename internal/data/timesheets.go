@@ -0,0 +1,90 @@
+package data
+
+import (
+	"math"
+	"time"
+)
+
+type TimesheetDay struct {
+	Date  Date    `json:"date"`
+	Hours float64 `json:"hours"`
+}
+
+// Timesheet summarises a faculty member's time entries over one week.
+type Timesheet struct {
+	FacultyID     int64           `json:"faculty_id"`
+	FirstName     string          `json:"first_name"`
+	LastName      string          `json:"last_name"`
+	WeekStart     Date            `json:"week_start"`
+	Days          []*TimesheetDay `json:"days"`
+	TotalHours    float64         `json:"total_hours"`
+	RegularHours  float64         `json:"regular_hours"`
+	OvertimeHours float64         `json:"overtime_hours"`
+	Entries       []*TimeEntry    `json:"entries"`
+}
+
+// WeekStart returns midnight on the Monday of the week t falls in.
+func WeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return dateOf(t).AddDate(0, 0, -offset)
+}
+
+// NewTimesheet builds the timesheet for the week starting at weekStart from the faculty member's
+// entries in that week. Entries count towards the day they were clocked in on, and any time
+// worked beyond overtimeAfter in the week is overtime.
+func NewTimesheet(faculty *Faculty, weekStart time.Time, entries []*TimeEntry, overtimeAfter time.Duration, now time.Time) *Timesheet {
+	timesheet := &Timesheet{
+		FacultyID: faculty.FacultyID,
+		FirstName: faculty.FirstName,
+		LastName:  faculty.LastName,
+		WeekStart: Date(weekStart),
+		Entries:   entries,
+	}
+
+	var days [7]time.Duration
+	var total time.Duration
+
+	for _, entry := range entries {
+		day := weekday(weekStart, entry.ClockIn)
+		if day < 0 {
+			continue
+		}
+
+		worked := entry.Worked(now)
+		days[day] += worked
+		total += worked
+	}
+
+	for i, worked := range days {
+		timesheet.Days = append(timesheet.Days, &TimesheetDay{
+			Date:  Date(weekStart.AddDate(0, 0, i)),
+			Hours: hours(worked),
+		})
+	}
+
+	overtime := max(total-overtimeAfter, 0)
+
+	timesheet.TotalHours = hours(total)
+	timesheet.OvertimeHours = hours(overtime)
+	timesheet.RegularHours = hours(total - overtime)
+
+	return timesheet
+}
+
+// weekday returns the index of the day t falls on in the week starting at weekStart, or -1 if t
+// is outside the week. Days are compared by calendar date, as they aren't all 24 hours long when
+// the clocks change.
+func weekday(weekStart, t time.Time) int {
+	date := dateOf(t.In(weekStart.Location()))
+	for i := 0; i < 7; i++ {
+		if weekStart.AddDate(0, 0, i).Equal(date) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hours converts d to hours rounded to two decimal places.
+func hours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
@@ -0,0 +1,133 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type TuitionPlanModel struct {
+	DB *sql.DB
+}
+
+// TuitionPlan is charged at RateCents every week or month, depending on its frequency. Amounts
+// are kept in integer cents so that they add up exactly.
+type TuitionPlan struct {
+	PlanID               int64     `json:"plan_id"`
+	Name                 string    `json:"name"`
+	Frequency            string    `json:"frequency"`
+	Schedule             string    `json:"schedule"`
+	RateCents            int64     `json:"rate_cents"`
+	RegistrationFeeCents int64     `json:"registration_fee_cents"`
+	Active               bool      `json:"active"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+func (m TuitionPlanModel) Insert(plan *TuitionPlan) error {
+	query := `
+		INSERT INTO tuition_plans (name, frequency, schedule, rate_cents, registration_fee_cents, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING plan_id, created_at
+		`
+	args := []any{plan.Name, plan.Frequency, plan.Schedule, plan.RateCents, plan.RegistrationFeeCents, plan.Active}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&plan.PlanID, &plan.CreatedAt)
+}
+
+func (m TuitionPlanModel) Get(id int64) (*TuitionPlan, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	plans, err := m.getAll(`WHERE plan_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plans) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return plans[0], nil
+}
+
+// GetAll returns the tuition plans, only the ones still offered if activeOnly is set.
+func (m TuitionPlanModel) GetAll(activeOnly bool) ([]*TuitionPlan, error) {
+	return m.getAll(`WHERE active OR NOT $1`, activeOnly)
+}
+
+func (m TuitionPlanModel) getAll(where string, args ...any) ([]*TuitionPlan, error) {
+	query := `
+		SELECT plan_id, name, frequency, schedule, rate_cents, registration_fee_cents, active, created_at
+		FROM tuition_plans
+		` + where + `
+		ORDER BY name, plan_id
+		`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []*TuitionPlan{}
+	for rows.Next() {
+		var plan TuitionPlan
+		err := rows.Scan(
+			&plan.PlanID,
+			&plan.Name,
+			&plan.Frequency,
+			&plan.Schedule,
+			&plan.RateCents,
+			&plan.RegistrationFeeCents,
+			&plan.Active,
+			&plan.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, &plan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// Update saves the changes to the plan. New rates apply from the next billing run, invoices
+// that were already issued keep the rates they were issued with.
+func (m TuitionPlanModel) Update(plan *TuitionPlan) error {
+	query := `
+		UPDATE tuition_plans
+		SET name = $1, frequency = $2, schedule = $3, rate_cents = $4, registration_fee_cents = $5, active = $6
+		WHERE plan_id = $7
+		`
+	args := []any{plan.Name, plan.Frequency, plan.Schedule, plan.RateCents, plan.RegistrationFeeCents, plan.Active, plan.PlanID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind before it is dropped.
+const subscriberBuffer = 32
+
+type Event struct {
+	ID      int64     `json:"id"`
+	ClassID int64     `json:"class_id"`
+	Type    string    `json:"type"`
+	Data    any       `json:"data"`
+	Time    time.Time `json:"time"`
+}
+
+// Hub is an in-process publish/subscribe hub that fans class events out to subscribers. It keeps
+// the most recent events of every class so that a reconnecting client can catch up on what it
+// missed.
+type Hub struct {
+	mu          sync.Mutex
+	lastID      int64
+	historySize int
+	history     map[int64][]Event
+	subscribers map[int64]map[chan Event]struct{}
+	closed      bool
+}
+
+// NewHub returns a hub that remembers up to historySize events per class. Event IDs start from
+// the current time in microseconds so that they keep increasing across restarts.
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		lastID:      time.Now().UnixMicro(),
+		historySize: historySize,
+		history:     make(map[int64][]Event),
+		subscribers: make(map[int64]map[chan Event]struct{}),
+	}
+}
+
+// Publish sends an event to every subscriber of the class. It never blocks: a subscriber whose
+// buffer is full is dropped and its channel closed, so that the client reconnects and catches up
+// from the history instead of holding up everyone else.
+func (h *Hub) Publish(classID int64, eventType string, data any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event := Event{
+		ID:      h.lastID,
+		ClassID: classID,
+		Type:    eventType,
+		Data:    data,
+		Time:    time.Now(),
+	}
+
+	history := append(h.history[classID], event)
+	if len(history) > h.historySize {
+		history = history[len(history)-h.historySize:]
+	}
+	h.history[classID] = history
+
+	for ch := range h.subscribers[classID] {
+		select {
+		case ch <- event:
+		default:
+			h.remove(classID, ch)
+		}
+	}
+}
+
+// Subscribe registers for the events of a class. It returns the remembered events published
+// after lastEventID (use 0 for none), a channel of new events and a function that must be called
+// to unsubscribe. The channel is closed if the subscriber falls too far behind.
+func (h *Hub) Subscribe(classID, lastEventID int64) (missed []Event, events <-chan Event, unsubscribe func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if lastEventID > 0 {
+		for _, event := range h.history[classID] {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	if h.closed {
+		close(ch)
+		return missed, ch, func() {}
+	}
+	if h.subscribers[classID] == nil {
+		h.subscribers[classID] = make(map[chan Event]struct{})
+	}
+	h.subscribers[classID][ch] = struct{}{}
+
+	unsubscribe = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		h.remove(classID, ch)
+	}
+
+	return missed, ch, unsubscribe
+}
+
+// Close drops every subscriber and closes their channels so that open streams end, for when the
+// server shuts down. Subscribing after Close returns a closed channel.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for classID, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			h.remove(classID, ch)
+		}
+	}
+}
+
+// remove drops a subscriber and closes its channel. It must be called with the lock held and is
+// safe to call more than once.
+func (h *Hub) remove(classID int64, ch chan Event) {
+	if _, ok := h.subscribers[classID][ch]; !ok {
+		return
+	}
+
+	delete(h.subscribers[classID], ch)
+	if len(h.subscribers[classID]) == 0 {
+		delete(h.subscribers, classID)
+	}
+
+	close(ch)
+}
@@ -0,0 +1,180 @@
+// Package immunization checks students' immunizations against the schedule of doses every child
+// needs by a given age, taking exemptions into account.
+package immunization
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// Compliance statuses of a student, and of the doses they are missing.
+const (
+	// StatusCompliant is a student who has had every dose that is due, or is exempt from it.
+	StatusCompliant = "compliant"
+	// StatusDue is a dose that falls due within DueSoonDays or is in its grace period.
+	StatusDue = "due"
+	// StatusOverdue is a dose whose grace period has passed.
+	StatusOverdue = "overdue"
+)
+
+// DueSoonDays is how many days ahead of its due date a dose is reported as due.
+const DueSoonDays = 30
+
+// MissingDose is a scheduled dose a student hasn't had yet.
+type MissingDose struct {
+	Vaccine   string    `json:"vaccine"`
+	Dose      int       `json:"dose"`
+	DueOn     data.Date `json:"due_on"`
+	OverdueOn data.Date `json:"overdue_on"`
+	Status    string    `json:"status"`
+}
+
+// Compliance is where a student stands with the schedule on a day. The student is overdue if
+// any dose is, due if any dose is, and compliant otherwise.
+type Compliance struct {
+	StudentID int64          `json:"student_id"`
+	AgeMonths int            `json:"age_months"`
+	Status    string         `json:"status"`
+	Missing   []*MissingDose `json:"missing"`
+	Exempt    []string       `json:"exempt"`
+}
+
+// Check works out the student's compliance on the day. Vaccines are matched by name regardless
+// of case, and a dose counts as given if a record of that dose number exists.
+func Check(student *data.Student, schedule []*data.ScheduledDose, records []*data.Immunization, exemptions []*data.ImmunizationExemption, on time.Time) *Compliance {
+	on = time.Date(on.Year(), on.Month(), on.Day(), 0, 0, 0, 0, time.UTC)
+	born := time.Time(student.DateOfBirth)
+
+	compliance := &Compliance{
+		StudentID: student.StudentID,
+		AgeMonths: ageMonths(born, on),
+		Status:    StatusCompliant,
+		Missing:   []*MissingDose{},
+		Exempt:    []string{},
+	}
+
+	given := map[doseKey]bool{}
+	for _, record := range records {
+		if record.StudentID == student.StudentID {
+			given[doseKey{strings.ToLower(record.Vaccine), record.Dose}] = true
+		}
+	}
+
+	for _, dose := range schedule {
+		if given[doseKey{strings.ToLower(dose.Vaccine), dose.Dose}] {
+			continue
+		}
+
+		dueOn := born.AddDate(0, dose.DueAgeMonths, 0)
+		if on.Before(dueOn.AddDate(0, 0, -DueSoonDays)) {
+			continue
+		}
+
+		if exempt(student.StudentID, dose.Vaccine, exemptions, on) {
+			if !slices.Contains(compliance.Exempt, dose.Vaccine) {
+				compliance.Exempt = append(compliance.Exempt, dose.Vaccine)
+			}
+			continue
+		}
+
+		overdueOn := dueOn.AddDate(0, dose.GraceMonths, 0)
+		missing := &MissingDose{
+			Vaccine:   dose.Vaccine,
+			Dose:      dose.Dose,
+			DueOn:     data.Date(dueOn),
+			OverdueOn: data.Date(overdueOn),
+			Status:    StatusDue,
+		}
+
+		if !on.Before(overdueOn) {
+			missing.Status = StatusOverdue
+			compliance.Status = StatusOverdue
+		} else if compliance.Status == StatusCompliant {
+			compliance.Status = StatusDue
+		}
+
+		compliance.Missing = append(compliance.Missing, missing)
+	}
+
+	return compliance
+}
+
+// ClassReport lists the overdue students of a class.
+type ClassReport struct {
+	ClassID   int64             `json:"class_id"`
+	ClassName string            `json:"class_name"`
+	Students  []*OverdueStudent `json:"students"`
+}
+
+type OverdueStudent struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	*Compliance
+}
+
+// Report checks every enrolled student, who are expected in class order, and returns the classes
+// with students who are overdue on the day.
+func Report(enrolled []*data.EnrolledStudent, schedule []*data.ScheduledDose, records []*data.Immunization, exemptions []*data.ImmunizationExemption, on time.Time) []*ClassReport {
+	byStudent := map[int64][]*data.Immunization{}
+	for _, record := range records {
+		byStudent[record.StudentID] = append(byStudent[record.StudentID], record)
+	}
+
+	reports := []*ClassReport{}
+	var current *ClassReport
+
+	for _, student := range enrolled {
+		compliance := Check(&student.Student, schedule, byStudent[student.StudentID], exemptions, on)
+		if compliance.Status != StatusOverdue {
+			continue
+		}
+
+		if current == nil || current.ClassID != student.ClassID {
+			current = &ClassReport{ClassID: student.ClassID, ClassName: student.ClassName, Students: []*OverdueStudent{}}
+			reports = append(reports, current)
+		}
+
+		current.Students = append(current.Students, &OverdueStudent{
+			FirstName:  student.FirstName,
+			LastName:   student.LastName,
+			Compliance: compliance,
+		})
+	}
+
+	return reports
+}
+
+// exempt reports whether the student has an exemption from the vaccine, or from every vaccine,
+// in force on the day.
+func exempt(studentID int64, vaccine string, exemptions []*data.ImmunizationExemption, on time.Time) bool {
+	for _, exemption := range exemptions {
+		if exemption.StudentID != studentID {
+			continue
+		}
+		if exemption.Vaccine != "" && !strings.EqualFold(exemption.Vaccine, vaccine) {
+			continue
+		}
+		if exemption.ExpiresOn != nil && time.Time(*exemption.ExpiresOn).Before(on) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// ageMonths returns how many whole months old someone born on the day is on another.
+func ageMonths(born, on time.Time) int {
+	months := (on.Year()-born.Year())*12 + int(on.Month()-born.Month())
+	if on.Day() < born.Day() {
+		months--
+	}
+	return max(months, 0)
+}
+
+type doseKey struct {
+	vaccine string
+	dose    int
+}
@@ -0,0 +1,106 @@
+// Package medication works out which scheduled doses of students' medications are due.
+package medication
+
+import (
+	"slices"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// Statuses of a scheduled dose.
+const (
+	// StatusDue is a dose whose time is within the window either side of now.
+	StatusDue = "due"
+	// StatusOverdue is a dose whose time passed more than the window ago.
+	StatusOverdue = "overdue"
+)
+
+// DueDose is the next scheduled dose of a medication a student hasn't had yet today.
+type DueDose struct {
+	AuthorizationID int64      `json:"authorization_id"`
+	StudentID       int64      `json:"student_id"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Medication      string     `json:"medication"`
+	Dosage          string     `json:"dosage"`
+	Route           string     `json:"route"`
+	Instructions    string     `json:"instructions"`
+	DueAt           time.Time  `json:"due_at"`
+	Status          string     `json:"status"`
+	GivenToday      int        `json:"given_today"`
+	LastGivenAt     *time.Time `json:"last_given_at"`
+}
+
+// Due returns the doses due now. Each dose given today uses up the earliest of the day's times,
+// so the next time left is due once now is within window of it, and overdue once it is more
+// than window behind. Medications without times are only given when needed and never come due,
+// nor do those whose daily maximum has been given. Doses are in the order of the authorizations.
+func Due(authorizations []*data.ClassMedication, administrations []*data.MedicationAdministration, now time.Time, window time.Duration) []*DueDose {
+	year, month, day := now.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	given := map[int64][]*data.MedicationAdministration{}
+	for _, administration := range administrations {
+		if !administration.GivenAt.Before(midnight) && administration.GivenAt.Before(midnight.AddDate(0, 0, 1)) {
+			given[administration.AuthorizationID] = append(given[administration.AuthorizationID], administration)
+		}
+	}
+
+	due := []*DueDose{}
+	for _, authorization := range authorizations {
+		doses := given[authorization.AuthorizationID]
+		if len(doses) >= authorization.MaxDailyDoses {
+			continue
+		}
+
+		slots := times(authorization.Times, midnight)
+		if len(doses) >= len(slots) {
+			continue
+		}
+
+		next := slots[len(doses)]
+		if now.Before(next.Add(-window)) {
+			continue
+		}
+
+		dose := &DueDose{
+			AuthorizationID: authorization.AuthorizationID,
+			StudentID:       authorization.StudentID,
+			FirstName:       authorization.FirstName,
+			LastName:        authorization.LastName,
+			Medication:      authorization.Medication,
+			Dosage:          authorization.Dosage,
+			Route:           authorization.Route,
+			Instructions:    authorization.Instructions,
+			DueAt:           next,
+			Status:          StatusDue,
+			GivenToday:      len(doses),
+		}
+		if now.After(next.Add(window)) {
+			dose.Status = StatusOverdue
+		}
+		if len(doses) > 0 {
+			dose.LastGivenAt = &doses[len(doses)-1].GivenAt
+		}
+
+		due = append(due, dose)
+	}
+
+	return due
+}
+
+// times returns the HH:MM times of day on the day starting at midnight, in order. Times that
+// don't parse are skipped.
+func times(values []string, midnight time.Time) []time.Time {
+	slots := []time.Time{}
+	for _, value := range values {
+		t, err := time.Parse("15:04", value)
+		if err != nil {
+			continue
+		}
+		slots = append(slots, time.Date(midnight.Year(), midnight.Month(), midnight.Day(), t.Hour(), t.Minute(), 0, 0, midnight.Location()))
+	}
+	slices.SortFunc(slots, func(a, b time.Time) int { return a.Compare(b) })
+	return slots
+}
@@ -0,0 +1,54 @@
+package menu
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Allergens are the allergens menu items are tagged with and allergies are recorded as: the
+// major food allergens, and other for anything else.
+var Allergens = []string{"milk", "egg", "peanut", "tree_nut", "soy", "wheat", "fish", "shellfish", "sesame", "other"}
+
+// allergenWords are words that, in a student's dietary restrictions, rule out food containing
+// the allergen.
+var allergenWords = map[string][]string{
+	"milk":      {"milk", "dairy", "lactose", "cheese", "butter", "cream", "yogurt", "vegan"},
+	"egg":       {"egg", "vegan"},
+	"peanut":    {"peanut"},
+	"tree_nut":  {"nut", "almond", "cashew", "walnut", "pecan", "hazelnut", "pistachio"},
+	"soy":       {"soy", "soya", "tofu"},
+	"wheat":     {"wheat", "gluten"},
+	"fish":      {"fish", "vegan", "vegetarian"},
+	"shellfish": {"shellfish", "shrimp", "prawn", "crab", "lobster", "vegan", "vegetarian"},
+	"sesame":    {"sesame", "tahini"},
+}
+
+// Tag normalises an allergen to the form used in the vocabulary, trimmed lowercase with words
+// joined by underscores, so "Tree nut" becomes "tree_nut".
+func Tag(allergen string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(allergen), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-' || r == '_'
+	}), "_")
+}
+
+// IsAllergen reports whether the tag is in the vocabulary.
+func IsAllergen(tag string) bool {
+	return contains(Allergens, tag)
+}
+
+// restricts reports whether the dietary restrictions rule out food containing the allergen,
+// by naming it or something made from it, singular or plural.
+func restricts(restrictions, allergen string) bool {
+	words := strings.FieldsFunc(strings.ToLower(restrictions), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	for _, name := range allergenWords[allergen] {
+		for _, word := range words {
+			if word == name || word == name+"s" || word == name+"es" {
+				return true
+			}
+		}
+	}
+	return false
+}
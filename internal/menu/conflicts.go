@@ -0,0 +1,101 @@
+// Package menu checks what is on the menu against the allergies and dietary restrictions of the
+// students eating it.
+package menu
+
+import (
+	"strings"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// Meals a menu item can be served at, in the order they are served.
+var Meals = []string{"breakfast", "am_snack", "lunch", "pm_snack", "dinner"}
+
+// Conflict is a menu item a student is allergic to, or that their dietary restrictions rule out,
+// in which case the restrictions are given. It is resolved once a substitution has been recorded
+// for the student.
+type Conflict struct {
+	StudentID    int64                  `json:"student_id"`
+	FirstName    string                 `json:"first_name"`
+	LastName     string                 `json:"last_name"`
+	ItemID       int64                  `json:"item_id"`
+	Meal         string                 `json:"meal"`
+	Item         string                 `json:"item"`
+	Allergies    []*data.AllergyFlag    `json:"allergies"`
+	Restrictions string                 `json:"dietary_restrictions,omitempty"`
+	Substitution *data.MenuSubstitution `json:"substitution"`
+}
+
+// Conflicts matches the allergens of the items against the students' allergies and dietary
+// restrictions, both given by student ID. Conflicts are in the order of the items, then the
+// students.
+func Conflicts(items []*data.MenuItem, students []*data.Student, allergies map[int64][]*data.AllergyFlag, restrictions map[int64]string, substitutions []*data.MenuSubstitution) []*Conflict {
+	substituted := map[substitutionKey]*data.MenuSubstitution{}
+	for _, substitution := range substitutions {
+		substituted[substitutionKey{substitution.ItemID, substitution.StudentID}] = substitution
+	}
+
+	conflicts := []*Conflict{}
+	for _, item := range items {
+		for _, student := range students {
+			matched := []*data.AllergyFlag{}
+			for _, allergy := range allergies[student.StudentID] {
+				if contains(item.Allergens, allergy.Allergen) {
+					matched = append(matched, allergy)
+				}
+			}
+
+			restricted := ""
+			for _, allergen := range item.Allergens {
+				if restricts(restrictions[student.StudentID], allergen) {
+					restricted = restrictions[student.StudentID]
+					break
+				}
+			}
+
+			if len(matched) == 0 && restricted == "" {
+				continue
+			}
+
+			conflicts = append(conflicts, &Conflict{
+				StudentID:    student.StudentID,
+				FirstName:    student.FirstName,
+				LastName:     student.LastName,
+				ItemID:       item.ItemID,
+				Meal:         item.Meal,
+				Item:         item.Name,
+				Allergies:    matched,
+				Restrictions: restricted,
+				Substitution: substituted[substitutionKey{item.ItemID, student.StudentID}],
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// Tags normalises allergen tags with Tag, dropping empty and repeated ones.
+func Tags(allergens []string) []string {
+	tags := []string{}
+	for _, allergen := range allergens {
+		tag := Tag(allergen)
+		if tag != "" && !contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func contains(tags []string, allergen string) bool {
+	for _, tag := range tags {
+		if strings.EqualFold(tag, strings.TrimSpace(allergen)) {
+			return true
+		}
+	}
+	return false
+}
+
+type substitutionKey struct {
+	itemID    int64
+	studentID int64
+}
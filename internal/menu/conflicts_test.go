@@ -0,0 +1,106 @@
+package menu
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+func TestTags(t *testing.T) {
+	got := Tags([]string{" Milk", "Tree nut", "tree-nut", "", "PEANUT", "milk"})
+	want := []string{"milk", "tree_nut", "peanut"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("Tags = %q, want %q", got, want)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	items := []*data.MenuItem{
+		{ItemID: 1, Meal: "lunch", Name: "Mac and cheese", Allergens: []string{"milk", "wheat"}},
+		{ItemID: 2, Meal: "pm_snack", Name: "Apple slices", Allergens: []string{}},
+		{ItemID: 3, Meal: "pm_snack", Name: "Trail mix", Allergens: []string{"peanut", "tree_nut"}},
+	}
+
+	students := []*data.Student{
+		{StudentID: 10, FirstName: "Ada"},
+		{StudentID: 11, FirstName: "Grace"},
+		{StudentID: 12, FirstName: "Alan"},
+		{StudentID: 13, FirstName: "Edsger"},
+	}
+
+	allergies := map[int64][]*data.AllergyFlag{
+		10: {{Allergen: "peanut", Severity: "life_threatening"}},
+		13: {{Allergen: "other", Details: "kiwi", Severity: "mild"}},
+	}
+
+	restrictions := map[int64]string{
+		11: "Dairy-free, no nuts",
+		12: "Halal",
+	}
+
+	substitutions := []*data.MenuSubstitution{
+		{SubstitutionID: 1, ItemID: 3, StudentID: 10, Substitute: "Raisins"},
+	}
+
+	type conflict struct {
+		itemID       int64
+		studentID    int64
+		allergies    int
+		restricted   bool
+		substitution bool
+	}
+
+	want := []conflict{
+		{1, 11, 0, true, false},
+		{3, 10, 1, false, true},
+		{3, 11, 0, true, false},
+	}
+
+	conflicts := Conflicts(items, students, allergies, restrictions, substitutions)
+
+	if len(conflicts) != len(want) {
+		t.Fatalf("got %d conflicts, want %d", len(conflicts), len(want))
+	}
+
+	for i, want := range want {
+		got := conflicts[i]
+		if got.ItemID != want.itemID || got.StudentID != want.studentID {
+			t.Errorf("conflict %d is item %d for student %d, want item %d for student %d", i, got.ItemID, got.StudentID, want.itemID, want.studentID)
+		}
+		if len(got.Allergies) != want.allergies {
+			t.Errorf("conflict %d matched %d allergies, want %d", i, len(got.Allergies), want.allergies)
+		}
+		if (got.Restrictions != "") != want.restricted {
+			t.Errorf("conflict %d restrictions = %q, want restricted %v", i, got.Restrictions, want.restricted)
+		}
+		if (got.Substitution != nil) != want.substitution {
+			t.Errorf("conflict %d substitution = %v, want substituted %v", i, got.Substitution, want.substitution)
+		}
+	}
+}
+
+func TestRestricts(t *testing.T) {
+	tests := []struct {
+		restrictions string
+		allergen     string
+		want         bool
+	}{
+		{"Dairy-free", "milk", true},
+		{"no eggs please", "egg", true},
+		{"Gluten free", "wheat", true},
+		{"vegan", "shellfish", true},
+		{"vegetarian", "milk", false},
+		{"nutritious food only", "tree_nut", false},
+		{"no peanuts", "tree_nut", false},
+		{"", "milk", false},
+		{"anything", "other", false},
+	}
+
+	for _, tt := range tests {
+		if got := restricts(tt.restrictions, tt.allergen); got != tt.want {
+			t.Errorf("restricts(%q, %q) = %v, want %v", tt.restrictions, tt.allergen, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Package pdf writes simple PDF documents: US Letter pages of text in the standard Helvetica
+// fonts, which every PDF reader has built in, and straight lines. It is just enough for
+// invoices, receipts and statements without depending on anything outside the standard library.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Page size of US Letter in points, of which there are 72 to the inch.
+const (
+	PageWidth  = 612.0
+	PageHeight = 792.0
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+// Document is a PDF document being put together page by page.
+type Document struct {
+	title string
+	pages []*Page
+}
+
+// Page is a page of a document. Positions are in points from the top left corner of the page,
+// and y is the baseline of text.
+type Page struct {
+	content bytes.Buffer
+}
+
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// AddPage adds a blank page to the end of the document and returns it.
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Pages returns the pages added so far.
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// Text writes s with its left edge at x.
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td %s Tj ET\n", font+1, num(size), num(x), num(PageHeight-y), literal(s))
+}
+
+// TextRight writes s with its right edge at x, for lining up amounts.
+func (p *Page) TextRight(x, y float64, font Font, size float64, s string) {
+	p.Text(x-TextWidth(font, size, s), y, font, size, s)
+}
+
+// Line draws a thin line from one point to another.
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "0.5 w %s %s m %s %s l S\n", num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// TextWidth returns how wide s is when written in the font at the size.
+func TextWidth(font Font, size float64, s string) float64 {
+	widths := &helveticaWidths
+	if font == Bold {
+		widths = &helveticaBoldWidths
+	}
+
+	total := 0
+	for _, b := range encode(s) {
+		if b >= 32 && b <= 126 {
+			total += widths[b-32]
+		} else {
+			total += 556
+		}
+	}
+
+	return float64(total) * size / 1000
+}
+
+// WriteTo writes the document out as a PDF file.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pages := d.pages
+	if len(pages) == 0 {
+		pages = []*Page{{}}
+	}
+
+	// objects are numbered from 1: the catalog, the page tree, the info dictionary, the two
+	// fonts, and then every page followed by its content stream
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Title " + literal(d.title) + " /Producer (daycare-server) >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	kids := make([]string, len(pages))
+	for i, page := range pages {
+		pageObject := len(objects) + 1
+		kids[i] = strconv.Itoa(pageObject) + " 0 R"
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents %d 0 R >>",
+				num(PageWidth), num(PageHeight), pageObject+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	offsets := make([]int64, len(objects))
+
+	// the comment of high bytes tells tools the file holds binary data
+	fmt.Fprint(cw, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	for i, object := range objects {
+		offsets[i] = cw.n
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+
+	return cw.n, cw.w.Flush()
+}
+
+// Bytes returns the document as a PDF file.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	// writing to a bytes.Buffer can't fail
+	_, _ = d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// num formats a position or size with at most two decimals.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// literal returns s as a PDF string literal in WinAnsiEncoding.
+func literal(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, b := range encode(s) {
+		switch {
+		case b == '(' || b == ')' || b == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(b)
+		case b < 32 || b > 126:
+			fmt.Fprintf(&sb, "\\%03o", b)
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// encode converts s to WinAnsiEncoding, which matches Latin-1 apart from a few punctuation
+// marks. Characters it doesn't have become question marks.
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			out = append(out, ' ')
+		case r >= 32 && r <= 126, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsiPunctuation[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+var winAnsiPunctuation = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// Widths of the printable ASCII characters, from space to tilde, in thousandths of the font
+// size, taken from the Adobe font metrics of the standard fonts.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,200 @@
+// Package subsidy works out what government agencies owe for the days subsidised students
+// attended, and writes the claims in the formats agencies accept. All amounts are integer cents.
+package subsidy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/liamgluna/daycare-server/internal/data"
+)
+
+// Kinds of discrepancies, which are worth a look before a claim is sent.
+const (
+	// KindOverAuthorized is attendance beyond the authorized days, which is not claimed.
+	KindOverAuthorized = "over_authorized"
+	// KindNoAttendance is an authorization without any attendance in the month.
+	KindNoAttendance = "no_attendance"
+	// KindMissingCheckOut is a day the student was checked in but never checked out.
+	KindMissingCheckOut = "missing_check_out"
+	// KindOutsideAuthorization is attendance on days no authorization with the agency covers.
+	KindOutsideAuthorization = "outside_authorization"
+	// KindCopayExceedsClaim is a co-pay larger than the value of the days claimed.
+	KindCopayExceedsClaim = "copay_exceeds_claim"
+)
+
+// Claim is what one agency owes for one month.
+type Claim struct {
+	AgencyID      int64     `json:"agency_id"`
+	AgencyName    string    `json:"agency_name"`
+	AgencyCode    string    `json:"agency_code"`
+	From          data.Date `json:"from"`
+	To            data.Date `json:"to"`
+	GeneratedOn   data.Date `json:"generated_on"`
+	Lines         []*Line   `json:"lines"`
+	DaysClaimed   int       `json:"days_claimed"`
+	TotalCents    int64     `json:"total_cents"`
+	Discrepancies int       `json:"discrepancies"`
+}
+
+// Line claims the days a student attended under one authorization.
+type Line struct {
+	AuthorizationID int64          `json:"authorization_id"`
+	StudentID       int64          `json:"student_id"`
+	CaseNumber      string         `json:"case_number"`
+	FirstName       string         `json:"first_name"`
+	LastName        string         `json:"last_name"`
+	AuthorizedDays  int            `json:"authorized_days"`
+	DaysAttended    int            `json:"days_attended"`
+	DaysClaimed     int            `json:"days_claimed"`
+	DailyRateCents  int64          `json:"daily_rate_cents"`
+	CopayCents      int64          `json:"copay_cents"`
+	AmountCents     int64          `json:"amount_cents"`
+	Discrepancies   []*Discrepancy `json:"discrepancies"`
+}
+
+type Discrepancy struct {
+	Kind    string      `json:"kind"`
+	Message string      `json:"message"`
+	Days    []data.Date `json:"days,omitempty"`
+}
+
+// BuildClaim works out the agency's claim for the days from one day to another, both included,
+// normally a calendar month.
+//
+// Every authorization in effect during the month gets a line claiming the days the student was
+// present while it was in effect, up to its authorized days, at its daily rate less the monthly
+// co-pay. Days are counted once however many classes the student attended that day.
+func BuildClaim(agency *data.SubsidyAgency, from, to time.Time, auths []*data.ClaimableAuthorization, days []*data.AttendedDay, now time.Time) *Claim {
+	from, to = day(from), day(to)
+
+	claim := &Claim{
+		AgencyID:    agency.AgencyID,
+		AgencyName:  agency.Name,
+		AgencyCode:  agency.Code,
+		From:        data.Date(from),
+		To:          data.Date(to),
+		GeneratedOn: data.Date(day(now)),
+		Lines:       []*Line{},
+	}
+
+	byStudent := map[int64][]*data.AttendedDay{}
+	for _, d := range days {
+		byStudent[d.StudentID] = append(byStudent[d.StudentID], d)
+	}
+
+	// days covered by any of the student's authorizations, to find the ones none of them cover
+	covered := map[int64]map[time.Time]bool{}
+	firstLine := map[int64]*Line{}
+
+	for _, auth := range auths {
+		starts, ends := authorizedRange(auth, from, to)
+
+		line := &Line{
+			AuthorizationID: auth.AuthorizationID,
+			StudentID:       auth.StudentID,
+			CaseNumber:      auth.CaseNumber,
+			FirstName:       auth.FirstName,
+			LastName:        auth.LastName,
+			AuthorizedDays:  auth.AuthorizedDays,
+			DailyRateCents:  auth.DailyRateCents,
+			CopayCents:      auth.CopayCents,
+			Discrepancies:   []*Discrepancy{},
+		}
+
+		if covered[auth.StudentID] == nil {
+			covered[auth.StudentID] = map[time.Time]bool{}
+			firstLine[auth.StudentID] = line
+		}
+
+		missingCheckOut := []data.Date{}
+		for _, d := range byStudent[auth.StudentID] {
+			date := day(d.Date)
+			if date.Before(starts) || date.After(ends) {
+				continue
+			}
+
+			covered[auth.StudentID][date] = true
+			line.DaysAttended++
+			if d.MissingCheckOut {
+				missingCheckOut = append(missingCheckOut, data.Date(date))
+			}
+		}
+
+		line.DaysClaimed = min(line.DaysAttended, line.AuthorizedDays)
+		line.AmountCents = int64(line.DaysClaimed)*line.DailyRateCents - line.CopayCents
+
+		switch {
+		case line.DaysAttended == 0:
+			line.addDiscrepancy(KindNoAttendance, nil, "no attendance while the authorization was in effect")
+		case line.DaysAttended > line.AuthorizedDays:
+			line.addDiscrepancy(KindOverAuthorized, nil, "attended %d days but only %d are authorized, %d days are not claimed",
+				line.DaysAttended, line.AuthorizedDays, line.DaysAttended-line.AuthorizedDays)
+		}
+
+		if len(missingCheckOut) > 0 {
+			line.addDiscrepancy(KindMissingCheckOut, missingCheckOut, "checked in without a check-out on %d days", len(missingCheckOut))
+		}
+
+		if line.AmountCents < 0 {
+			line.addDiscrepancy(KindCopayExceedsClaim, nil, "the co-pay is more than the value of the days claimed")
+			line.AmountCents = 0
+		}
+
+		claim.Lines = append(claim.Lines, line)
+	}
+
+	for studentID, line := range firstLine {
+		outside := []data.Date{}
+		for _, d := range byStudent[studentID] {
+			if !covered[studentID][day(d.Date)] {
+				outside = append(outside, data.Date(day(d.Date)))
+			}
+		}
+
+		if len(outside) > 0 {
+			line.addDiscrepancy(KindOutsideAuthorization, outside, "attended %d days no authorization covers, which are not claimed", len(outside))
+		}
+	}
+
+	for _, line := range claim.Lines {
+		claim.DaysClaimed += line.DaysClaimed
+		claim.TotalCents += line.AmountCents
+		if len(line.Discrepancies) > 0 {
+			claim.Discrepancies++
+		}
+	}
+
+	return claim
+}
+
+func (l *Line) addDiscrepancy(kind string, days []data.Date, format string, args ...any) {
+	l.Discrepancies = append(l.Discrepancies, &Discrepancy{
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+		Days:    days,
+	})
+}
+
+// authorizedRange returns the days of from to to that the authorization is in effect.
+func authorizedRange(auth *data.ClaimableAuthorization, from, to time.Time) (time.Time, time.Time) {
+	starts := day(time.Time(auth.StartsOn))
+	if starts.Before(from) {
+		starts = from
+	}
+
+	ends := to
+	if auth.EndsOn != nil {
+		if e := day(time.Time(*auth.EndsOn)); e.Before(ends) {
+			ends = e
+		}
+	}
+
+	return starts, ends
+}
+
+// day returns the day of t as midnight UTC, the way dates come back from the database.
+func day(t time.Time) time.Time {
+	year, month, d := t.Date()
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
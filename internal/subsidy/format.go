@@ -0,0 +1,199 @@
+package subsidy
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteCSV writes the claim as CSV, one row per line after a header row. Discrepancies are
+// joined into the last column so they stand out when the file is opened in a spreadsheet.
+func WriteCSV(w io.Writer, claim *Claim) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{
+		"case_number", "student_id", "last_name", "first_name", "authorized_days", "days_attended",
+		"days_claimed", "daily_rate_cents", "copay_cents", "amount_cents", "discrepancies",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, line := range claim.Lines {
+		discrepancies := make([]string, len(line.Discrepancies))
+		for i, d := range line.Discrepancies {
+			discrepancies[i] = d.Kind + ": " + d.Message
+		}
+
+		err := cw.Write([]string{
+			line.CaseNumber,
+			strconv.FormatInt(line.StudentID, 10),
+			line.LastName,
+			line.FirstName,
+			strconv.Itoa(line.AuthorizedDays),
+			strconv.Itoa(line.DaysAttended),
+			strconv.Itoa(line.DaysClaimed),
+			strconv.FormatInt(line.DailyRateCents, 10),
+			strconv.FormatInt(line.CopayCents, 10),
+			strconv.FormatInt(line.AmountCents, 10),
+			strings.Join(discrepancies, "; "),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// RecordLength is the length of every record of a fixed-width claim, not counting the newline.
+const RecordLength = 120
+
+// WriteFixedWidth writes the claim in a fixed-width format for agencies that cannot read CSV.
+// The layout is stable, columns are only ever added at the end of a record. Every record is
+// RecordLength characters followed by a newline, text is upper case ASCII padded with spaces on
+// the right, numbers are padded with zeros on the left and dates are YYYYMMDD. Positions count
+// from 1.
+//
+// Header, one per claim:
+//
+//	1       record type "H"
+//	2-11    agency code
+//	12-19   first day of the claim
+//	20-27   last day of the claim
+//	28-35   day the claim was generated
+//	36-75   agency name
+//
+// Detail, one per line:
+//
+//	1       record type "D"
+//	2-16    case number
+//	17-26   student ID
+//	27-46   last name
+//	47-61   first name
+//	62-63   authorized days
+//	64-65   days attended
+//	66-67   days claimed
+//	68-76   daily rate in cents
+//	77-85   co-pay in cents
+//	86-96   amount claimed in cents
+//	97      "Y" if the line has discrepancies, "N" otherwise
+//
+// Trailer, one per claim:
+//
+//	1       record type "T"
+//	2-7     number of detail records
+//	8-13    total days claimed
+//	14-26   total amount claimed in cents
+//
+// Names longer than their column are cut short. Codes, case numbers and numbers that don't fit
+// return an error instead, as cutting them short would claim for the wrong thing.
+func WriteFixedWidth(w io.Writer, claim *Claim) error {
+	bw := bufio.NewWriter(w)
+
+	records := [][]field{
+		{
+			text("H", 1),
+			code(claim.AgencyCode, 10, "agency code"),
+			text(time.Time(claim.From).Format("20060102"), 8),
+			text(time.Time(claim.To).Format("20060102"), 8),
+			text(time.Time(claim.GeneratedOn).Format("20060102"), 8),
+			text(claim.AgencyName, 40),
+		},
+	}
+
+	for _, line := range claim.Lines {
+		flag := "N"
+		if len(line.Discrepancies) > 0 {
+			flag = "Y"
+		}
+
+		records = append(records, []field{
+			text("D", 1),
+			code(line.CaseNumber, 15, "case number"),
+			number(line.StudentID, 10, "student ID"),
+			text(line.LastName, 20),
+			text(line.FirstName, 15),
+			number(int64(line.AuthorizedDays), 2, "authorized days"),
+			number(int64(line.DaysAttended), 2, "days attended"),
+			number(int64(line.DaysClaimed), 2, "days claimed"),
+			number(line.DailyRateCents, 9, "daily rate"),
+			number(line.CopayCents, 9, "co-pay"),
+			number(line.AmountCents, 11, "amount"),
+			text(flag, 1),
+		})
+	}
+
+	records = append(records, []field{
+		text("T", 1),
+		number(int64(len(claim.Lines)), 6, "number of records"),
+		number(int64(claim.DaysClaimed), 6, "total days claimed"),
+		number(claim.TotalCents, 13, "total amount"),
+	})
+
+	for _, record := range records {
+		var sb strings.Builder
+		for _, f := range record {
+			if f.err != nil {
+				return f.err
+			}
+			sb.WriteString(f.value)
+		}
+
+		_, err := fmt.Fprintf(bw, "%-*s\n", RecordLength, sb.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// field is one column of a fixed-width record, or the reason it could not be written.
+type field struct {
+	value string
+	err   error
+}
+
+// text returns s as upper case ASCII, cut short or padded with spaces to width.
+func text(s string, width int) field {
+	s = ascii(s)
+	if len(s) > width {
+		s = s[:width]
+	}
+	return field{value: fmt.Sprintf("%-*s", width, s)}
+}
+
+// code is like text, but fails rather than cutting s short.
+func code(s string, width int, name string) field {
+	s = ascii(s)
+	if len(s) > width {
+		return field{err: fmt.Errorf("%s %q is longer than %d characters", name, s, width)}
+	}
+	return field{value: fmt.Sprintf("%-*s", width, s)}
+}
+
+// number returns n padded with zeros to width, failing if it is negative or too long.
+func number(n int64, width int, name string) field {
+	s := strconv.FormatInt(n, 10)
+	if n < 0 || len(s) > width {
+		return field{err: fmt.Errorf("%s %d does not fit in %d digits", name, n, width)}
+	}
+	return field{value: fmt.Sprintf("%0*d", width, n)}
+}
+
+// ascii upper cases s and replaces anything that isn't printable ASCII with a question mark, so
+// that every character takes up exactly one column.
+func ascii(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return '?'
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(s)))
+}
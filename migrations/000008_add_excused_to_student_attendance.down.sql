@@ -0,0 +1 @@
+ALTER TABLE student_attendance DROP COLUMN IF EXISTS excused;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE student_attendance ADD COLUMN IF NOT EXISTS excused bool NOT NULL DEFAULT false;
\ No newline at end of file
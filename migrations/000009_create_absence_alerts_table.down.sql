@@ -0,0 +1 @@
+DROP TABLE IF EXISTS absence_alerts;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS absence_alerts (
+    alert_id serial PRIMARY KEY,
+    student_id integer REFERENCES students(student_id) ON DELETE CASCADE,
+    class_id integer REFERENCES classes(class_id) ON DELETE CASCADE,
+    absences integer NOT NULL,
+    sessions integer NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    resolved_at timestamp(0) with time zone
+);
+
+-- Only one open alert per student and class, so repeated scans don't pile up duplicates.
+CREATE UNIQUE INDEX IF NOT EXISTS absence_alerts_open_idx ON absence_alerts (student_id, class_id) WHERE resolved_at IS NULL;
\ No newline at end of file
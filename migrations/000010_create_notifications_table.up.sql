@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id serial PRIMARY KEY,
+    faculty_id integer REFERENCES faculty(faculty_id) ON DELETE CASCADE,
+    subject text NOT NULL,
+    message text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    read_at timestamp(0) with time zone
+);
\ No newline at end of file
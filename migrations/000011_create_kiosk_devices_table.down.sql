@@ -0,0 +1 @@
+DROP TABLE IF EXISTS kiosk_devices;
\ No newline at end of file
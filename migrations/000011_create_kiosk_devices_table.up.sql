@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS kiosk_devices (
+    device_id serial PRIMARY KEY,
+    name text NOT NULL,
+    token_hash bytea UNIQUE NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    revoked_at timestamp(0) with time zone
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE guardians DROP COLUMN IF EXISTS pin_hash;
\ No newline at end of file
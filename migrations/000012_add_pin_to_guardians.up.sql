@@ -0,0 +1 @@
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS pin_hash bytea;
\ No newline at end of file
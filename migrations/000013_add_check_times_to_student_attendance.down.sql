@@ -0,0 +1,2 @@
+ALTER TABLE student_attendance DROP COLUMN IF EXISTS check_out_at;
+ALTER TABLE student_attendance DROP COLUMN IF EXISTS check_in_at;
\ No newline at end of file
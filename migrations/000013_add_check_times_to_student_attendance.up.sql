@@ -0,0 +1,2 @@
+ALTER TABLE student_attendance ADD COLUMN IF NOT EXISTS check_in_at timestamp(0) with time zone;
+ALTER TABLE student_attendance ADD COLUMN IF NOT EXISTS check_out_at timestamp(0) with time zone;
\ No newline at end of file
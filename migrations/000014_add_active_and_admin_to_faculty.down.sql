@@ -0,0 +1,2 @@
+ALTER TABLE faculty DROP COLUMN IF EXISTS is_admin;
+ALTER TABLE faculty DROP COLUMN IF EXISTS active;
\ No newline at end of file
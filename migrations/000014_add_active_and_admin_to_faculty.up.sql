@@ -0,0 +1,5 @@
+ALTER TABLE faculty ADD COLUMN IF NOT EXISTS active bool NOT NULL DEFAULT true;
+ALTER TABLE faculty ADD COLUMN IF NOT EXISTS is_admin bool NOT NULL DEFAULT false;
+
+-- Directors were only recognisable by their position so far, make them the first admins.
+UPDATE faculty SET is_admin = true WHERE position ILIKE 'director';
\ No newline at end of file
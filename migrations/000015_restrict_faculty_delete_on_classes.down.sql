@@ -0,0 +1,2 @@
+ALTER TABLE classes DROP CONSTRAINT IF EXISTS classes_faculty_id_fkey;
+ALTER TABLE classes ADD CONSTRAINT classes_faculty_id_fkey FOREIGN KEY (faculty_id) REFERENCES faculty(faculty_id) ON DELETE CASCADE;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Deleting a faculty member must not silently delete their classes, they have to be reassigned first.
+ALTER TABLE classes DROP CONSTRAINT IF EXISTS classes_faculty_id_fkey;
+ALTER TABLE classes ADD CONSTRAINT classes_faculty_id_fkey FOREIGN KEY (faculty_id) REFERENCES faculty(faculty_id) ON DELETE RESTRICT;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS time_entries (
+    entry_id serial PRIMARY KEY,
+    faculty_id integer NOT NULL REFERENCES faculty(faculty_id) ON DELETE CASCADE,
+    clock_in timestamp(0) with time zone NOT NULL,
+    clock_out timestamp(0) with time zone,
+    CHECK (clock_out IS NULL OR clock_out >= clock_in)
+);
+
+-- A faculty member can only be clocked in once at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS time_entries_open_idx ON time_entries (faculty_id) WHERE clock_out IS NULL;
\ No newline at end of file
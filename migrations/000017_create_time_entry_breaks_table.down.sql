@@ -0,0 +1 @@
+DROP TABLE IF EXISTS time_entry_breaks;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS time_entry_breaks (
+    break_id serial PRIMARY KEY,
+    entry_id integer NOT NULL REFERENCES time_entries(entry_id) ON DELETE CASCADE,
+    started_at timestamp(0) with time zone NOT NULL,
+    ended_at timestamp(0) with time zone,
+    CHECK (ended_at IS NULL OR ended_at >= started_at)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS time_entry_breaks_open_idx ON time_entry_breaks (entry_id) WHERE ended_at IS NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS time_entry_edits;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS time_entry_edits (
+    edit_id serial PRIMARY KEY,
+    entry_id integer NOT NULL REFERENCES time_entries(entry_id) ON DELETE CASCADE,
+    edited_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    reason text NOT NULL,
+    old_clock_in timestamp(0) with time zone NOT NULL,
+    old_clock_out timestamp(0) with time zone,
+    new_clock_in timestamp(0) with time zone NOT NULL,
+    new_clock_out timestamp(0) with time zone,
+    edited_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS shifts;
\ No newline at end of file
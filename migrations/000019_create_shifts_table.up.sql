@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS shifts (
+    shift_id serial PRIMARY KEY,
+    faculty_id integer NOT NULL REFERENCES faculty(faculty_id) ON DELETE CASCADE,
+    class_id integer NOT NULL REFERENCES classes(class_id) ON DELETE CASCADE,
+    starts_at timestamp(0) with time zone NOT NULL,
+    ends_at timestamp(0) with time zone NOT NULL,
+    notes text NOT NULL DEFAULT '',
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS shifts_faculty_id_idx ON shifts (faculty_id, starts_at);
+CREATE INDEX IF NOT EXISTS shifts_class_id_idx ON shifts (class_id, starts_at);
\ No newline at end of file
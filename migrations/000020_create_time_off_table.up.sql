@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS time_off (
+    time_off_id serial PRIMARY KEY,
+    faculty_id integer NOT NULL REFERENCES faculty(faculty_id) ON DELETE CASCADE,
+    starts_at timestamp(0) with time zone NOT NULL,
+    ends_at timestamp(0) with time zone NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    CHECK (ends_at > starts_at)
+);
\ No newline at end of file
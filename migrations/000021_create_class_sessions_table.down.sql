@@ -0,0 +1 @@
+DROP TABLE IF EXISTS class_sessions;
\ No newline at end of file
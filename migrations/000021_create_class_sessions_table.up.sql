@@ -0,0 +1,9 @@
+-- weekday follows Go and PostgreSQL: 0 is Sunday, 6 is Saturday.
+CREATE TABLE IF NOT EXISTS class_sessions (
+    session_id serial PRIMARY KEY,
+    class_id integer NOT NULL REFERENCES classes(class_id) ON DELETE CASCADE,
+    weekday smallint NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    starts_at time NOT NULL,
+    ends_at time NOT NULL,
+    CHECK (ends_at > starts_at)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS faculty_credentials;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS faculty_credentials (
+    credential_id serial PRIMARY KEY,
+    faculty_id integer NOT NULL REFERENCES faculty(faculty_id) ON DELETE CASCADE,
+    type text NOT NULL,
+    issued_on date NOT NULL,
+    expires_on date,
+    document_ref text NOT NULL DEFAULT '',
+    flagged_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CHECK (expires_on IS NULL OR expires_on >= issued_on)
+);
+
+CREATE INDEX IF NOT EXISTS faculty_credentials_faculty_id_idx ON faculty_credentials (faculty_id, type);
\ No newline at end of file
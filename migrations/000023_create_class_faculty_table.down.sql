@@ -0,0 +1 @@
+DROP TABLE IF EXISTS class_faculty;
\ No newline at end of file
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS class_faculty (
+    assignment_id serial PRIMARY KEY,
+    class_id integer NOT NULL REFERENCES classes(class_id) ON DELETE CASCADE,
+    faculty_id integer NOT NULL REFERENCES faculty(faculty_id) ON DELETE CASCADE,
+    role text NOT NULL CHECK (role IN ('lead', 'assistant', 'substitute')),
+    starts_on date NOT NULL DEFAULT CURRENT_DATE,
+    ends_on date,
+    CHECK (ends_on IS NULL OR ends_on >= starts_on)
+);
+
+CREATE INDEX IF NOT EXISTS class_faculty_class_id_idx ON class_faculty (class_id);
+CREATE INDEX IF NOT EXISTS class_faculty_faculty_id_idx ON class_faculty (faculty_id);
+
+-- Every existing class starts out with its faculty member as the lead.
+INSERT INTO class_faculty (class_id, faculty_id, role, starts_on)
+SELECT class_id, faculty_id, 'lead', CURRENT_DATE
+FROM classes
+WHERE faculty_id IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS coverage_grants;
\ No newline at end of file
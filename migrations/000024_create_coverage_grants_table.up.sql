@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS coverage_grants (
+    grant_id serial PRIMARY KEY,
+    class_id integer NOT NULL REFERENCES classes(class_id) ON DELETE CASCADE,
+    faculty_id integer NOT NULL REFERENCES faculty(faculty_id) ON DELETE CASCADE,
+    starts_at timestamp(0) with time zone NOT NULL,
+    ends_at timestamp(0) with time zone NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    status text NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    requested_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    decided_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    decided_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS coverage_grants_faculty_id_idx ON coverage_grants (faculty_id, class_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS coverage_audit;
\ No newline at end of file
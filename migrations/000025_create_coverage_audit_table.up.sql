@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS coverage_audit (
+    audit_id serial PRIMARY KEY,
+    grant_id integer NOT NULL REFERENCES coverage_grants(grant_id) ON DELETE CASCADE,
+    faculty_id integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    method text NOT NULL,
+    path text NOT NULL,
+    status integer NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS coverage_audit_grant_id_idx ON coverage_audit (grant_id);
\ No newline at end of file
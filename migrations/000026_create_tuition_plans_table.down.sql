@@ -0,0 +1 @@
+DROP TABLE IF EXISTS tuition_plans;
\ No newline at end of file
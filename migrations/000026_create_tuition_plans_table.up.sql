@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS tuition_plans (
+    plan_id serial PRIMARY KEY,
+    name text NOT NULL,
+    frequency text NOT NULL CHECK (frequency IN ('weekly', 'monthly')),
+    schedule text NOT NULL CHECK (schedule IN ('full_time', 'part_time')),
+    rate_cents bigint NOT NULL CHECK (rate_cents >= 0),
+    registration_fee_cents bigint NOT NULL DEFAULT 0 CHECK (registration_fee_cents >= 0),
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS student_plans;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS student_plans (
+    student_plan_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    plan_id integer NOT NULL REFERENCES tuition_plans(plan_id) ON DELETE RESTRICT,
+    starts_on date NOT NULL,
+    ends_on date,
+    CHECK (ends_on IS NULL OR ends_on >= starts_on)
+);
+
+CREATE INDEX IF NOT EXISTS student_plans_student_id_idx ON student_plans (student_id);
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS invoices (
+    invoice_id serial PRIMARY KEY,
+    guardian_id integer NOT NULL REFERENCES guardians(guardian_id) ON DELETE RESTRICT,
+    period_start date NOT NULL,
+    period_end date NOT NULL,
+    total_cents bigint NOT NULL,
+    status text NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'paid', 'void')),
+    due_on date NOT NULL,
+    issued_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CHECK (period_end >= period_start)
+);
+
+-- A billing run can be repeated for the same period without billing a family twice.
+CREATE UNIQUE INDEX IF NOT EXISTS invoices_guardian_period_idx ON invoices (guardian_id, period_start, period_end);
\ No newline at end of file
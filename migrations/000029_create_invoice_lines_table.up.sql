@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    line_id serial PRIMARY KEY,
+    invoice_id integer NOT NULL REFERENCES invoices(invoice_id) ON DELETE CASCADE,
+    student_id integer REFERENCES students(student_id) ON DELETE SET NULL,
+    student_plan_id integer REFERENCES student_plans(student_plan_id) ON DELETE SET NULL,
+    kind text NOT NULL,
+    description text NOT NULL,
+    quantity integer NOT NULL DEFAULT 1,
+    unit_cents bigint NOT NULL,
+    amount_cents bigint NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS invoice_lines_invoice_id_idx ON invoice_lines (invoice_id);
+
+-- The registration fee of a plan assignment is only ever billed once.
+CREATE UNIQUE INDEX IF NOT EXISTS invoice_lines_registration_idx ON invoice_lines (student_plan_id) WHERE kind = 'registration';
\ No newline at end of file
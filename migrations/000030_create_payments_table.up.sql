@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS payments (
+    payment_id serial PRIMARY KEY,
+    guardian_id integer NOT NULL REFERENCES guardians(guardian_id) ON DELETE RESTRICT,
+    amount_cents bigint NOT NULL CHECK (amount_cents > 0),
+    method text NOT NULL CHECK (method IN ('cash', 'cheque', 'bank_transfer')),
+    reference text NOT NULL DEFAULT '',
+    received_on date NOT NULL,
+    recorded_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS payments_guardian_id_idx ON payments (guardian_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS account_adjustments;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS account_adjustments (
+    adjustment_id serial PRIMARY KEY,
+    guardian_id integer NOT NULL REFERENCES guardians(guardian_id) ON DELETE RESTRICT,
+    kind text NOT NULL CHECK (kind IN ('credit', 'refund')),
+    amount_cents bigint NOT NULL CHECK (amount_cents > 0),
+    reason text NOT NULL,
+    recorded_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS account_adjustments_guardian_id_idx ON account_adjustments (guardian_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS subsidy_agencies;
\ No newline at end of file
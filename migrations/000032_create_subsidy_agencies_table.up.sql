@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS subsidy_agencies (
+    agency_id serial PRIMARY KEY,
+    name text NOT NULL,
+    code text NOT NULL UNIQUE,
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
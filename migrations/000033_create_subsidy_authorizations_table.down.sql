@@ -0,0 +1 @@
+DROP TABLE IF EXISTS subsidy_authorizations;
\ No newline at end of file
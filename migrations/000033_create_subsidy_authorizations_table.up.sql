@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS subsidy_authorizations (
+    authorization_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    agency_id integer NOT NULL REFERENCES subsidy_agencies(agency_id) ON DELETE RESTRICT,
+    case_number text NOT NULL,
+    starts_on date NOT NULL,
+    ends_on date,
+    authorized_days integer NOT NULL CHECK (authorized_days BETWEEN 1 AND 31),
+    daily_rate_cents bigint NOT NULL CHECK (daily_rate_cents >= 0),
+    copay_cents bigint NOT NULL DEFAULT 0 CHECK (copay_cents >= 0),
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CHECK (ends_on IS NULL OR ends_on >= starts_on)
+);
+
+CREATE INDEX IF NOT EXISTS subsidy_authorizations_agency_id_idx ON subsidy_authorizations (agency_id);
+CREATE INDEX IF NOT EXISTS subsidy_authorizations_student_id_idx ON subsidy_authorizations (student_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS late_fee_rules;
\ No newline at end of file
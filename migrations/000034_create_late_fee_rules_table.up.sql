@@ -0,0 +1,12 @@
+-- Late pickup fees are charged under the rule with the latest effective_from on or before the
+-- day of the pickup, so past fees keep the rule they were charged under.
+CREATE TABLE IF NOT EXISTS late_fee_rules (
+    rule_id serial PRIMARY KEY,
+    effective_from date NOT NULL UNIQUE,
+    closes_at time NOT NULL,
+    grace_minutes integer NOT NULL DEFAULT 0 CHECK (grace_minutes >= 0),
+    charge text NOT NULL CHECK (charge IN ('per_minute', 'per_block')),
+    block_minutes integer NOT NULL DEFAULT 1 CHECK (block_minutes > 0),
+    rate_cents bigint NOT NULL CHECK (rate_cents >= 0),
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS late_pickup_fees;
\ No newline at end of file
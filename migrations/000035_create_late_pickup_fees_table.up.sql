@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS late_pickup_fees (
+    fee_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    class_date date NOT NULL,
+    rule_id integer NOT NULL REFERENCES late_fee_rules(rule_id) ON DELETE RESTRICT,
+    checked_out_at timestamp(0) with time zone NOT NULL,
+    minutes_late integer NOT NULL CHECK (minutes_late > 0),
+    amount_cents bigint NOT NULL CHECK (amount_cents >= 0),
+    waived boolean NOT NULL DEFAULT false,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    UNIQUE (student_id, class_date)
+);
+
+CREATE INDEX IF NOT EXISTS late_pickup_fees_class_date_idx ON late_pickup_fees (class_date);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS invoice_lines_late_fee_idx;
+ALTER TABLE invoice_lines DROP COLUMN IF EXISTS late_fee_id;
\ No newline at end of file
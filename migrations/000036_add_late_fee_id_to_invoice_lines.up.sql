@@ -0,0 +1,4 @@
+ALTER TABLE invoice_lines ADD COLUMN IF NOT EXISTS late_fee_id integer REFERENCES late_pickup_fees(fee_id) ON DELETE SET NULL;
+
+-- A late pickup fee is only ever billed once.
+CREATE UNIQUE INDEX IF NOT EXISTS invoice_lines_late_fee_idx ON invoice_lines (late_fee_id) WHERE kind = 'late_pickup';
\ No newline at end of file
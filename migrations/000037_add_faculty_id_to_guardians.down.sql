@@ -0,0 +1 @@
+ALTER TABLE guardians DROP COLUMN IF EXISTS faculty_id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Guardians who work at the daycare are linked to their faculty account, which makes their
+-- children eligible for staff discounts.
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS faculty_id integer REFERENCES faculty(faculty_id) ON DELETE SET NULL;
\ No newline at end of file
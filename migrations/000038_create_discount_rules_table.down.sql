@@ -0,0 +1 @@
+DROP TABLE IF EXISTS discount_rules;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS discount_rules (
+    rule_id serial PRIMARY KEY,
+    name text NOT NULL,
+    applies_to text NOT NULL CHECK (applies_to IN ('sibling', 'staff')),
+    calculation text NOT NULL CHECK (calculation IN ('percent', 'fixed')),
+    value bigint NOT NULL CHECK (value > 0),
+    from_child integer NOT NULL DEFAULT 2 CHECK (from_child >= 2),
+    stackable boolean NOT NULL DEFAULT false,
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CHECK (calculation <> 'percent' OR value <= 100)
+);
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_method_check CHECK (method IN ('cash', 'cheque', 'bank_transfer'));
+
+ALTER TABLE payments DROP COLUMN IF EXISTS provider_ref;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider_ref text UNIQUE;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_method_check CHECK (method IN ('cash', 'cheque', 'bank_transfer', 'card'));
\ No newline at end of file
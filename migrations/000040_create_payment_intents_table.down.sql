@@ -0,0 +1 @@
+DROP TABLE IF EXISTS payment_intents;
\ No newline at end of file
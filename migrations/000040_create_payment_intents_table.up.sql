@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS payment_intents (
+    intent_id serial PRIMARY KEY,
+    provider text NOT NULL,
+    provider_ref text NOT NULL UNIQUE,
+    invoice_id integer NOT NULL REFERENCES invoices(invoice_id) ON DELETE RESTRICT,
+    guardian_id integer NOT NULL REFERENCES guardians(guardian_id) ON DELETE RESTRICT,
+    amount_cents bigint NOT NULL CHECK (amount_cents > 0),
+    status text NOT NULL CHECK (status IN ('requires_capture', 'succeeded', 'failed')),
+    created_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS payment_intents_invoice_id_idx ON payment_intents (invoice_id);
\ No newline at end of file
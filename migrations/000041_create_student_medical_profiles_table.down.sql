@@ -0,0 +1 @@
+DROP TABLE IF EXISTS student_medical_profiles;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS student_medical_profiles (
+    student_id integer PRIMARY KEY REFERENCES students(student_id) ON DELETE CASCADE,
+    conditions text NOT NULL DEFAULT '',
+    medications text NOT NULL DEFAULT '',
+    dietary_restrictions text NOT NULL DEFAULT '',
+    physician_name text NOT NULL DEFAULT '',
+    physician_phone text NOT NULL DEFAULT '',
+    insurance_provider text NOT NULL DEFAULT '',
+    insurance_policy_number text NOT NULL DEFAULT '',
+    updated_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
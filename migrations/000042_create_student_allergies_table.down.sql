@@ -0,0 +1 @@
+DROP TABLE IF EXISTS student_allergies;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS student_allergies (
+    allergy_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    allergen text NOT NULL,
+    severity text NOT NULL CHECK (severity IN ('mild', 'moderate', 'severe', 'life_threatening')),
+    reaction text NOT NULL DEFAULT '',
+    treatment text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS student_allergies_student_allergen_idx ON student_allergies (student_id, lower(allergen));
\ No newline at end of file
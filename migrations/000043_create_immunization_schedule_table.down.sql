@@ -0,0 +1 @@
+DROP TABLE IF EXISTS immunization_schedule;
\ No newline at end of file
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS immunization_schedule (
+    schedule_id serial PRIMARY KEY,
+    vaccine text NOT NULL,
+    dose integer NOT NULL CHECK (dose >= 1),
+    due_age_months integer NOT NULL CHECK (due_age_months >= 0),
+    grace_months integer NOT NULL DEFAULT 2 CHECK (grace_months >= 0),
+    UNIQUE (vaccine, dose)
+);
+
+-- The routine childhood schedule up to school age. Each dose is due at the given age and
+-- overdue once the grace period after it has passed.
+INSERT INTO immunization_schedule (vaccine, dose, due_age_months, grace_months) VALUES
+    ('HepB', 1, 0, 2),
+    ('HepB', 2, 1, 2),
+    ('HepB', 3, 6, 12),
+    ('DTaP', 1, 2, 2),
+    ('DTaP', 2, 4, 2),
+    ('DTaP', 3, 6, 2),
+    ('DTaP', 4, 15, 4),
+    ('DTaP', 5, 48, 24),
+    ('Hib', 1, 2, 2),
+    ('Hib', 2, 4, 2),
+    ('Hib', 3, 6, 2),
+    ('Hib', 4, 12, 4),
+    ('PCV', 1, 2, 2),
+    ('PCV', 2, 4, 2),
+    ('PCV', 3, 6, 2),
+    ('PCV', 4, 12, 4),
+    ('IPV', 1, 2, 2),
+    ('IPV', 2, 4, 2),
+    ('IPV', 3, 6, 12),
+    ('IPV', 4, 48, 24),
+    ('MMR', 1, 12, 4),
+    ('MMR', 2, 48, 24),
+    ('Varicella', 1, 12, 4),
+    ('Varicella', 2, 48, 24),
+    ('HepA', 1, 12, 12),
+    ('HepA', 2, 18, 12)
+ON CONFLICT (vaccine, dose) DO NOTHING;
\ No newline at end of file
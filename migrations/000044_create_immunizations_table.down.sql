@@ -0,0 +1 @@
+DROP TABLE IF EXISTS immunizations;
\ No newline at end of file
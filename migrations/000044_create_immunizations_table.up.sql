@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS immunizations (
+    immunization_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    vaccine text NOT NULL,
+    dose integer NOT NULL CHECK (dose >= 1),
+    administered_on date NOT NULL,
+    administered_by text NOT NULL DEFAULT '',
+    recorded_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS immunizations_student_vaccine_dose_idx ON immunizations (student_id, lower(vaccine), dose);
\ No newline at end of file
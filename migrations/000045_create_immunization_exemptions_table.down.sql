@@ -0,0 +1 @@
+DROP TABLE IF EXISTS immunization_exemptions;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS immunization_exemptions (
+    exemption_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    vaccine text NOT NULL DEFAULT '',
+    kind text NOT NULL CHECK (kind IN ('medical', 'religious', 'personal')),
+    document_ref text NOT NULL DEFAULT '',
+    expires_on date,
+    recorded_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS immunization_exemptions_student_id_idx ON immunization_exemptions (student_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS medication_authorizations;
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS medication_authorizations (
+    authorization_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    medication text NOT NULL,
+    dosage text NOT NULL,
+    route text NOT NULL DEFAULT '',
+    instructions text NOT NULL DEFAULT '',
+    times text NOT NULL DEFAULT '',
+    min_interval_minutes integer NOT NULL DEFAULT 120 CHECK (min_interval_minutes >= 0),
+    max_daily_doses integer NOT NULL CHECK (max_daily_doses >= 1),
+    starts_on date NOT NULL,
+    ends_on date NOT NULL,
+    signed_by integer REFERENCES guardians(guardian_id) ON DELETE SET NULL,
+    signed_at timestamp(0) with time zone,
+    revoked_at timestamp(0) with time zone,
+    created_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX IF NOT EXISTS medication_authorizations_student_id_idx ON medication_authorizations (student_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS medication_administrations;
\ No newline at end of file
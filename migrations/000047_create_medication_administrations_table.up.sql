@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS medication_administrations (
+    administration_id serial PRIMARY KEY,
+    authorization_id integer NOT NULL REFERENCES medication_authorizations(authorization_id) ON DELETE CASCADE,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    given_at timestamp(0) with time zone NOT NULL,
+    given_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    dose_given text NOT NULL,
+    notes text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS medication_administrations_authorization_given_at_idx ON medication_administrations (authorization_id, given_at);
\ No newline at end of file
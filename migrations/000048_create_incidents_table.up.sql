@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS incidents (
+    incident_id serial PRIMARY KEY,
+    class_id integer REFERENCES classes(class_id) ON DELETE SET NULL,
+    kind text NOT NULL CHECK (kind IN ('injury', 'illness', 'behavior', 'other')),
+    occurred_at timestamp(0) with time zone NOT NULL,
+    location text NOT NULL DEFAULT '',
+    description text NOT NULL,
+    first_aid text NOT NULL DEFAULT '',
+    witnesses text NOT NULL DEFAULT '',
+    status text NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'submitted', 'acknowledged')),
+    reported_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    submitted_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS incidents_occurred_at_idx ON incidents (occurred_at);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS incident_students;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS incident_students (
+    incident_id integer NOT NULL REFERENCES incidents(incident_id) ON DELETE CASCADE,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    acknowledged_by integer REFERENCES guardians(guardian_id) ON DELETE SET NULL,
+    acknowledged_at timestamp(0) with time zone,
+    PRIMARY KEY (incident_id, student_id)
+);
+
+CREATE INDEX IF NOT EXISTS incident_students_student_id_idx ON incident_students (student_id);
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS daily_log_entries (
+    entry_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    kind text NOT NULL CHECK (kind IN ('meal', 'nap', 'diaper', 'toilet', 'mood', 'note')),
+    occurred_at timestamp(0) with time zone NOT NULL,
+    ended_at timestamp(0) with time zone,
+    amount text CHECK (amount IN ('none', 'some', 'most', 'all')),
+    details text NOT NULL DEFAULT '',
+    notes text NOT NULL DEFAULT '',
+    recorded_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CHECK (ended_at IS NULL OR ended_at >= occurred_at)
+);
+
+CREATE INDEX IF NOT EXISTS daily_log_entries_student_occurred_at_idx ON daily_log_entries (student_id, occurred_at);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS menu_items;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS menu_items (
+    item_id serial PRIMARY KEY,
+    served_on date NOT NULL,
+    meal text NOT NULL CHECK (meal IN ('breakfast', 'am_snack', 'lunch', 'pm_snack', 'dinner')),
+    name text NOT NULL,
+    allergens text NOT NULL DEFAULT '',
+    created_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS menu_items_served_on_meal_name_idx ON menu_items (served_on, meal, lower(name));
\ No newline at end of file
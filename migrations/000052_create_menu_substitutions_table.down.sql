@@ -0,0 +1 @@
+DROP TABLE IF EXISTS menu_substitutions;
\ No newline at end of file
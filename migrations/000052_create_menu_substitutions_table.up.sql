@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS menu_substitutions (
+    substitution_id serial PRIMARY KEY,
+    item_id integer NOT NULL REFERENCES menu_items(item_id) ON DELETE CASCADE,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    substitute text NOT NULL,
+    notes text NOT NULL DEFAULT '',
+    recorded_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    UNIQUE (item_id, student_id)
+);
\ No newline at end of file
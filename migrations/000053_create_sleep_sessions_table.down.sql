@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sleep_sessions;
\ No newline at end of file
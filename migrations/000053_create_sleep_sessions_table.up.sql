@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS sleep_sessions (
+    session_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    class_id integer NOT NULL REFERENCES classes(class_id) ON DELETE CASCADE,
+    started_at timestamp(0) with time zone NOT NULL,
+    ended_at timestamp(0) with time zone,
+    started_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    ended_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    overdue_alerted_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CHECK (ended_at IS NULL OR ended_at >= started_at)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS sleep_sessions_open_student_idx ON sleep_sessions (student_id) WHERE ended_at IS NULL;
+CREATE INDEX IF NOT EXISTS sleep_sessions_class_started_at_idx ON sleep_sessions (class_id, started_at);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sleep_checks;
\ No newline at end of file
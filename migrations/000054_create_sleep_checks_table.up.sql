@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS sleep_checks (
+    check_id serial PRIMARY KEY,
+    session_id integer NOT NULL REFERENCES sleep_sessions(session_id) ON DELETE CASCADE,
+    checked_at timestamp(0) with time zone NOT NULL,
+    position text NOT NULL CHECK (position IN ('back', 'side', 'stomach')),
+    breathing text NOT NULL CHECK (breathing IN ('normal', 'irregular', 'labored')),
+    checked_by integer REFERENCES faculty(faculty_id) ON DELETE SET NULL,
+    notes text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS sleep_checks_session_checked_at_idx ON sleep_checks (session_id, checked_at);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS student_emergency_contacts;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS student_emergency_contacts (
+    contact_id serial PRIMARY KEY,
+    student_id integer NOT NULL REFERENCES students(student_id) ON DELETE CASCADE,
+    priority integer NOT NULL CHECK (priority >= 1),
+    name text NOT NULL,
+    relationship text NOT NULL DEFAULT '',
+    phone text NOT NULL,
+    alt_phone text NOT NULL DEFAULT '',
+    notes text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS student_emergency_contacts_student_priority_idx ON student_emergency_contacts (student_id, priority);
\ No newline at end of file
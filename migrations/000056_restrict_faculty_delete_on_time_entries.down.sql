@@ -0,0 +1,2 @@
+ALTER TABLE time_entries DROP CONSTRAINT IF EXISTS time_entries_faculty_id_fkey;
+ALTER TABLE time_entries ADD CONSTRAINT time_entries_faculty_id_fkey FOREIGN KEY (faculty_id) REFERENCES faculty(faculty_id) ON DELETE CASCADE;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Time entries are payroll records, so a faculty member who has clocked in can only be deactivated.
+ALTER TABLE time_entries DROP CONSTRAINT IF EXISTS time_entries_faculty_id_fkey;
+ALTER TABLE time_entries ADD CONSTRAINT time_entries_faculty_id_fkey FOREIGN KEY (faculty_id) REFERENCES faculty(faculty_id) ON DELETE RESTRICT;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS invoices_guardian_period_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS invoices_guardian_period_idx ON invoices (guardian_id, period_start, period_end);
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Voided invoices don't count, so the period can be billed again once an invoice is voided. Overlapping
+-- periods are refused when the invoice is saved.
+DROP INDEX IF EXISTS invoices_guardian_period_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS invoices_guardian_period_idx ON invoices (guardian_id, period_start, period_end) WHERE status <> 'void';
\ No newline at end of file
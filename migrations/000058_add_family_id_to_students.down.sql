@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS students_family_id_idx;
+ALTER TABLE students DROP COLUMN IF EXISTS family_id;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- A student's family is the account they're billed to, identified by the guardian it's billed through.
+-- It's set when the student is registered and only changes when an admin moves the student, so
+-- linking another guardian later doesn't move invoices and payments between accounts. Existing
+-- students keep the account they were billed to so far.
+ALTER TABLE students ADD COLUMN IF NOT EXISTS family_id integer REFERENCES guardians(guardian_id) ON DELETE SET NULL;
+UPDATE students s SET family_id = (SELECT min(guardian_id) FROM student_guardian sg WHERE sg.student_id = s.student_id);
+CREATE INDEX IF NOT EXISTS students_family_id_idx ON students (family_id);
\ No newline at end of file
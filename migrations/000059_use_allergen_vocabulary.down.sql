@@ -0,0 +1,5 @@
+ALTER TABLE student_allergies DROP CONSTRAINT IF EXISTS student_allergies_allergen_check;
+DROP INDEX IF EXISTS student_allergies_student_allergen_idx;
+UPDATE student_allergies SET allergen = details WHERE allergen = 'other' AND details <> '';
+ALTER TABLE student_allergies DROP COLUMN IF EXISTS details;
+CREATE UNIQUE INDEX IF NOT EXISTS student_allergies_student_allergen_idx ON student_allergies (student_id, lower(allergen));
\ No newline at end of file
@@ -0,0 +1,46 @@
+-- Allergies and menu items use a fixed vocabulary of allergens, the major food allergens and other,
+-- so that they match however staff spell them. An allergy to something else is recorded as other
+-- with what it is in details. Existing free text is mapped onto the vocabulary, and anything that
+-- doesn't map is kept in details as other.
+CREATE OR REPLACE FUNCTION allergen_category(allergen text) RETURNS text AS $$
+    SELECT CASE regexp_replace(lower(trim(allergen)), '[[:space:]-]+', '_', 'g')
+        WHEN 'milk' THEN 'milk' WHEN 'dairy' THEN 'milk' WHEN 'lactose' THEN 'milk'
+        WHEN 'egg' THEN 'egg' WHEN 'eggs' THEN 'egg'
+        WHEN 'peanut' THEN 'peanut' WHEN 'peanuts' THEN 'peanut'
+        WHEN 'tree_nut' THEN 'tree_nut' WHEN 'tree_nuts' THEN 'tree_nut' WHEN 'nut' THEN 'tree_nut' WHEN 'nuts' THEN 'tree_nut'
+        WHEN 'soy' THEN 'soy' WHEN 'soya' THEN 'soy'
+        WHEN 'wheat' THEN 'wheat' WHEN 'gluten' THEN 'wheat'
+        WHEN 'fish' THEN 'fish'
+        WHEN 'shellfish' THEN 'shellfish' WHEN 'crustaceans' THEN 'shellfish' WHEN 'shrimp' THEN 'shellfish'
+        WHEN 'sesame' THEN 'sesame'
+        ELSE 'other'
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+DROP INDEX IF EXISTS student_allergies_student_allergen_idx;
+ALTER TABLE student_allergies ADD COLUMN IF NOT EXISTS details text NOT NULL DEFAULT '';
+
+UPDATE student_allergies
+SET details = CASE WHEN allergen_category(allergen) = 'other' AND lower(trim(allergen)) <> 'other' THEN trim(allergen) ELSE details END,
+    allergen = allergen_category(allergen);
+
+-- Allergies that now say the same thing are merged into the most severe one.
+DELETE FROM student_allergies a
+USING student_allergies b
+WHERE a.student_id = b.student_id AND a.allergen = b.allergen AND lower(a.details) = lower(b.details)
+    AND (CASE a.severity WHEN 'life_threatening' THEN 0 WHEN 'severe' THEN 1 WHEN 'moderate' THEN 2 ELSE 3 END, a.allergy_id)
+        > (CASE b.severity WHEN 'life_threatening' THEN 0 WHEN 'severe' THEN 1 WHEN 'moderate' THEN 2 ELSE 3 END, b.allergy_id);
+
+ALTER TABLE student_allergies ADD CONSTRAINT student_allergies_allergen_check
+    CHECK (allergen IN ('milk', 'egg', 'peanut', 'tree_nut', 'soy', 'wheat', 'fish', 'shellfish', 'sesame', 'other'));
+CREATE UNIQUE INDEX IF NOT EXISTS student_allergies_student_allergen_idx ON student_allergies (student_id, allergen, lower(details));
+
+UPDATE menu_items
+SET allergens = COALESCE((
+    SELECT string_agg(DISTINCT allergen_category(tag), ',')
+    FROM unnest(string_to_array(allergens, ',')) tag
+    WHERE trim(tag) <> ''
+), '')
+WHERE allergens <> '';
+
+DROP FUNCTION allergen_category(text);
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- The plan each registration fee on a voided invoice was billed for can't be told apart from the
+-- invoice alone, so the lines stay unlinked.
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Registration fees on voided invoices are billed again, so they no longer hold the plan's place in
+-- invoice_lines_registration_idx.
+UPDATE invoice_lines il
+SET student_plan_id = NULL
+FROM invoices i
+WHERE il.invoice_id = i.invoice_id AND il.kind = 'registration' AND i.status = 'void';
\ No newline at end of file
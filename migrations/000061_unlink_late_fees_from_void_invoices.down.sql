@@ -0,0 +1,2 @@
+-- The fee each late pickup line on a voided invoice was billed for isn't kept, so the lines stay
+-- unlinked.
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Late pickup fees on voided invoices are billed again, so they no longer hold the fee's place in
+-- invoice_lines_late_fee_idx.
+UPDATE invoice_lines il
+SET late_fee_id = NULL
+FROM invoices i
+WHERE il.invoice_id = i.invoice_id AND il.kind = 'late_pickup' AND i.status = 'void';
\ No newline at end of file